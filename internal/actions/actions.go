@@ -0,0 +1,373 @@
+// Package actions describes replayable light commands shared by timers and
+// schedules, and converts them to WiZ requests.
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+)
+
+// Kind names what an Action does.
+type Kind string
+
+// Supported action kinds.
+const (
+	Off        Kind = "off"
+	On         Kind = "on"
+	Color      Kind = "color"
+	White      Kind = "white"
+	Scene      Kind = "scene"
+	Brightness Kind = "brightness"
+	Preset     Kind = "preset"
+	// Sunrise ramps from deep red to bright cool white over the fade; without
+	// a fade it switches straight to the final state.
+	Sunrise Kind = "sunrise"
+)
+
+// ErrInvalid is returned for malformed or incomplete actions.
+var ErrInvalid = errors.New("invalid action")
+
+// Action is a light command that can be stored and replayed later.
+// Brightness is optional for on, color, white, and scene actions.
+type Action struct {
+	Kind       Kind   `json:"kind"`
+	ColorHex   string `json:"color,omitempty"`
+	Temp       int    `json:"temp,omitempty"`
+	Scene      string `json:"scene,omitempty"`
+	Speed      int    `json:"speed,omitempty"`
+	Brightness int    `json:"brightness,omitempty"`
+	Preset     string `json:"preset,omitempty"`
+}
+
+// Parse reads an action from JSON or from the compact form used on the
+// command line: "off", "on", "sunrise", "color=#FF8800", "white=2700", "scene=Ocean",
+// "brightness=30", or "preset=Reading", optionally followed by
+// ",brightness=N" and, for scenes, ",speed=N".
+func Parse(spec string) (Action, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "{") {
+		var action Action
+		if err := json.Unmarshal([]byte(spec), &action); err != nil {
+			return Action{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+		return action, action.Validate()
+	}
+
+	var action Action
+	for index, part := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if index == 0 {
+			action.Kind = Kind(key)
+		}
+		switch Kind(key) {
+		case Off, On, Sunrise:
+		case Color:
+			action.ColorHex = strings.ToUpper(value)
+		case White:
+			temp, err := strconv.Atoi(strings.TrimSuffix(strings.ToUpper(value), "K"))
+			if err != nil {
+				return Action{}, fmt.Errorf("%w: white temperature %q", ErrInvalid, value)
+			}
+			action.Temp = temp
+		case Scene:
+			action.Scene = value
+		case Preset:
+			action.Preset = value
+		case Brightness:
+			level, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil {
+				return Action{}, fmt.Errorf("%w: brightness %q", ErrInvalid, value)
+			}
+			action.Brightness = level
+		case "speed":
+			speed, err := strconv.Atoi(value)
+			if err != nil {
+				return Action{}, fmt.Errorf("%w: speed %q", ErrInvalid, value)
+			}
+			action.Speed = speed
+		default:
+			return Action{}, fmt.Errorf("%w: unknown key %q", ErrInvalid, key)
+		}
+	}
+	return action, action.Validate()
+}
+
+// Validate checks that the fields required by the action kind are present and in range.
+func (a Action) Validate() error {
+	if a.Brightness != 0 && (a.Brightness < 10 || a.Brightness > 100) {
+		return fmt.Errorf("%w: brightness must be 10-100, got %d", ErrInvalid, a.Brightness)
+	}
+	switch a.Kind {
+	case Off, On, Sunrise:
+	case Color:
+		if _, _, _, err := wiz.HexToRGB(a.ColorHex); err != nil {
+			return fmt.Errorf("%w: color %q: %w", ErrInvalid, a.ColorHex, err)
+		}
+	case White:
+		if a.Temp < wiz.MinKelvin || a.Temp > wiz.MaxKelvin {
+			return fmt.Errorf("%w: white must be %d-%dK, got %d", ErrInvalid, wiz.MinKelvin, wiz.MaxKelvin, a.Temp)
+		}
+	case Scene:
+		if _, err := a.scene(); err != nil {
+			return err
+		}
+	case Brightness:
+		if a.Brightness == 0 {
+			return fmt.Errorf("%w: brightness level is required", ErrInvalid)
+		}
+	case Preset:
+		if strings.TrimSpace(a.Preset) == "" {
+			return fmt.Errorf("%w: preset name is required", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalid, a.Kind)
+	}
+	return nil
+}
+
+// String renders the action in the compact form accepted by Parse.
+func (a Action) String() string {
+	var parts []string
+	switch a.Kind {
+	case Color:
+		parts = append(parts, "color="+strings.ToUpper(a.ColorHex))
+	case White:
+		parts = append(parts, fmt.Sprintf("white=%d", a.Temp))
+	case Scene:
+		parts = append(parts, "scene="+a.Scene)
+	case Preset:
+		parts = append(parts, "preset="+a.Preset)
+	case Brightness:
+		return fmt.Sprintf("brightness=%d", a.Brightness)
+	default:
+		parts = append(parts, string(a.Kind))
+	}
+	if a.Brightness > 0 {
+		parts = append(parts, fmt.Sprintf("brightness=%d", a.Brightness))
+	}
+	if a.Kind == Scene && a.Speed > 0 {
+		parts = append(parts, fmt.Sprintf("speed=%d", a.Speed))
+	}
+	return strings.Join(parts, ",")
+}
+
+// JSON returns the structured form passed to detached workers.
+func (a Action) JSON() string {
+	data, _ := json.Marshal(a)
+	return string(data)
+}
+
+// Resolve replaces a preset action with the stored preset's state.
+func (a Action) Resolve(presets []config.Preset) (Action, error) {
+	if a.Kind != Preset {
+		return a, nil
+	}
+	for _, preset := range presets {
+		if strings.EqualFold(strings.TrimSpace(preset.Name), strings.TrimSpace(a.Preset)) {
+			resolved := FromPreset(preset)
+			if a.Brightness > 0 {
+				resolved.Brightness = a.Brightness
+			}
+			return resolved, resolved.Validate()
+		}
+	}
+	names := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		names = append(names, preset.Name)
+	}
+	sort.Strings(names)
+	return Action{}, fmt.Errorf("%w: unknown preset %q (saved: %s)", ErrInvalid, a.Preset, strings.Join(names, ", "))
+}
+
+// FromPreset converts a saved preset into the action that reproduces it.
+func FromPreset(preset config.Preset) Action {
+	action := Action{Kind: On, Brightness: preset.Brightness}
+	switch {
+	case !preset.Power:
+		return Action{Kind: Off}
+	case preset.Scene != "":
+		action.Kind = Scene
+		action.Scene = preset.Scene
+		action.Speed = preset.Speed
+	case preset.ColorHex != "":
+		action.Kind = Color
+		action.ColorHex = preset.ColorHex
+	case preset.Temp > 0:
+		action.Kind = White
+		action.Temp = preset.Temp
+	}
+	return action
+}
+
+// Request returns the WiZ method and params for a resolved action.
+func (a Action) Request() (string, map[string]interface{}, error) {
+	if err := a.Validate(); err != nil {
+		return "", nil, err
+	}
+	params := map[string]interface{}{}
+	switch a.Kind {
+	case Off:
+		return "setState", map[string]interface{}{"state": false}, nil
+	case On:
+		if a.Brightness == 0 {
+			return "setState", map[string]interface{}{"state": true}, nil
+		}
+		params["state"] = true
+	case Color:
+		r, g, b, _ := wiz.HexToRGB(a.ColorHex)
+		params["r"], params["g"], params["b"] = r, g, b
+	case White:
+		params["temp"] = a.Temp
+	case Scene:
+		scene, _ := a.scene()
+		speed := a.Speed
+		if speed == 0 {
+			speed = wiz.DefaultSceneSpeed
+		}
+		params = wiz.SceneParams(scene, wiz.ClampSceneSpeed(speed))
+	case Sunrise:
+		params["temp"] = 6500
+		params["dimming"] = 100
+		return "setPilot", params, nil
+	case Brightness:
+	case Preset:
+		return "", nil, fmt.Errorf("%w: preset %q must be resolved first", ErrInvalid, a.Preset)
+	}
+	if a.Brightness > 0 {
+		params["dimming"] = a.Brightness
+	}
+	return "setPilot", params, nil
+}
+
+// Target returns the pilot state a fade should end in, and false for actions
+// that cannot be faded, such as scenes.
+func (a Action) Target() (wiz.PilotState, bool) {
+	switch a.Kind {
+	case Off:
+		return wiz.PilotState{Power: false}, true
+	case On, Brightness:
+		return wiz.PilotState{Power: true, Brightness: a.Brightness}, true
+	case Color:
+		return wiz.PilotState{Power: true, Brightness: a.Brightness, ColorHex: strings.ToUpper(a.ColorHex)}, true
+	case White:
+		return wiz.PilotState{Power: true, Brightness: a.Brightness, Temp: a.Temp}, true
+	}
+	return wiz.PilotState{}, false
+}
+
+func (a Action) scene() (wiz.Scene, error) {
+	if id, err := strconv.Atoi(a.Scene); err == nil {
+		if scene, ok := wiz.SceneByID(id); ok {
+			return scene, nil
+		}
+	}
+	if scene, ok := wiz.SceneByName(a.Scene); ok {
+		return scene, nil
+	}
+	return wiz.Scene{}, fmt.Errorf("%w: unknown scene %q", ErrInvalid, a.Scene)
+}
+
+// Targets returns the members of a named group, or the single ip:port target
+// when group is empty.
+func Targets(cfg config.Config, group, ip, port string) ([]wiz.Target, error) {
+	if strings.TrimSpace(group) == "" {
+		if err := config.Validate(ip, port); err != nil {
+			return nil, err
+		}
+		return []wiz.Target{{IP: ip, Port: port}}, nil
+	}
+	found, ok := cfg.FindGroup(group)
+	if !ok {
+		return nil, fmt.Errorf("unknown group %q", group)
+	}
+	members := cfg.GroupMembers(found)
+	if len(members) == 0 {
+		return nil, fmt.Errorf("group %q has no saved members", found.Name)
+	}
+	targets := make([]wiz.Target, 0, len(members))
+	for _, member := range members {
+		targets = append(targets, wiz.Target{Name: member.Name, IP: member.IP, Port: member.Port})
+	}
+	return targets, nil
+}
+
+// ResolveByMAC returns a copy of saved with each device moved to the address
+// discovery found for its MAC, since DHCP can hand bulbs new IPs. Devices
+// without a MAC or not found keep their saved address.
+func ResolveByMAC(saved []config.SavedDevice, discovered []wiz.Device) []config.SavedDevice {
+	deviceByMAC := map[string]wiz.Device{}
+	for _, device := range discovered {
+		mac := config.NormalizeMAC(device.Mac)
+		if mac == "" {
+			continue
+		}
+		deviceByMAC[mac] = device
+	}
+
+	resolved := make([]config.SavedDevice, len(saved))
+	copy(resolved, saved)
+	for index := range resolved {
+		mac := config.NormalizeMAC(resolved[index].Mac)
+		if mac == "" {
+			continue
+		}
+		if device, ok := deviceByMAC[mac]; ok && device.IP != "" {
+			resolved[index].IP = device.IP
+			if device.Port != "" {
+				resolved[index].Port = device.Port
+			}
+		}
+	}
+	return resolved
+}
+
+// Lead returns how long before a deadline Run must start so the action
+// finishes on time: fade when Run would fade the resolved action, and zero
+// when it is sent at once, such as a scene or a preset that resolves to one.
+func Lead(action Action, presets []config.Preset, fade time.Duration) time.Duration {
+	if fade <= 0 {
+		return 0
+	}
+	resolved, err := action.Resolve(presets)
+	if err != nil {
+		return 0
+	}
+	if _, ok := resolved.Target(); ok || resolved.Kind == Sunrise {
+		return fade
+	}
+	return 0
+}
+
+// Run applies an action to every target, resolving presets first. When fade
+// is positive and the action has a fade target, each device fades from its
+// current state, and a sunrise ramps over the fade; otherwise the command is
+// sent immediately.
+func Run(ctx context.Context, targets []wiz.Target, action Action, presets []config.Preset, fade time.Duration) ([]wiz.Result, error) {
+	resolved, err := action.Resolve(presets)
+	if err != nil {
+		return nil, err
+	}
+	if resolved.Kind == Sunrise && fade > 0 {
+		return wiz.BroadcastSunrise(ctx, targets, fade), nil
+	}
+	if to, ok := resolved.Target(); ok && fade > 0 {
+		return wiz.BroadcastFade(ctx, targets, to, fade), nil
+	}
+	method, params, err := resolved.Request()
+	if err != nil {
+		return nil, err
+	}
+	return wiz.Broadcast(ctx, targets, method, params), nil
+}
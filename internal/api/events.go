@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+)
+
+const (
+	// DefaultPollInterval is how often GET /events polls watched devices.
+	DefaultPollInterval = 2 * time.Second
+	// keepAliveInterval spaces comment lines that stop proxies from closing idle streams.
+	keepAliveInterval = 15 * time.Second
+	// subscriberBuffer is how many events a slow client may fall behind
+	// before it is disconnected to reconnect and start from a fresh snapshot.
+	subscriberBuffer = 64
+)
+
+// Event is one message on GET /events, sent when a saved device's power,
+// brightness, color, scene, or reachability changes. State uses the
+// wiz.PilotState schema and is omitted while the device is unreachable.
+type Event struct {
+	Mac       string          `json:"mac"`
+	Target    wiz.Target      `json:"target"`
+	Reachable bool            `json:"reachable"`
+	State     *wiz.PilotState `json:"state,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Time      time.Time       `json:"time"`
+}
+
+// changed reports whether next differs from prev in anything a dashboard shows.
+func (e Event) changed(prev Event) bool {
+	if e.Reachable != prev.Reachable {
+		return true
+	}
+	if e.State == nil || prev.State == nil {
+		return e.State != prev.State
+	}
+	return *e.State != *prev.State
+}
+
+// subscriber is one connected event stream.
+type subscriber struct {
+	// macs limits the stream to these devices; nil streams every device.
+	macs   map[string]bool
+	events chan Event
+}
+
+func (sub *subscriber) wants(mac string) bool {
+	return sub.macs == nil || sub.macs[mac]
+}
+
+// subscribe registers a stream, starting the poller for the first one, and
+// returns the latest known event for each device it wants.
+func (s *Server) subscribe(macs map[string]bool) (*subscriber, []Event) {
+	sub := &subscriber{macs: macs, events: make(chan Event, subscriberBuffer)}
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.subscribers == nil {
+		s.subscribers = map[*subscriber]bool{}
+	}
+	s.subscribers[sub] = true
+	if s.stopWatch == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopWatch = cancel
+		s.latest = map[string]Event{}
+		go s.watch(ctx)
+	}
+
+	var snapshot []Event
+	for mac, event := range s.latest {
+		if sub.wants(mac) {
+			snapshot = append(snapshot, event)
+		}
+	}
+	return sub, snapshot
+}
+
+// unsubscribe removes a stream and stops polling when none are left.
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	// publish may already have dropped a slow subscriber.
+	if s.subscribers[sub] {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+	if len(s.subscribers) == 0 && s.stopWatch != nil {
+		s.stopWatch()
+		s.stopWatch, s.latest = nil, nil
+	}
+}
+
+// publish records an event and forwards it to interested streams if the
+// device's state or reachability changed.
+func (s *Server) publish(event Event) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.latest == nil {
+		return
+	}
+	if prev, ok := s.latest[event.Mac]; ok && !event.changed(prev) {
+		return
+	}
+	s.latest[event.Mac] = event
+	for sub := range s.subscribers {
+		if !sub.wants(event.Mac) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// watch polls every saved device until ctx is cancelled.
+func (s *Server) watch(ctx context.Context) {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	rescan := false
+	for {
+		rescan = s.poll(ctx, rescan)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads every saved device's state once and publishes the results. It
+// reports whether any device was unreachable, in which case the next poll
+// rescans (when the cache is stale) in case it moved to a new address.
+func (s *Server) poll(ctx context.Context, rescan bool) bool {
+	found := s.cached()
+	if len(found) == 0 || rescan {
+		found = s.discovered(ctx)
+	}
+	cfg, err := s.resolvedConfig(found)
+	if err != nil {
+		return false
+	}
+
+	var (
+		targets []wiz.Target
+		macs    []string
+	)
+	for _, saved := range cfg.SavedDevices {
+		mac := config.NormalizeMAC(saved.Mac)
+		if mac == "" {
+			continue
+		}
+		port := saved.Port
+		if port == "" {
+			port = cfg.Port
+		}
+		targets = append(targets, wiz.Target{Name: saved.Name, IP: saved.IP, Port: port})
+		macs = append(macs, mac)
+	}
+
+	states := make([]wiz.PilotState, len(targets))
+	results := wiz.ForEach(targets, func(i int, target wiz.Target) error {
+		state, err := s.Client.GetPilotStateContext(ctx, target.IP, target.Port)
+		states[i] = state
+		return err
+	})
+	if ctx.Err() != nil {
+		return false
+	}
+
+	unreachable := false
+	now := time.Now()
+	for i, result := range results {
+		event := Event{Mac: macs[i], Target: result.Target, Reachable: result.Err == nil, Time: now}
+		if result.Err != nil {
+			event.Error = result.Err.Error()
+			unreachable = true
+		} else {
+			event.State = &states[i]
+		}
+		s.publish(event)
+	}
+	return unreachable
+}
+
+// events streams device changes as Server-Sent Events. Clients may pass
+// mac (repeatable) or group to limit the stream; each connection starts
+// with the latest known state of every device it watches.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	macs, status, err := s.eventFilter(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sub, snapshot := s.subscribe(macs)
+	defer s.unsubscribe(sub)
+	for _, event := range snapshot {
+		if writeEvent(w, event) != nil {
+			return
+		}
+	}
+	if controller.Flush() != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.events:
+			if !ok || writeEvent(w, event) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if controller.Flush() != nil {
+			return
+		}
+	}
+}
+
+// eventFilter reads the mac and group query parameters into a set of MACs,
+// or nil to stream every device.
+func (s *Server) eventFilter(r *http.Request) (map[string]bool, int, error) {
+	query := r.URL.Query()
+	if len(query["mac"]) == 0 && query.Get("group") == "" {
+		return nil, http.StatusOK, nil
+	}
+	macs := map[string]bool{}
+	for _, text := range query["mac"] {
+		mac := config.NormalizeMAC(text)
+		if mac == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid MAC %q", text)
+		}
+		macs[mac] = true
+	}
+	if name := query.Get("group"); name != "" {
+		cfg, err := s.Config()
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		group, ok := cfg.FindGroup(name)
+		if !ok {
+			return nil, http.StatusNotFound, fmt.Errorf("unknown group %q", name)
+		}
+		for _, member := range group.Members {
+			if mac := config.NormalizeMAC(member); mac != "" {
+				macs[mac] = true
+			}
+		}
+	}
+	return macs, http.StatusOK, nil
+}
+
+func writeEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: state\ndata: %s\n\n", data)
+	return err
+}
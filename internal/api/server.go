@@ -0,0 +1,399 @@
+// Package api serves saved WiZ devices and groups over a local HTTP JSON API,
+// reusing the client's discovery and retry logic.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+)
+
+// DefaultRescanInterval is how long discovery results are reused before a
+// device listing or an unknown MAC triggers a new scan.
+const DefaultRescanInterval = 30 * time.Second
+
+// maxBodyBytes bounds request bodies; setPilot params are a handful of fields.
+const maxBodyBytes = 64 << 10
+
+// Server exposes the WiZ client over HTTP:
+//
+//	GET  /devices               saved and discovered devices
+//	GET  /devices/{mac}/state   current pilot state of one device
+//	POST /devices/{mac}/pilot   send setPilot params to one device
+//	POST /groups/{name}/pilot   send setPilot params to every group member
+//	GET  /events                Server-Sent Events of saved device state changes
+//
+// Saved devices are addressed by MAC and follow the IP discovery last saw
+// them at, so DHCP changes do not break clients.
+type Server struct {
+	// Config returns the current config. It is called per request so edits
+	// made in the TUI apply without a restart.
+	Config func() (config.Config, error)
+	// Client sends commands and runs discovery.
+	Client *wiz.Client
+	// RescanInterval bounds how often discovery runs; DefaultRescanInterval when zero.
+	RescanInterval time.Duration
+	// PollInterval is how often GET /events polls devices while any stream
+	// is connected; DefaultPollInterval when zero.
+	PollInterval time.Duration
+	// Logf reports each request when set.
+	Logf func(format string, args ...interface{})
+
+	scanMu  sync.Mutex
+	mu      sync.Mutex
+	found   map[string]wiz.Device
+	scanned time.Time
+
+	watchMu     sync.Mutex
+	subscribers map[*subscriber]bool
+	latest      map[string]Event
+	stopWatch   context.CancelFunc
+}
+
+// Device is one entry of GET /devices. Saved devices that did not answer
+// discovery are listed with Online false and their saved address.
+type Device struct {
+	wiz.Device
+	Saved  bool     `json:"saved"`
+	Online bool     `json:"online"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Handler returns the API's HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices", s.listDevices)
+	mux.HandleFunc("GET /devices/{mac}/state", s.deviceState)
+	mux.HandleFunc("POST /devices/{mac}/pilot", s.devicePilot)
+	mux.HandleFunc("POST /groups/{name}/pilot", s.groupPilot)
+	mux.HandleFunc("GET /events", s.events)
+	if s.Logf == nil {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		mux.ServeHTTP(recorder, r)
+		s.Logf("%s %s %d %s", r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// Refresh runs discovery now and caches the devices found.
+func (s *Server) Refresh(ctx context.Context) ([]wiz.Device, error) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+	devices, err := s.Client.DiscoverDevicesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]wiz.Device, len(devices))
+	for _, device := range devices {
+		if mac := config.NormalizeMAC(device.Mac); mac != "" {
+			found[mac] = device
+		}
+	}
+	s.mu.Lock()
+	s.found, s.scanned = found, time.Now()
+	s.mu.Unlock()
+	return devices, nil
+}
+
+// discovered returns the cached discovery results, rescanning first when they
+// are older than the rescan interval.
+func (s *Server) discovered(ctx context.Context) map[string]wiz.Device {
+	interval := s.RescanInterval
+	if interval <= 0 {
+		interval = DefaultRescanInterval
+	}
+	s.mu.Lock()
+	stale := time.Since(s.scanned) >= interval
+	s.mu.Unlock()
+	if stale {
+		// A failed scan keeps serving the previous results.
+		_, _ = s.Refresh(ctx)
+	}
+	return s.cached()
+}
+
+func (s *Server) cached() map[string]wiz.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := make(map[string]wiz.Device, len(s.found))
+	for mac, device := range s.found {
+		found[mac] = device
+	}
+	return found
+}
+
+// resolvedConfig loads the config with saved devices moved to the addresses
+// discovery found for their MACs.
+func (s *Server) resolvedConfig(found map[string]wiz.Device) (config.Config, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return config.Config{}, err
+	}
+	if cfg.Port == "" {
+		cfg.Port = "38899"
+	}
+	devices := make([]wiz.Device, 0, len(found))
+	for _, device := range found {
+		devices = append(devices, device)
+	}
+	cfg.SavedDevices = actions.ResolveByMAC(cfg.SavedDevices, devices)
+	return cfg, nil
+}
+
+// deviceTarget finds a saved or discovered device by MAC. It scans when
+// nothing has been discovered yet, when the MAC is unknown, or when rescan is
+// set, in each case only if the cached results are stale.
+func (s *Server) deviceTarget(ctx context.Context, mac string, rescan bool) (wiz.Target, int, error) {
+	mac = config.NormalizeMAC(mac)
+	if mac == "" {
+		return wiz.Target{}, http.StatusBadRequest, errors.New("device must be addressed by a 12-digit MAC")
+	}
+	lookup := func(found map[string]wiz.Device) (wiz.Target, bool, error) {
+		cfg, err := s.resolvedConfig(found)
+		if err != nil {
+			return wiz.Target{}, false, err
+		}
+		for _, saved := range cfg.SavedDevices {
+			if config.NormalizeMAC(saved.Mac) == mac {
+				port := saved.Port
+				if port == "" {
+					port = cfg.Port
+				}
+				return wiz.Target{Name: saved.Name, IP: saved.IP, Port: port}, true, nil
+			}
+		}
+		if device, ok := found[mac]; ok {
+			return wiz.Target{Name: device.Name, IP: device.IP, Port: device.Port}, true, nil
+		}
+		return wiz.Target{}, false, nil
+	}
+
+	found := s.cached()
+	if len(found) == 0 || rescan {
+		found = s.discovered(ctx)
+	}
+	target, ok, err := lookup(found)
+	if err == nil && !ok {
+		target, ok, err = lookup(s.discovered(ctx))
+	}
+	switch {
+	case err != nil:
+		return wiz.Target{}, http.StatusInternalServerError, err
+	case !ok:
+		return wiz.Target{}, http.StatusNotFound, fmt.Errorf("no saved or discovered device with MAC %s", mac)
+	}
+	return target, http.StatusOK, nil
+}
+
+// sendToDevice runs fn against the device with the given MAC. When it fails
+// and a rescan finds the device at a new address, such as after a DHCP lease
+// change, fn is retried there once.
+func (s *Server) sendToDevice(ctx context.Context, mac string, fn func(wiz.Target) error) (wiz.Result, int, error) {
+	target, status, err := s.deviceTarget(ctx, mac, false)
+	if err != nil {
+		return wiz.Result{}, status, err
+	}
+	run := func(_ int, target wiz.Target) error { return fn(target) }
+	result := wiz.ForEach([]wiz.Target{target}, run)[0]
+	if result.Err == nil || ctx.Err() != nil {
+		return result, http.StatusOK, nil
+	}
+	if moved, _, err := s.deviceTarget(ctx, mac, true); err == nil && moved != target {
+		result = wiz.ForEach([]wiz.Target{moved}, run)[0]
+	}
+	return result, http.StatusOK, nil
+}
+
+func (s *Server) listDevices(w http.ResponseWriter, r *http.Request) {
+	found := s.discovered(r.Context())
+	cfg, err := s.resolvedConfig(found)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	devices := make([]Device, 0, len(found)+len(cfg.SavedDevices))
+	listed := map[string]bool{}
+	for _, saved := range cfg.SavedDevices {
+		mac := config.NormalizeMAC(saved.Mac)
+		device := Device{Saved: true}
+		if discovered, ok := found[mac]; ok && mac != "" {
+			device.Device, device.Online = discovered, true
+		}
+		device.Name, device.Mac, device.IP, device.Port = saved.Name, saved.Mac, saved.IP, saved.Port
+		if device.Port == "" {
+			device.Port = cfg.Port
+		}
+		for _, group := range cfg.Groups {
+			for _, member := range group.Members {
+				if mac != "" && config.NormalizeMAC(member) == mac {
+					device.Groups = append(device.Groups, group.Name)
+				}
+			}
+		}
+		listed[mac] = true
+		devices = append(devices, device)
+	}
+	for _, discovered := range sortedDevices(found) {
+		if !listed[config.NormalizeMAC(discovered.Mac)] {
+			devices = append(devices, Device{Device: discovered, Online: true})
+		}
+	}
+	writeJSON(w, http.StatusOK, devices)
+}
+
+func (s *Server) deviceState(w http.ResponseWriter, r *http.Request) {
+	var state wiz.PilotState
+	result, status, err := s.sendToDevice(r.Context(), r.PathValue("mac"), func(target wiz.Target) error {
+		var err error
+		state, err = s.Client.GetPilotStateContext(r.Context(), target.IP, target.Port)
+		return err
+	})
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	outcome := result.Outcome()
+	if result.Err == nil {
+		outcome.State = &state
+	}
+	writeJSON(w, outcomeStatus([]wiz.Result{result}), outcome)
+}
+
+func (s *Server) devicePilot(w http.ResponseWriter, r *http.Request) {
+	params, err := readPilot(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	result, status, err := s.sendToDevice(r.Context(), r.PathValue("mac"), func(target wiz.Target) error {
+		return s.Client.SendCommandAckContext(r.Context(), target.IP, target.Port, "setPilot", params)
+	})
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, outcomeStatus([]wiz.Result{result}), result.Outcome())
+}
+
+func (s *Server) groupPilot(w http.ResponseWriter, r *http.Request) {
+	params, err := readPilot(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	found := s.cached()
+	if len(found) == 0 {
+		found = s.discovered(r.Context())
+	}
+	cfg, err := s.resolvedConfig(found)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	name := r.PathValue("name")
+	if _, ok := cfg.FindGroup(name); !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown group %q", name))
+		return
+	}
+	targets, err := actions.Targets(cfg, name, "", "")
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	results := s.Client.Broadcast(r.Context(), targets, "setPilot", params)
+	outcomes := make([]wiz.Outcome, len(results))
+	for i, result := range results {
+		outcomes[i] = result.Outcome()
+	}
+	writeJSON(w, outcomeStatus(results), outcomes)
+}
+
+// readPilot decodes a JSON object of setPilot params, keeping numbers exact.
+func readPilot(w http.ResponseWriter, r *http.Request) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.UseNumber()
+	var params map[string]interface{}
+	if err := decoder.Decode(&params); err != nil {
+		return nil, fmt.Errorf("body must be a JSON object of setPilot params: %w", err)
+	}
+	if len(params) == 0 {
+		return nil, errors.New("body must set at least one setPilot param, e.g. {\"state\": true}")
+	}
+	return params, nil
+}
+
+// outcomeStatus is 200 when every device succeeded, 207 when some did, and
+// 502 when none did.
+func outcomeStatus(results []wiz.Result) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return http.StatusOK
+	case failed < len(results):
+		return http.StatusMultiStatus
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// sortedDevices returns discovered devices ordered by address for stable listings.
+func sortedDevices(found map[string]wiz.Device) []wiz.Device {
+	devices := make([]wiz.Device, 0, len(found))
+	for _, device := range found {
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		a, b := net.ParseIP(devices[i].IP).To16(), net.ParseIP(devices[j].IP).To16()
+		if cmp := bytes.Compare(a, b); cmp != 0 {
+			return cmp < 0
+		}
+		return devices[i].Port < devices[j].Port
+	})
+	return devices
+}
+
+// statusRecorder captures the response status for request logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController flush event streams through the recorder.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,533 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+)
+
+// cliUsage lists the non-interactive subcommands and their arguments.
+var cliUsage = map[string]string{
+	"on":       "[device...] [--group NAME] [--fade 2s]",
+	"off":      "[device...] [--group NAME] [--fade 2s]",
+	"toggle":   "[device...] [--group NAME]",
+	"set":      "[device...] [--group NAME] [--color #RRGGBB | --temp K | --scene NAME | --preset NAME] [--brightness N] [--speed N] [--fade 2s]",
+	"get":      "[device...] [--group NAME]",
+	"discover": "",
+	"devices":  "",
+}
+
+// cliCommands orders the subcommands for help output.
+var cliCommands = []string{"on", "off", "toggle", "set", "get", "discover", "devices"}
+
+// printUsage describes the interactive flags and every subcommand.
+func printUsage(stderr io.Writer) {
+	fmt.Fprintln(stderr, "usage: lumina [flags]          start the TUI, or run a --timer/--alarm worker")
+	for _, name := range cliCommands {
+		fmt.Fprintln(stderr, strings.TrimRight("       lumina "+name+" "+cliUsage[name], " "))
+	}
+	fmt.Fprintln(stderr, "       lumina timers list|cancel ID")
+	fmt.Fprintln(stderr, "       lumina daemon [--dry-run] [--catch-up 15m]")
+	fmt.Fprintln(stderr, "       lumina serve [--listen 127.0.0.1:8080]")
+	fmt.Fprintln(stderr, "       lumina mqtt [--broker tcp://localhost:1883]")
+	fmt.Fprintln(stderr, "\nDevices are a saved name, a MAC, or an IP[:port]; without one the configured device is used.")
+	fmt.Fprintln(stderr, "Every subcommand accepts --output json or ndjson. Exit codes: 0 all devices succeeded,")
+	fmt.Fprintln(stderr, "1 none did, 2 usage error, 3 some did.\n\nflags:")
+	flag.PrintDefaults()
+}
+
+// isCLICommand reports whether name is a non-interactive subcommand.
+func isCLICommand(name string) bool {
+	_, ok := cliUsage[name]
+	return ok
+}
+
+// runCLICommand runs one non-interactive subcommand and returns its exit code.
+// Devices are given by saved name, MAC, or IP[:port]; without any, the
+// configured device is used.
+func runCLICommand(name string, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, strings.TrimRight("usage: lumina "+name+" "+cliUsage[name], " "))
+		flags.PrintDefaults()
+	}
+	group := flags.String("group", "", "target every member of this saved group")
+	port := flags.String("port", "", "UDP port for devices given by IP (default from config, or 38899)")
+	output := outputFlag(flags)
+	fade := new(time.Duration)
+	if name == "on" || name == "off" || name == "set" {
+		fade = flags.Duration("fade", 0, "fade to the new state over this duration, e.g. 2s")
+	}
+	var (
+		color      = new(string)
+		temp       = new(int)
+		scene      = new(string)
+		preset     = new(string)
+		brightness = new(int)
+		speed      = new(int)
+	)
+	if name == "set" {
+		color = flags.String("color", "", "RGB color as #RRGGBB")
+		temp = flags.Int("temp", 0, "white color temperature in Kelvin")
+		scene = flags.String("scene", "", "built-in scene name or id, e.g. cozy")
+		preset = flags.String("preset", "", "saved preset name")
+		brightness = flags.Int("brightness", 0, "brightness 10-100")
+		speed = flags.Int("speed", 0, "scene animation speed")
+	}
+
+	refs, err := parseInterleaved(flags, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintf(stderr, "lumina %s: %v\n", name, err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	cfg, _ := config.Load()
+
+	switch name {
+	case "discover":
+		return runDiscoverCommand(ctx, cfg, *output, stdout, stderr)
+	case "devices":
+		return runDevicesCommand(cfg, *output, stdout, stderr)
+	}
+
+	targets, err := resolveTargets(ctx, cfg, refs, *group, *port)
+	if err != nil {
+		fmt.Fprintf(stderr, "lumina %s: %v\n", name, err)
+		var usage usageError
+		if errors.As(err, &usage) {
+			return exitUsage
+		}
+		return exitFailed
+	}
+
+	switch name {
+	case "get":
+		return runGetCommand(ctx, targets, *output, stdout, stderr)
+	case "toggle":
+		return runToggleCommand(ctx, targets, *output, stdout, stderr)
+	}
+
+	action := actions.Action{Kind: actions.Kind(name)}
+	if name == "set" {
+		action, err = setAction(*color, *temp, *scene, *preset, *brightness, *speed)
+		if err != nil {
+			fmt.Fprintf(stderr, "lumina set: %v\n", err)
+			return exitUsage
+		}
+	}
+	results, err := actions.Run(ctx, targets, action, cfg.Presets, *fade)
+	if err != nil {
+		fmt.Fprintf(stderr, "lumina %s: %v\n", name, err)
+		return exitFailed
+	}
+	outcomes := make([]wiz.Outcome, len(results))
+	for i, result := range results {
+		outcomes[i] = result.Outcome()
+		outcomes[i].Action = action.String()
+	}
+	return printOutcomes(results, outcomes, *output, stdout, stderr)
+}
+
+// parseInterleaved parses flags that may appear before, between, or after
+// positional arguments, returning the positional arguments in order.
+func parseInterleaved(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// setAction builds the action for `lumina set` from its flags.
+func setAction(color string, temp int, scene, preset string, brightness, speed int) (actions.Action, error) {
+	modes := 0
+	for _, set := range []bool{color != "", temp != 0, scene != "", preset != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return actions.Action{}, errors.New("use only one of --color, --temp, --scene, or --preset")
+	}
+
+	action := actions.Action{Brightness: brightness}
+	switch {
+	case color != "":
+		if !strings.HasPrefix(color, "#") {
+			color = "#" + color
+		}
+		action.Kind, action.ColorHex = actions.Color, strings.ToUpper(color)
+	case temp != 0:
+		action.Kind, action.Temp = actions.White, temp
+	case scene != "":
+		action.Kind, action.Scene, action.Speed = actions.Scene, scene, speed
+	case preset != "":
+		action.Kind, action.Preset = actions.Preset, preset
+	case brightness != 0:
+		action.Kind = actions.Brightness
+	default:
+		return actions.Action{}, errors.New("nothing to set; pass --color, --temp, --scene, --preset, or --brightness")
+	}
+	return action, action.Validate()
+}
+
+// usageError marks a device reference or group name that can never resolve,
+// as opposed to a lookup that failed at runtime, such as a MAC not found on
+// the network.
+type usageError struct {
+	error
+}
+
+func (e usageError) Unwrap() error {
+	return e.error
+}
+
+// resolveTargets turns device references and an optional group into
+// de-duplicated targets, defaulting to the configured device.
+func resolveTargets(ctx context.Context, cfg config.Config, refs []string, group, port string) ([]wiz.Target, error) {
+	if port == "" {
+		port = cfg.Port
+	}
+	if port == "" {
+		port = "38899"
+	}
+
+	var targets []wiz.Target
+	if group != "" {
+		members, err := actions.Targets(cfg, group, "", "")
+		if err != nil {
+			return nil, usageError{err}
+		}
+		targets = append(targets, members...)
+	}
+
+	// Discovery runs at most once, and only for MACs that are not saved.
+	var (
+		once        sync.Once
+		discovered  []wiz.Device
+		discoverErr error
+	)
+	discover := func() ([]wiz.Device, error) {
+		once.Do(func() { discovered, discoverErr = wiz.DiscoverDevicesContext(ctx) })
+		return discovered, discoverErr
+	}
+	for _, ref := range refs {
+		target, err := resolveDevice(cfg, ref, port, discover)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	if len(refs) == 0 && group == "" {
+		if err := config.Validate(cfg.IP, port); err != nil {
+			return nil, usageError{errors.New("no device given and none configured; pass a saved name, MAC, or IP")}
+		}
+		targets = append(targets, wiz.Target{Name: savedNameFor(cfg, cfg.IP, port), IP: cfg.IP, Port: port})
+	}
+
+	seen := map[string]bool{}
+	unique := targets[:0]
+	for _, target := range targets {
+		key := net.JoinHostPort(target.IP, target.Port)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, target)
+		}
+	}
+	return unique, nil
+}
+
+// resolveDevice matches a reference against saved names, then MACs (saved
+// first, then discovered), then IP[:port] addresses.
+func resolveDevice(cfg config.Config, ref, port string, discover func() ([]wiz.Device, error)) (wiz.Target, error) {
+	ref = strings.TrimSpace(ref)
+	savedTarget := func(saved config.SavedDevice) wiz.Target {
+		target := wiz.Target{Name: saved.Name, IP: saved.IP, Port: saved.Port}
+		if target.Port == "" {
+			target.Port = port
+		}
+		return target
+	}
+
+	for _, saved := range cfg.SavedDevices {
+		if strings.EqualFold(strings.TrimSpace(saved.Name), ref) {
+			return savedTarget(saved), nil
+		}
+	}
+
+	if mac := config.NormalizeMAC(ref); mac != "" {
+		for _, saved := range cfg.SavedDevices {
+			if config.NormalizeMAC(saved.Mac) == mac {
+				return savedTarget(saved), nil
+			}
+		}
+		devices, err := discover()
+		if err != nil {
+			return wiz.Target{}, fmt.Errorf("looking up MAC %s: %w", ref, err)
+		}
+		for _, device := range devices {
+			if config.NormalizeMAC(device.Mac) == mac {
+				return wiz.Target{Name: device.Name, IP: device.IP, Port: device.Port}, nil
+			}
+		}
+		return wiz.Target{}, fmt.Errorf("no device with MAC %s found on the network", ref)
+	}
+
+	host, hostPort := ref, port
+	if splitHost, splitPort, err := net.SplitHostPort(ref); err == nil {
+		host, hostPort = splitHost, splitPort
+	}
+	if err := config.Validate(host, hostPort); err == nil {
+		return wiz.Target{Name: savedNameFor(cfg, host, hostPort), IP: host, Port: hostPort}, nil
+	}
+	return wiz.Target{}, usageError{fmt.Errorf("unknown device %q: not a saved name, MAC, or IP", ref)}
+}
+
+// savedNameFor returns the saved name for an address, if any.
+func savedNameFor(cfg config.Config, ip, port string) string {
+	for _, saved := range cfg.SavedDevices {
+		if saved.IP == ip && savedPort(cfg, saved) == port {
+			return saved.Name
+		}
+	}
+	return ""
+}
+
+// savedPort returns a saved device's port, falling back to the config's.
+func savedPort(cfg config.Config, saved config.SavedDevice) string {
+	if saved.Port != "" {
+		return saved.Port
+	}
+	if cfg.Port != "" {
+		return cfg.Port
+	}
+	return "38899"
+}
+
+// printOutcomes reports each target's outcome, as "name: action" lines or
+// as JSON, and returns the exit code for the results.
+func printOutcomes(results []wiz.Result, outcomes []wiz.Outcome, output string, stdout, stderr io.Writer) int {
+	if output != textOutput {
+		if err := writeRecords(stdout, output, outcomes); err != nil {
+			fmt.Fprintf(stderr, "failed to write output: %v\n", err)
+			return exitFailed
+		}
+		return resultsExitCode(results)
+	}
+	for _, outcome := range outcomes {
+		if !outcome.OK {
+			fmt.Fprintf(stderr, "%s: %s\n", outcome.Target, outcome.Error)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %s\n", outcome.Target, outcome.Action)
+	}
+	return resultsExitCode(results)
+}
+
+// runToggleCommand flips each target's power based on its current state.
+func runToggleCommand(ctx context.Context, targets []wiz.Target, output string, stdout, stderr io.Writer) int {
+	states := make([]wiz.PilotState, len(targets))
+	results := wiz.ForEach(targets, func(i int, target wiz.Target) error {
+		state, err := wiz.GetPilotStateContext(ctx, target.IP, target.Port)
+		if err != nil {
+			return err
+		}
+		state.Power = !state.Power
+		states[i] = state
+		return wiz.SendCommandAckContext(ctx, target.IP, target.Port, "setState", map[string]interface{}{"state": state.Power})
+	})
+	outcomes := make([]wiz.Outcome, len(results))
+	for i, result := range results {
+		outcomes[i] = result.Outcome()
+		if result.Err == nil {
+			outcomes[i].Action = powerLabel(states[i].Power)
+			outcomes[i].State = &states[i]
+		}
+	}
+	return printOutcomes(results, outcomes, output, stdout, stderr)
+}
+
+// runGetCommand prints each target's current state.
+func runGetCommand(ctx context.Context, targets []wiz.Target, output string, stdout, stderr io.Writer) int {
+	states := make([]wiz.PilotState, len(targets))
+	results := wiz.ForEach(targets, func(i int, target wiz.Target) error {
+		state, err := wiz.GetPilotStateContext(ctx, target.IP, target.Port)
+		states[i] = state
+		return err
+	})
+	if output != textOutput {
+		outcomes := make([]wiz.Outcome, len(results))
+		for i, result := range results {
+			outcomes[i] = result.Outcome()
+			if result.Err == nil {
+				outcomes[i].State = &states[i]
+			}
+		}
+		return printOutcomes(results, outcomes, output, stdout, stderr)
+	}
+
+	writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DEVICE\tADDRESS\tPOWER\tBRIGHTNESS\tMODE")
+	for i, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", result.Target, result.Err)
+			continue
+		}
+		state := states[i]
+		address := net.JoinHostPort(result.Target.IP, result.Target.Port)
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d%%\t%s\n", result.Target, address, powerLabel(state.Power), state.Brightness, modeLabel(state))
+	}
+	_ = writer.Flush()
+	return resultsExitCode(results)
+}
+
+// discoveredDevice is a discovery result with the name it is saved under, if any.
+type discoveredDevice struct {
+	wiz.Device
+	SavedAs string `json:"savedAs,omitempty"`
+}
+
+// runDiscoverCommand scans the network and lists the devices found.
+func runDiscoverCommand(ctx context.Context, cfg config.Config, output string, stdout, stderr io.Writer) int {
+	devices, err := wiz.DiscoverDevicesContext(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "discovery failed: %v\n", err)
+		return exitFailed
+	}
+	saved := map[string]string{}
+	for _, device := range cfg.SavedDevices {
+		saved[config.NormalizeMAC(device.Mac)] = device.Name
+	}
+	records := make([]discoveredDevice, 0, len(devices))
+	for _, device := range devices {
+		records = append(records, discoveredDevice{Device: device, SavedAs: saved[config.NormalizeMAC(device.Mac)]})
+	}
+
+	if output != textOutput {
+		return writeCommandRecords(stdout, stderr, output, records)
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(stdout, "no devices found")
+		return exitOK
+	}
+	writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tADDRESS\tMAC\tMODEL\tKIND\tSAVED AS")
+	for _, record := range records {
+		savedAs := record.SavedAs
+		if savedAs == "" {
+			savedAs = "-"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", record.Name, net.JoinHostPort(record.IP, record.Port), record.Mac, record.Model, record.Capabilities.Kind, savedAs)
+	}
+	_ = writer.Flush()
+	return exitOK
+}
+
+// savedDeviceRecord is a saved device with its effective port and groups.
+type savedDeviceRecord struct {
+	config.SavedDevice
+	Groups  []string `json:"groups"`
+	Default bool     `json:"default"`
+}
+
+// runDevicesCommand lists saved devices and their groups without touching the network.
+func runDevicesCommand(cfg config.Config, output string, stdout, stderr io.Writer) int {
+	defaultPort := cfg.Port
+	if defaultPort == "" {
+		defaultPort = "38899"
+	}
+	records := make([]savedDeviceRecord, 0, len(cfg.SavedDevices))
+	for _, device := range cfg.SavedDevices {
+		device.Port = savedPort(cfg, device)
+		record := savedDeviceRecord{SavedDevice: device, Groups: []string{}, Default: device.IP == cfg.IP && device.Port == defaultPort}
+		for _, group := range cfg.Groups {
+			for _, member := range group.Members {
+				if mac := config.NormalizeMAC(device.Mac); mac != "" && config.NormalizeMAC(member) == mac {
+					record.Groups = append(record.Groups, group.Name)
+				}
+			}
+		}
+		records = append(records, record)
+	}
+
+	if output != textOutput {
+		return writeCommandRecords(stdout, stderr, output, records)
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(stdout, "no saved devices; save some from the TUI's Discover Devices view")
+		return exitOK
+	}
+	writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tADDRESS\tMAC\tGROUPS\tDEFAULT")
+	for _, record := range records {
+		groupList := strings.Join(record.Groups, ",")
+		if groupList == "" {
+			groupList = "-"
+		}
+		isDefault := ""
+		if record.Default {
+			isDefault = "*"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", record.Name, net.JoinHostPort(record.IP, record.Port), record.Mac, groupList, isDefault)
+	}
+	_ = writer.Flush()
+	return exitOK
+}
+
+func powerLabel(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// modeLabel describes whether a light shows a color, white, or a scene.
+func modeLabel(state wiz.PilotState) string {
+	switch {
+	case state.SceneID > 0:
+		name := fmt.Sprintf("scene %d", state.SceneID)
+		if scene, ok := wiz.SceneByID(state.SceneID); ok {
+			name = "scene " + scene.Name
+		}
+		if state.Speed > 0 {
+			name += fmt.Sprintf(" (speed %d)", state.Speed)
+		}
+		return name
+	case state.ColorHex != "":
+		return "color " + state.ColorHex
+	case state.Temp > 0:
+		return fmt.Sprintf("white %dK", state.Temp)
+	default:
+		return "-"
+	}
+}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/schedule"
+	"wiz-tui/internal/wiz"
+)
+
+// runDaemonCommand implements `lumina daemon`, running the config's schedules
+// until interrupted.
+func runDaemonCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	grace := flags.Duration("catch-up", schedule.DefaultGrace, "run triggers missed during sleep or suspend if they are at most this late")
+	dryRun := flags.Bool("dry-run", false, "print the upcoming trigger times and exit without sending commands")
+	count := flags.Int("count", 10, "with --dry-run, how many upcoming triggers to print")
+	output := outputFlag(flags)
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintf(stderr, "lumina daemon: %v\n", err)
+		return exitUsage
+	}
+	if *dryRun {
+		return printUpcomingTriggers(stdout, stderr, *count, *output)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// With JSON output, stdout carries one event per line and logs move to stderr.
+	logOutput, events := stdout, func(schedule.Event) {}
+	if *output != textOutput {
+		logOutput = stderr
+		encoder := json.NewEncoder(stdout)
+		events = func(event schedule.Event) { _ = encoder.Encode(newDaemonEvent(event, time.Now())) }
+	}
+	logger := log.New(logOutput, "", log.LstdFlags)
+	// Invalid rules are reported once rather than on every check.
+	reported := map[string]bool{}
+	rules, errs := loadScheduleRules()
+	for _, err := range errs {
+		reported[err.Error()] = true
+		logger.Printf("ignoring %v", err)
+	}
+	if len(rules) == 0 {
+		logger.Printf("no enabled schedules in %s; edits are picked up while running", config.Path())
+	}
+	now := time.Now()
+	for _, rule := range rules {
+		logger.Printf("schedule %q: %s -> %s %s, next %s", rule.Name, rule.When(), schedule.DescribeTarget(rule.Schedule), rule.Action, rule.Next(now).Format("Mon 15:04"))
+	}
+
+	daemon := &schedule.Daemon{
+		Rules: func() ([]schedule.Rule, error) {
+			rules, errs := loadScheduleRules()
+			for _, err := range errs {
+				if !reported[err.Error()] {
+					reported[err.Error()] = true
+					logger.Printf("ignoring %v", err)
+				}
+			}
+			return rules, nil
+		},
+		Fire:   fireScheduleRule,
+		Grace:  *grace,
+		Logf:   logger.Printf,
+		Events: events,
+	}
+	if err := daemon.Run(ctx); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(stderr, "daemon stopped: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// daemonEvent is the JSON form of a schedule.Event.
+type daemonEvent struct {
+	Time     time.Time `json:"time"`
+	Status   string    `json:"status"`
+	Schedule string    `json:"schedule"`
+	At       time.Time `json:"at"`
+	Target   string    `json:"target"`
+	Action   string    `json:"action"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func newDaemonEvent(event schedule.Event, now time.Time) daemonEvent {
+	rule := event.Trigger.Rule
+	record := daemonEvent{
+		Time:     now,
+		Status:   event.Status,
+		Schedule: rule.Name,
+		At:       event.Trigger.At,
+		Target:   schedule.DescribeTarget(rule.Schedule),
+		Action:   rule.Action.String(),
+	}
+	if event.Err != nil {
+		record.Error = event.Err.Error()
+	}
+	return record
+}
+
+// upcomingTrigger is the JSON form of a trigger listed by --dry-run.
+type upcomingTrigger struct {
+	At       time.Time `json:"at"`
+	Schedule string    `json:"schedule"`
+	When     string    `json:"when"`
+	Target   string    `json:"target"`
+	Action   string    `json:"action"`
+}
+
+// printUpcomingTriggers lists the next count triggers across all enabled schedules.
+func printUpcomingTriggers(stdout, stderr io.Writer, count int, output string) int {
+	rules, errs := loadScheduleRules()
+	for _, err := range errs {
+		fmt.Fprintf(stderr, "ignoring %v\n", err)
+	}
+	upcoming := schedule.Upcoming(rules, time.Now(), count)
+	if output != textOutput {
+		records := make([]upcomingTrigger, 0, len(upcoming))
+		for _, trigger := range upcoming {
+			rule := trigger.Rule
+			records = append(records, upcomingTrigger{
+				At:       trigger.At,
+				Schedule: rule.Name,
+				When:     rule.When(),
+				Target:   schedule.DescribeTarget(rule.Schedule),
+				Action:   rule.Action.String(),
+			})
+		}
+		return writeCommandRecords(stdout, stderr, output, records)
+	}
+	if len(upcoming) == 0 {
+		fmt.Fprintln(stdout, "no upcoming triggers")
+		return 0
+	}
+	writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "WHEN\tSCHEDULE\tRULE\tTARGET\tACTION")
+	for _, trigger := range upcoming {
+		rule := trigger.Rule
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+			trigger.At.Format("Mon 2006-01-02 15:04"), rule.Name, rule.When(), schedule.DescribeTarget(rule.Schedule), rule.Action)
+	}
+	_ = writer.Flush()
+	return 0
+}
+
+// loadScheduleRules reads the config and compiles its enabled schedules.
+func loadScheduleRules() ([]schedule.Rule, []error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, []error{err}
+	}
+	return schedule.CompileAll(cfg.Schedules, cfg.Location)
+}
+
+// fireScheduleRule applies a rule's action to its targets using the current config.
+func fireScheduleRule(ctx context.Context, trigger schedule.Trigger) error {
+	rule := trigger.Rule
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	ip, port := rule.IP, rule.Port
+	if ip == "" {
+		ip = cfg.IP
+	}
+	if port == "" {
+		port = cfg.Port
+	}
+	targets, err := actions.Targets(cfg, rule.Group, ip, port)
+	if err != nil {
+		return err
+	}
+	results, err := actions.Run(ctx, targets, rule.Action, cfg.Presets, time.Duration(rule.FadeMs)*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	return wiz.JoinResults(results)
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+// startDemo launches simulated bulbs on loopback, points the wiz package at them,
+// and isolates config writes in a temporary file so real settings are untouched.
+// The returned stop function closes the bulbs and removes the temporary config.
+func startDemo() (config.Config, func(), error) {
+	bulbs, err := wiztest.StartFleet(wiztest.DemoFleet()...)
+	if err != nil {
+		return config.Config{}, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "lumina-demo-")
+	if err != nil {
+		closeBulbs(bulbs)
+		return config.Config{}, nil, fmt.Errorf("failed to create demo config dir: %w", err)
+	}
+	_ = os.Setenv(config.PathEnv, filepath.Join(dir, "config.json"))
+
+	opts := wiz.DefaultOptions()
+	opts.DiscoveryTargets = wiztest.DiscoveryTargets(bulbs)
+	opts.DiscoveryWindow = 500 * time.Millisecond
+	wiz.SetDefault(wiz.NewClient(opts))
+
+	cfg := config.Config{IP: bulbs[0].IP(), Port: bulbs[0].Port()}
+	stop := func() {
+		closeBulbs(bulbs)
+		_ = os.RemoveAll(dir)
+	}
+	return cfg, stop, nil
+}
+
+// closeBulbs stops every simulated bulb.
+func closeBulbs(bulbs []*wiztest.Bulb) {
+	for _, bulb := range bulbs {
+		_ = bulb.Close()
+	}
+}
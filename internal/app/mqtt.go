@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/mqttbridge"
+	"wiz-tui/internal/wiz"
+)
+
+// runMQTTCommand implements `lumina mqtt`, bridging devices to an MQTT
+// broker with Home Assistant discovery until interrupted.
+func runMQTTCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("mqtt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	broker := flags.String("broker", "tcp://localhost:1883", "MQTT broker URL (tcp://, ssl:// or ws://)")
+	username := flags.String("username", "", "broker username")
+	password := flags.String("password", "", "broker password (default $LUMINA_MQTT_PASSWORD)")
+	clientID := flags.String("client-id", "", "MQTT client ID (default lumina-<prefix>)")
+	prefix := flags.String("prefix", mqttbridge.DefaultTopicPrefix, "root of the state, availability and command topics")
+	discoveryPrefix := flags.String("discovery-prefix", mqttbridge.DefaultDiscoveryPrefix, "Home Assistant discovery prefix")
+	poll := flags.Duration("poll", mqttbridge.DefaultPollInterval, "how often device state is read and republished")
+	rescan := flags.Duration("rescan", mqttbridge.DefaultRescanInterval, "how often discovery looks for new devices")
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	// Read the environment only after parsing so help and errors never print it.
+	if *password == "" {
+		*password = os.Getenv("LUMINA_MQTT_PASSWORD")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(stdout, "", log.LstdFlags)
+	client := wiz.NewClient(wiz.DefaultOptions())
+	defer client.Close()
+	bridge := &mqttbridge.Bridge{
+		Client:          client,
+		Config:          config.Load,
+		Broker:          *broker,
+		ClientID:        *clientID,
+		Username:        *username,
+		Password:        *password,
+		TopicPrefix:     *prefix,
+		DiscoveryPrefix: *discoveryPrefix,
+		PollInterval:    *poll,
+		RescanInterval:  *rescan,
+		Logf:            logger.Printf,
+	}
+	if err := bridge.Run(ctx); err != nil {
+		fmt.Fprintf(stderr, "lumina mqtt: %v\n", err)
+		return exitFailed
+	}
+	return exitOK
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"wiz-tui/internal/wiz"
+)
+
+// Output formats accepted by --output.
+const (
+	textOutput   = "text"
+	jsonOutput   = "json"
+	ndjsonOutput = "ndjson"
+)
+
+// Exit codes shared by every subcommand. Multi-device commands return
+// exitFailed only when no device succeeded and exitPartial when some did.
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitPartial = 3
+)
+
+// outputFlag registers --output on a subcommand's flag set.
+func outputFlag(flags *flag.FlagSet) *string {
+	return flags.String("output", textOutput, "output format: text, json (one document), or ndjson (one object per line)")
+}
+
+// checkOutput validates an --output value.
+func checkOutput(format string) error {
+	switch format {
+	case textOutput, jsonOutput, ndjsonOutput:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q: use text, json, or ndjson", format)
+	}
+}
+
+// writeRecords writes records as one JSON array, or one object per line for NDJSON.
+func writeRecords[T any](w io.Writer, format string, records []T) error {
+	encoder := json.NewEncoder(w)
+	if format == ndjsonOutput {
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if records == nil {
+		records = []T{}
+	}
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// writeCommandRecords writes records for a listing command and returns its exit code.
+func writeCommandRecords[T any](stdout, stderr io.Writer, format string, records []T) int {
+	if err := writeRecords(stdout, format, records); err != nil {
+		fmt.Fprintf(stderr, "failed to write output: %v\n", err)
+		return exitFailed
+	}
+	return exitOK
+}
+
+// resultsExitCode maps per-device results to exitOK, exitPartial, or exitFailed.
+func resultsExitCode(results []wiz.Result) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return exitOK
+	case failed < len(results):
+		return exitPartial
+	default:
+		return exitFailed
+	}
+}
@@ -1,15 +1,20 @@
 package app
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/joho/godotenv"
+	"wiz-tui/internal/actions"
 	"wiz-tui/internal/config"
+	"wiz-tui/internal/schedule"
 	"wiz-tui/internal/ui"
 	"wiz-tui/internal/version"
 	"wiz-tui/internal/wiz"
@@ -17,13 +22,24 @@ import (
 
 // Run executes CLI handling and starts the interactive Lumina TUI.
 func Run() {
+	if code, ok := Command(os.Args[1:], os.Stdout, os.Stderr); ok {
+		os.Exit(code)
+	}
+
 	var (
-		timer    = flag.Int("timer", 0, "sleep timer in minutes; if >0 program will wait and then send a power command")
-		ipFlag   = flag.String("ip", "", "target device IP address (required when --timer > 0)")
+		timer    = flag.Int("timer", 0, "timer in minutes; if >0 program will wait and then run the timer action")
+		ipFlag   = flag.String("ip", "", "target device IP address (required when --timer > 0 without --group)")
 		portFlag = flag.String("port", "38899", "target device UDP port")
 		offFlag  = flag.Bool("off", false, "when used with --timer the command will turn the light off (default)")
+		demoFlag = flag.Bool("demo", false, "run against simulated bulbs on loopback without touching saved config")
+		nameFlag = flag.String("name", "", "display name for the --timer target shown by 'lumina timers list'")
+		fadeFlag = flag.Duration("fade", 0, "with --timer, fade gradually over this final part of the timer, e.g. 5m")
+		action   = flag.String("action", "", "timer action: off, on, color=#RRGGBB, white=2700, scene=Ocean, brightness=30, preset=NAME (optionally ,brightness=N) or JSON; overrides --off")
+		group    = flag.String("group", "", "with --timer or --alarm, target every member of this saved group instead of --ip")
+		alarm    = flag.String("alarm", "", "wake-up alarm at a HH:MM local time: a sunrise ramp over --fade (default 30m) that ends at that time")
 	)
 
+	flag.Usage = func() { printUsage(os.Stderr) }
 	flag.Parse()
 
 	for _, a := range os.Args[1:] {
@@ -33,50 +49,130 @@ func Run() {
 		}
 	}
 
-	if *timer > 0 {
-		if err := config.Validate(*ipFlag, *portFlag); err != nil {
+	// Interrupts cancel headless waits and the startup scan; the TUI handles
+	// Ctrl+C itself once it takes over the terminal.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *timer > 0 || *alarm != "" {
+		duration := time.Duration(*timer) * time.Minute
+		fade := *fadeFlag
+		timerAction := actions.Action{Kind: actions.On}
+		if *offFlag {
+			timerAction.Kind = actions.Off
+		}
+		if *alarm != "" {
+			spec, err := schedule.ParseDaily(*alarm, "")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid alarm time: %v\n", err)
+				os.Exit(1)
+			}
+			now := time.Now()
+			duration = spec.Next(now).Sub(now)
+			timerAction = actions.Action{Kind: actions.Sunrise}
+			if fade <= 0 {
+				fade = defaultSunriseRamp
+			}
+		} else if *action != "" {
+			parsed, err := actions.Parse(*action)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid timer action: %v\n", err)
+				os.Exit(1)
+			}
+			timerAction = parsed
+		}
+		cfg, _ := config.Load()
+		targets, err := actions.Targets(cfg, *group, *ipFlag, *portFlag)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "invalid timer configuration: %v\n", err)
 			os.Exit(1)
 		}
-		dur := time.Duration(*timer) * time.Minute
-		fmt.Printf("sleep timer: %dm -> %s:%s (off=%v)\n", *timer, *ipFlag, *portFlag, *offFlag)
-		time.Sleep(dur)
-		state := !*offFlag
-		if err := wiz.SendCommand(*ipFlag, *portFlag, "setState", map[string]interface{}{"state": state}); err != nil {
-			fmt.Fprintf(os.Stderr, "timer command failed: %v\n", err)
+		os.Exit(runTimerWorker(ctx, timerJob{
+			Targets:  targets,
+			Group:    *group,
+			Name:     *nameFlag,
+			Action:   timerAction,
+			Duration: duration,
+			Fade:     fade,
+		}))
+	}
+
+	var (
+		cfg        config.Config
+		needsSetup bool
+		stopDemo   = func() {}
+	)
+	if *demoFlag {
+		demoCfg, stopFleet, err := startDemo()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start demo bulbs: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("timer command sent")
-		os.Exit(0)
+		stopDemo = stopFleet
+		defer stopDemo()
+		cfg = demoCfg
+	} else {
+		cfg, needsSetup = loadRuntimeConfig(ctx)
 	}
-
-	cfg, needsSetup := loadRuntimeConfig()
 	if cfg.Port == "" {
 		cfg.Port = "38899"
 	}
 
-	if !needsSetup {
-		if err := wiz.SendCommand(cfg.IP, cfg.Port, "setState", map[string]interface{}{"state": true}); err != nil {
-			fmt.Printf("Warning: auto power-on failed: %v\n", err)
-		}
+	if ctx.Err() != nil {
+		stopDemo()
+		os.Exit(1)
+	}
+	stop()
+
+	model := ui.NewModel(cfg, needsSetup)
+	// Live updates need the fixed push port; when another program holds it
+	// the dashboard falls back to syncing on selection.
+	if listener, err := wiz.ListenPush(fmt.Sprintf(":%d", wiz.DefaultPushPort)); err == nil {
+		defer listener.Close()
+		model = model.WithPushListener(listener)
 	}
 
-	p := tea.NewProgram(ui.NewModel(cfg, needsSetup), tea.WithAltScreen())
+	p := tea.NewProgram(model, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error starting Lumina-TUI: %v\n", err)
+		// os.Exit skips deferred calls, so clean up the demo fleet first.
+		stopDemo()
 		os.Exit(1)
 	}
 }
 
+// Command runs the subcommand named by args[0], such as `on` or `timers`,
+// and returns its exit code. It reports false when args do not start with a
+// subcommand, so the caller falls back to the flag-driven TUI and workers.
+func Command(args []string, stdout, stderr io.Writer) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch args[0] {
+	case "timers":
+		return runTimersCommand(args[1:], stdout, stderr), true
+	case "daemon":
+		return runDaemonCommand(args[1:], stdout, stderr), true
+	case "serve":
+		return runServeCommand(args[1:], stdout, stderr), true
+	case "mqtt":
+		return runMQTTCommand(args[1:], stdout, stderr), true
+	}
+	if isCLICommand(args[0]) {
+		return runCLICommand(args[0], args[1:], stdout, stderr), true
+	}
+	return 0, false
+}
+
 // loadRuntimeConfig loads saved config first, then falls back to environment values.
-func loadRuntimeConfig() (config.Config, bool) {
+func loadRuntimeConfig(ctx context.Context) (config.Config, bool) {
 	cfg, err := config.Load()
 	if err == nil {
 		if cfg.Port == "" {
 			cfg.Port = "38899"
 		}
 		if len(cfg.SavedDevices) > 0 {
-			resolved := resolveSavedTargetsByMAC(cfg.SavedDevices)
+			resolved := resolveSavedTargetsByMAC(ctx, cfg.SavedDevices)
 			if cfg.IP == "" && len(resolved) > 0 {
 				cfg.IP = resolved[0].IP
 				if resolved[0].Port != "" {
@@ -124,36 +220,14 @@ func loadRuntimeConfig() (config.Config, bool) {
 	return cfg, false
 }
 
-func resolveSavedTargetsByMAC(savedDevices []config.SavedDevice) []config.SavedDevice {
+func resolveSavedTargetsByMAC(ctx context.Context, savedDevices []config.SavedDevice) []config.SavedDevice {
 	if len(savedDevices) == 0 {
 		return savedDevices
 	}
 
-	discovered, err := wiz.DiscoverDevices()
+	discovered, err := wiz.DiscoverDevicesContext(ctx)
 	if err != nil {
 		return savedDevices
 	}
-
-	ipByMAC := map[string]string{}
-	for _, device := range discovered {
-		mac := strings.ToLower(strings.TrimSpace(device.Mac))
-		if mac == "" {
-			continue
-		}
-		ipByMAC[mac] = device.IP
-	}
-
-	resolved := make([]config.SavedDevice, len(savedDevices))
-	copy(resolved, savedDevices)
-	for index := range resolved {
-		mac := strings.ToLower(strings.TrimSpace(resolved[index].Mac))
-		if mac == "" {
-			continue
-		}
-		if ip, ok := ipByMAC[mac]; ok && ip != "" {
-			resolved[index].IP = ip
-		}
-	}
-
-	return resolved
+	return actions.ResolveByMAC(savedDevices, discovered)
 }
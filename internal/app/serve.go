@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"wiz-tui/internal/api"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+)
+
+// runServeCommand implements `lumina serve`, exposing the wiz client over a
+// local HTTP API until interrupted.
+func runServeCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	listen := flags.String("listen", "127.0.0.1:8080", "address to serve the HTTP API on; the API has no authentication, so keep it on loopback or a trusted network")
+	rescan := flags.Duration("rescan", api.DefaultRescanInterval, "reuse discovery results for this long before scanning again")
+	poll := flags.Duration("poll", api.DefaultPollInterval, "how often /events polls devices while a stream is connected")
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(stdout, "", log.LstdFlags)
+	client := wiz.NewClient(wiz.DefaultOptions())
+	defer client.Close()
+	server := &api.Server{
+		Config:         config.Load,
+		Client:         client,
+		RescanInterval: *rescan,
+		PollInterval:   *poll,
+		Logf:           logger.Printf,
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "lumina serve: %v\n", err)
+		return exitFailed
+	}
+	httpServer := &http.Server{
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		// Event streams never finish on their own; end them on shutdown.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if devices, err := server.Refresh(ctx); err != nil {
+		logger.Printf("initial discovery failed: %v", err)
+	} else {
+		logger.Printf("discovered %d devices", len(devices))
+	}
+	logger.Printf("serving the Lumina API on http://%s", listener.Addr())
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "lumina serve: %v\n", err)
+		return exitFailed
+	}
+	return exitOK
+}
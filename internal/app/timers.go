@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/timers"
+	"wiz-tui/internal/wiz"
+)
+
+// defaultSunriseRamp is the wake-up ramp length when --alarm is used without --fade.
+const defaultSunriseRamp = 30 * time.Minute
+
+// timerJob describes one headless timer run started with --timer.
+type timerJob struct {
+	Targets  []wiz.Target
+	Group    string
+	Name     string
+	Action   actions.Action
+	Duration time.Duration
+	Fade     time.Duration
+}
+
+// runTimerWorker registers the timer, waits for it, and replays its action,
+// returning the process exit code.
+func runTimerWorker(ctx context.Context, job timerJob) int {
+	fade := job.Fade
+	if fade > job.Duration {
+		fade = job.Duration
+	}
+	// Actions that cannot fade are sent at once, so they wait the full duration.
+	cfg, _ := config.Load()
+	fade = actions.Lead(job.Action, cfg.Presets, fade)
+
+	now := time.Now()
+	entry := timers.Timer{
+		ID:       timers.NewID(),
+		PID:      os.Getpid(),
+		Name:     job.Name,
+		Group:    job.Group,
+		Action:   job.Action.String(),
+		FadeMs:   int(fade / time.Millisecond),
+		Deadline: now.Add(job.Duration),
+		Created:  now,
+	}
+	if job.Group == "" && len(job.Targets) == 1 {
+		entry.IP, entry.Port = job.Targets[0].IP, job.Targets[0].Port
+	}
+	if err := timers.Add(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: timer not registered: %v\n", err)
+	}
+	defer func() { _ = timers.Remove(entry.ID) }()
+
+	fmt.Printf("timer %s: %s -> %s %s (fade=%s)\n", entry.ID, job.Duration, entry.Target(), entry.Action, fade)
+	select {
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "timer cancelled")
+		return exitFailed
+	case <-time.After(job.Duration - fade):
+	}
+
+	// Presets are read again when the timer fires so edits made meanwhile apply.
+	cfg, _ = config.Load()
+	results, err := actions.Run(ctx, job.Targets, job.Action, cfg.Presets, fade)
+	if err == nil {
+		err = wiz.JoinResults(results)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "timer command failed: %v\n", err)
+		return exitFailed
+	}
+	fmt.Println("timer command sent")
+	return exitOK
+}
+
+// runTimersCommand implements `lumina timers list|cancel <id>`.
+func runTimersCommand(args []string, stdout, stderr io.Writer) int {
+	usage := "usage: lumina timers list [--output json] | lumina timers cancel <id> [--output json]"
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	var name string
+	switch args[0] {
+	case "list", "ls":
+		name = "list"
+	case "cancel", "rm":
+		name = "cancel"
+	default:
+		fmt.Fprintln(stderr, usage)
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("timers "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := outputFlag(flags)
+	ids, err := parseInterleaved(flags, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintf(stderr, "lumina timers: %v\n", err)
+		return exitUsage
+	}
+
+	if name == "cancel" {
+		if len(ids) != 1 {
+			fmt.Fprintln(stderr, usage)
+			return exitUsage
+		}
+		timer, err := timers.Cancel(ids[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to cancel timer: %v\n", err)
+			return exitFailed
+		}
+		if *output != textOutput {
+			return writeCommandRecords(stdout, stderr, *output, []timers.Timer{timer})
+		}
+		fmt.Fprintf(stdout, "cancelled timer %s (%s %s)\n", timer.ID, timerActionLabel(timer), timer.Target())
+		return exitOK
+	}
+
+	if len(ids) > 0 {
+		fmt.Fprintln(stderr, usage)
+		return exitUsage
+	}
+	active, err := timers.List()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to read timers: %v\n", err)
+		return exitFailed
+	}
+	if *output != textOutput {
+		return writeCommandRecords(stdout, stderr, *output, active)
+	}
+	if len(active) == 0 {
+		fmt.Fprintln(stdout, "no active timers")
+		return exitOK
+	}
+	writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tTARGET\tACTION\tREMAINING\tDEADLINE\tPID")
+	now := time.Now()
+	for _, timer := range active {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			timer.ID, timer.Target(), timerActionLabel(timer), timer.Remaining(now).Round(time.Second), timer.Deadline.Format("15:04:05"), timer.PID)
+	}
+	_ = writer.Flush()
+	return exitOK
+}
+
+// timerActionLabel describes what a timer does when it fires.
+func timerActionLabel(timer timers.Timer) string {
+	if timer.FadeMs > 0 {
+		return fmt.Sprintf("%s (fade %s)", timer.Action, time.Duration(timer.FadeMs)*time.Millisecond)
+	}
+	return timer.Action
+}
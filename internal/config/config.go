@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 // Config stores target bulb network settings.
@@ -14,6 +15,61 @@ type Config struct {
 	IP           string        `json:"ip"`
 	Port         string        `json:"port"`
 	SavedDevices []SavedDevice `json:"savedDevices,omitempty"`
+	Groups       []Group       `json:"groups,omitempty"`
+	ActiveGroup  string        `json:"activeGroup,omitempty"`
+	// TransitionMs is the TUI fade duration for power and color changes; zero is instant.
+	TransitionMs int        `json:"transitionMs,omitempty"`
+	Presets      []Preset   `json:"presets,omitempty"`
+	Schedules    []Schedule `json:"schedules,omitempty"`
+	// Location enables sunrise and sunset schedules; it never leaves the machine.
+	Location *Location `json:"location,omitempty"`
+}
+
+// Location is a position in decimal degrees, north and east positive.
+type Location struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+}
+
+// Validate checks that the coordinates are in range.
+func (l Location) Validate() error {
+	if l.Latitude < -90 || l.Latitude > 90 {
+		return fmt.Errorf("latitude %v must be between -90 and 90", l.Latitude)
+	}
+	if l.Longitude < -180 || l.Longitude > 180 {
+		return fmt.Errorf("longitude %v must be between -180 and 180", l.Longitude)
+	}
+	return nil
+}
+
+// Schedule is a recurring rule run by `lumina daemon`. It fires either on a
+// five-field Cron expression or daily At a HH:MM time, or a sun-relative
+// time such as "sunset-30m", on the given Days ("weekdays", "sat,sun",
+// "mon-fri"; empty means every day). Action uses the
+// timer action syntax, and the rule targets Group, or IP and Port, falling
+// back to the configured device.
+type Schedule struct {
+	Name     string `json:"name"`
+	At       string `json:"at,omitempty"`
+	Days     string `json:"days,omitempty"`
+	Cron     string `json:"cron,omitempty"`
+	Action   string `json:"action"`
+	Group    string `json:"group,omitempty"`
+	IP       string `json:"ip,omitempty"`
+	Port     string `json:"port,omitempty"`
+	FadeMs   int    `json:"fadeMs,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// Preset stores a named light state that timers and the TUI can reapply.
+type Preset struct {
+	Name       string `json:"name"`
+	Power      bool   `json:"power"`
+	Brightness int    `json:"brightness,omitempty"`
+	ColorHex   string `json:"color,omitempty"`
+	Temp       int    `json:"temp,omitempty"`
+	Scene      string `json:"scene,omitempty"`
+	Speed      int    `json:"speed,omitempty"`
 }
 
 // SavedDevice stores a user-named bulb target for quick reuse.
@@ -24,8 +80,68 @@ type SavedDevice struct {
 	Mac  string `json:"mac,omitempty"`
 }
 
-// Path returns the persisted config file location in the user home directory.
+// NormalizeMAC returns a MAC in bare lowercase hex, or "" if text is not a
+// MAC. Colon, dash, and dot separators are accepted.
+func NormalizeMAC(text string) string {
+	mac := strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.TrimSpace(text)))
+	if len(mac) != 12 {
+		return ""
+	}
+	for _, r := range mac {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return ""
+		}
+	}
+	return mac
+}
+
+// PathEnv names an environment variable that overrides the config file location.
+const PathEnv = "LUMINA_CONFIG"
+
+// Group names a set of saved devices, referenced by MAC, that are controlled together.
+type Group struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// FindGroup returns the group with a case-insensitive name match.
+func (c Config) FindGroup(name string) (Group, bool) {
+	name = strings.TrimSpace(name)
+	for _, group := range c.Groups {
+		if strings.EqualFold(strings.TrimSpace(group.Name), name) {
+			return group, true
+		}
+	}
+	return Group{}, false
+}
+
+// GroupMembers resolves a group's member MACs to saved devices, skipping unknown MACs.
+func (c Config) GroupMembers(group Group) []SavedDevice {
+	byMAC := map[string]SavedDevice{}
+	for _, saved := range c.SavedDevices {
+		mac := NormalizeMAC(saved.Mac)
+		if mac != "" {
+			byMAC[mac] = saved
+		}
+	}
+
+	members := make([]SavedDevice, 0, len(group.Members))
+	for _, mac := range group.Members {
+		if saved, ok := byMAC[NormalizeMAC(mac)]; ok {
+			if saved.Port == "" {
+				saved.Port = c.Port
+			}
+			members = append(members, saved)
+		}
+	}
+	return members
+}
+
+// Path returns the persisted config file location, defaulting to the user home directory.
 func Path() string {
+	if override := os.Getenv(PathEnv); override != "" {
+		return override
+	}
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".lumina-config.json")
 }
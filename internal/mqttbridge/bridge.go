@@ -0,0 +1,520 @@
+// Package mqttbridge mirrors WiZ devices onto an MQTT broker, publishing
+// retained state and Home Assistant discovery configs and applying commands
+// received on per-device topics.
+package mqttbridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+)
+
+const (
+	// DefaultTopicPrefix roots the bridge's state, availability and command topics.
+	DefaultTopicPrefix = "lumina"
+	// DefaultDiscoveryPrefix is Home Assistant's default discovery prefix.
+	DefaultDiscoveryPrefix = "homeassistant"
+	// DefaultPollInterval is how often device state is read and republished.
+	DefaultPollInterval = 5 * time.Second
+	// DefaultRescanInterval is how often discovery looks for new devices.
+	DefaultRescanInterval = 5 * time.Minute
+
+	// publishTimeout bounds how long one publish waits for the broker.
+	publishTimeout = 5 * time.Second
+)
+
+// Availability payloads for the bridge and device availability topics.
+const (
+	online  = "online"
+	offline = "offline"
+)
+
+// Bridge connects WiZ devices to an MQTT broker. For every saved and
+// discovered device, addressed by bare lowercase MAC, it maintains:
+//
+//	<prefix>/status                        bridge availability, "online" or "offline"
+//	<prefix>/<mac>/availability            device availability
+//	<prefix>/<mac>/state                   Home Assistant JSON schema state
+//	<prefix>/<mac>/set                     commands in the same schema
+//	<discovery>/light/lumina_<mac>/config  Home Assistant discovery config
+//
+// Every topic except set is retained.
+type Bridge struct {
+	// Client sends commands and runs discovery.
+	Client *wiz.Client
+	// Config returns the current config. It is called on every poll so
+	// devices saved in the TUI are picked up without a restart.
+	Config func() (config.Config, error)
+
+	// Broker is the broker URL, e.g. tcp://localhost:1883.
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+
+	// TopicPrefix defaults to DefaultTopicPrefix.
+	TopicPrefix string
+	// DiscoveryPrefix defaults to DefaultDiscoveryPrefix.
+	DiscoveryPrefix string
+	// PollInterval defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// RescanInterval defaults to DefaultRescanInterval.
+	RescanInterval time.Duration
+	// Logf reports connection changes, commands and errors when set.
+	Logf func(format string, args ...interface{})
+
+	client mqtt.Client
+	ctx    context.Context
+	// wake asks the poll loop to sync now, e.g. to republish after a reconnect.
+	wake chan struct{}
+
+	mu      sync.Mutex
+	found   map[string]wiz.Device
+	devices map[string]*device
+}
+
+// device is one bridged device and the payloads last published for it.
+type device struct {
+	mac      string
+	target   wiz.Target
+	info     wiz.Device
+	detected bool
+	// unreachable records a failed capability probe; it is retried on the
+	// next rescan rather than on every poll.
+	unreachable bool
+
+	config       []byte
+	state        []byte
+	availability string
+}
+
+// Run connects to the broker and bridges devices until ctx is cancelled.
+// It returns an error only if the first connection fails; later
+// disconnections are retried in the background.
+func (b *Bridge) Run(ctx context.Context) error {
+	if b.TopicPrefix == "" {
+		b.TopicPrefix = DefaultTopicPrefix
+	}
+	if b.DiscoveryPrefix == "" {
+		b.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+	if b.PollInterval <= 0 {
+		b.PollInterval = DefaultPollInterval
+	}
+	if b.RescanInterval <= 0 {
+		b.RescanInterval = DefaultRescanInterval
+	}
+	if b.ClientID == "" {
+		b.ClientID = "lumina-" + b.TopicPrefix
+	}
+	b.ctx = ctx
+	b.wake = make(chan struct{}, 1)
+	b.found = map[string]wiz.Device{}
+	b.devices = map[string]*device{}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(b.Broker).
+		SetClientID(b.ClientID).
+		SetUsername(b.Username).
+		SetPassword(b.Password).
+		SetWill(b.topic("status"), offline, 1, true).
+		SetAutoReconnect(true).
+		SetOrderMatters(false).
+		SetConnectTimeout(10 * time.Second).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			b.logf("lost connection to %s: %v", b.Broker, err)
+		})
+	b.client = mqtt.NewClient(opts)
+	if err := wait(ctx, b.client.Connect()); err != nil {
+		return fmt.Errorf("connect to %s: %w", b.Broker, err)
+	}
+	defer func() {
+		_ = b.publish(b.topic("status"), []byte(offline))
+		b.client.Disconnect(250)
+	}()
+
+	ticker := time.NewTicker(b.PollInterval)
+	defer ticker.Stop()
+	var scanned time.Time
+	for {
+		rescan := time.Since(scanned) >= b.RescanInterval
+		if rescan {
+			scanned = time.Now()
+		}
+		b.sync(ctx, rescan)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-b.wake:
+		}
+	}
+}
+
+// onConnect announces the bridge and (re)subscribes after every connection.
+// Retained payloads are republished in case the broker lost them.
+func (b *Bridge) onConnect(client mqtt.Client) {
+	b.logf("connected to %s", b.Broker)
+	if err := b.publish(b.topic("status"), []byte(online)); err != nil {
+		b.logf("publish bridge status: %v", err)
+	}
+	filters := map[string]byte{
+		b.topic("+", "set"):           1,
+		b.DiscoveryPrefix + "/status": 1,
+	}
+	if err := wait(b.ctx, client.SubscribeMultiple(filters, b.onMessage)); err != nil {
+		b.logf("subscribe: %v", err)
+	}
+	b.forget()
+	b.requestSync()
+}
+
+// onMessage dispatches commands and Home Assistant birth messages.
+func (b *Bridge) onMessage(_ mqtt.Client, message mqtt.Message) {
+	if message.Topic() == b.DiscoveryPrefix+"/status" {
+		if string(message.Payload()) == online {
+			b.forget()
+			b.requestSync()
+		}
+		return
+	}
+	mac := strings.TrimSuffix(strings.TrimPrefix(message.Topic(), b.TopicPrefix+"/"), "/set")
+	if err := b.command(b.ctx, mac, message.Payload()); err != nil {
+		b.logf("command for %s: %v", mac, err)
+	}
+}
+
+// command applies one set-topic payload and publishes the resulting state.
+func (b *Bridge) command(ctx context.Context, mac string, payload []byte) error {
+	b.mu.Lock()
+	dev, ok := b.devices[config.NormalizeMAC(mac)]
+	var (
+		target wiz.Target
+		caps   wiz.Capabilities
+	)
+	if ok {
+		target, caps = dev.target, dev.info.Capabilities
+	}
+	b.mu.Unlock()
+	if !ok {
+		return errors.New("unknown device")
+	}
+
+	var cmd Command
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		return fmt.Errorf("decode %q: %w", payload, err)
+	}
+	action, fade, err := cmd.Action(caps)
+	if err != nil {
+		return err
+	}
+	b.logf("%s: %s", target, action)
+	if to, ok := action.Target(); ok && fade > 0 {
+		err = b.Client.FadeTo(ctx, target.IP, target.Port, to, fade)
+	} else {
+		var (
+			method string
+			params map[string]interface{}
+		)
+		method, params, err = action.Request()
+		if err == nil {
+			err = b.Client.SendCommandAckContext(ctx, target.IP, target.Port, method, params)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	b.poll(ctx, []string{config.NormalizeMAC(mac)})
+	return nil
+}
+
+// forget clears the published payloads so the next sync republishes them.
+func (b *Bridge) forget() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, dev := range b.devices {
+		dev.config, dev.state, dev.availability = nil, nil, ""
+	}
+}
+
+func (b *Bridge) requestSync() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// sync refreshes the device list, optionally running discovery first,
+// announces new or changed devices and publishes their state.
+func (b *Bridge) sync(ctx context.Context, rescan bool) {
+	if rescan {
+		devices, err := b.Client.DiscoverDevicesContext(ctx)
+		if err != nil {
+			b.logf("discovery: %v", err)
+		}
+		b.mu.Lock()
+		for _, found := range devices {
+			if mac := config.NormalizeMAC(found.Mac); mac != "" {
+				b.found[mac] = found
+			}
+		}
+		b.mu.Unlock()
+	}
+	if err := b.refreshDevices(ctx, rescan); err != nil {
+		b.logf("load config: %v", err)
+	}
+
+	b.mu.Lock()
+	macs := make([]string, 0, len(b.devices))
+	for mac := range b.devices {
+		macs = append(macs, mac)
+	}
+	b.mu.Unlock()
+	b.poll(ctx, macs)
+}
+
+// refreshDevices merges saved devices, moved to the addresses discovery
+// found for their MACs, with discovered devices that are not saved. Devices
+// that did not answer a capability probe are only probed again on a rescan.
+func (b *Bridge) refreshDevices(ctx context.Context, rescan bool) error {
+	cfg, err := b.Config()
+	if err != nil {
+		return err
+	}
+	port := cfg.Port
+	if port == "" {
+		port = "38899"
+	}
+
+	b.mu.Lock()
+	found := make([]wiz.Device, 0, len(b.found))
+	for _, device := range b.found {
+		found = append(found, device)
+	}
+	targets := map[string]wiz.Target{}
+	for mac, device := range b.found {
+		targets[mac] = wiz.Target{Name: device.Name, IP: device.IP, Port: device.Port}
+	}
+	for _, saved := range actions.ResolveByMAC(cfg.SavedDevices, found) {
+		if mac := config.NormalizeMAC(saved.Mac); mac != "" {
+			target := wiz.Target{Name: saved.Name, IP: saved.IP, Port: saved.Port}
+			if target.Port == "" {
+				target.Port = port
+			}
+			targets[mac] = target
+		}
+	}
+	var undetected []string
+	for mac, target := range targets {
+		dev, ok := b.devices[mac]
+		if !ok {
+			dev = &device{mac: mac}
+			b.devices[mac] = dev
+		}
+		dev.target = target
+		if rescan {
+			dev.unreachable = false
+		}
+		if info, ok := b.found[mac]; ok {
+			dev.info, dev.detected = info, true
+		}
+		if !dev.detected && !dev.unreachable {
+			undetected = append(undetected, mac)
+		}
+	}
+	b.mu.Unlock()
+
+	// Saved devices that missed discovery are asked for their module
+	// directly so their entity gets the right color modes. Probes run
+	// concurrently so one unplugged bulb does not stall the others.
+	var wg sync.WaitGroup
+	for _, mac := range undetected {
+		b.mu.Lock()
+		target := b.devices[mac].target
+		b.mu.Unlock()
+		wg.Add(1)
+		go func(mac string, target wiz.Target) {
+			defer wg.Done()
+			caps, err := b.Client.DetectCapabilitiesContext(ctx, target.IP, target.Port)
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			dev := b.devices[mac]
+			dev.info.Capabilities = caps
+			dev.detected = err == nil
+			dev.unreachable = err != nil && ctx.Err() == nil
+		}(mac, target)
+	}
+	wg.Wait()
+	return nil
+}
+
+// poll reads the state of the given devices and publishes whatever changed.
+func (b *Bridge) poll(ctx context.Context, macs []string) {
+	targets := make([]wiz.Target, len(macs))
+	b.mu.Lock()
+	for i, mac := range macs {
+		targets[i] = b.devices[mac].target
+	}
+	b.mu.Unlock()
+
+	states := make([]wiz.PilotState, len(targets))
+	results := wiz.ForEach(targets, func(i int, target wiz.Target) error {
+		state, err := b.Client.GetPilotStateContext(ctx, target.IP, target.Port)
+		states[i] = state
+		return err
+	})
+	if ctx.Err() != nil {
+		return
+	}
+
+	for i, result := range results {
+		b.announce(macs[i])
+		availability := online
+		if result.Err != nil {
+			availability = offline
+		} else {
+			b.mu.Lock()
+			caps := b.devices[macs[i]].info.Capabilities
+			b.mu.Unlock()
+			state, _ := json.Marshal(newLightState(states[i], caps))
+			b.publishChanged(macs[i], b.topic(macs[i], "state"), state, func(dev *device) *[]byte { return &dev.state })
+		}
+		b.mu.Lock()
+		dev := b.devices[macs[i]]
+		changed := dev.availability != availability
+		b.mu.Unlock()
+		if !changed {
+			continue
+		}
+		if err := b.publish(b.topic(macs[i], "availability"), []byte(availability)); err != nil {
+			b.logf("publish availability of %s: %v", macs[i], err)
+			continue
+		}
+		b.mu.Lock()
+		dev.availability = availability
+		b.mu.Unlock()
+	}
+}
+
+// announce publishes a device's discovery config if it changed.
+func (b *Bridge) announce(mac string) {
+	b.mu.Lock()
+	dev := b.devices[mac]
+	config, _ := json.Marshal(b.discoveryConfig(dev))
+	b.mu.Unlock()
+	b.publishChanged(mac, b.DiscoveryPrefix+"/light/lumina_"+mac+"/config", config, func(dev *device) *[]byte { return &dev.config })
+}
+
+// publishChanged publishes payload unless it matches the one last published
+// in the field that last selects.
+func (b *Bridge) publishChanged(mac, topic string, payload []byte, last func(*device) *[]byte) {
+	b.mu.Lock()
+	dev := b.devices[mac]
+	same := bytes.Equal(*last(dev), payload)
+	b.mu.Unlock()
+	if same {
+		return
+	}
+	if err := b.publish(topic, payload); err != nil {
+		b.logf("publish %s: %v", topic, err)
+		return
+	}
+	b.mu.Lock()
+	*last(dev) = payload
+	b.mu.Unlock()
+}
+
+// discoveryConfig describes a device as a Home Assistant JSON schema light.
+func (b *Bridge) discoveryConfig(dev *device) DiscoveryConfig {
+	caps := dev.info.Capabilities
+	name := dev.target.Name
+	if name == "" {
+		name = dev.info.Name
+	}
+	if name == "" {
+		name = "WiZ " + dev.mac
+	}
+	cfg := DiscoveryConfig{
+		UniqueID:     "lumina_" + dev.mac,
+		Schema:       "json",
+		StateTopic:   b.topic(dev.mac, "state"),
+		CommandTopic: b.topic(dev.mac, "set"),
+		Availability: []Availability{
+			{Topic: b.topic("status")},
+			{Topic: b.topic(dev.mac, "availability")},
+		},
+		AvailabilityMode:    "all",
+		SupportedColorModes: colorModes(caps),
+		Device: DeviceInfo{
+			Identifiers:  []string{"lumina_" + dev.mac},
+			Connections:  [][]string{{"mac", formatMAC(dev.mac)}},
+			Name:         name,
+			Manufacturer: "WiZ",
+			Model:        dev.info.Model,
+			SWVersion:    dev.info.Firmware,
+		},
+	}
+	if caps.Dimming {
+		cfg.Brightness, cfg.BrightnessScale = true, 100
+	}
+	if caps.ColorTemp {
+		cfg.ColorTempKelvin = true
+		cfg.MinKelvin = caps.ClampKelvin(wiz.MinKelvin)
+		cfg.MaxKelvin = caps.ClampKelvin(wiz.MaxKelvin)
+	}
+	if list := effects(caps); len(list) > 0 {
+		cfg.Effect, cfg.EffectList = true, list
+	}
+	return cfg
+}
+
+// publish sends a retained QoS 1 message and waits for the broker to accept it.
+func (b *Bridge) publish(topic string, payload []byte) error {
+	token := b.client.Publish(topic, 1, true, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		return errors.New("timed out")
+	}
+	return token.Error()
+}
+
+func (b *Bridge) topic(parts ...string) string {
+	return b.TopicPrefix + "/" + strings.Join(parts, "/")
+}
+
+func (b *Bridge) logf(format string, args ...interface{}) {
+	if b.Logf != nil {
+		b.Logf(format, args...)
+	}
+}
+
+// wait blocks until token completes or ctx is cancelled.
+func wait(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// formatMAC writes a bare MAC with colons, as Home Assistant connections expect.
+func formatMAC(mac string) string {
+	var parts []string
+	for i := 0; i+2 <= len(mac); i += 2 {
+		parts = append(parts, mac[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,166 @@
+package mqttbridge
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/wiz"
+)
+
+// Home Assistant color modes used in discovery and state payloads.
+const (
+	modeOnOff      = "onoff"
+	modeBrightness = "brightness"
+	modeColorTemp  = "color_temp"
+	modeRGB        = "rgb"
+)
+
+// rgbColor is the color object of Home Assistant's JSON light schema.
+type rgbColor struct {
+	R int `json:"r"`
+	G int `json:"g"`
+	B int `json:"b"`
+}
+
+// LightState is a state payload in Home Assistant's JSON light schema.
+// Brightness is a percentage (brightness_scale 100) and ColorTemp is in Kelvin.
+type LightState struct {
+	State      string    `json:"state"`
+	Brightness int       `json:"brightness,omitempty"`
+	ColorMode  string    `json:"color_mode,omitempty"`
+	Color      *rgbColor `json:"color,omitempty"`
+	ColorTemp  int       `json:"color_temp,omitempty"`
+	Effect     string    `json:"effect,omitempty"`
+}
+
+// Command is a command payload in Home Assistant's JSON light schema.
+// Transition is in seconds.
+type Command struct {
+	State      string    `json:"state"`
+	Brightness int       `json:"brightness,omitempty"`
+	Color      *rgbColor `json:"color,omitempty"`
+	ColorTemp  int       `json:"color_temp,omitempty"`
+	Effect     string    `json:"effect,omitempty"`
+	Transition float64   `json:"transition,omitempty"`
+}
+
+// Availability is one entry of a discovery config's availability list.
+type Availability struct {
+	Topic string `json:"topic"`
+}
+
+// DeviceInfo groups entities under one device in Home Assistant.
+type DeviceInfo struct {
+	Identifiers  []string   `json:"identifiers"`
+	Connections  [][]string `json:"connections,omitempty"`
+	Name         string     `json:"name"`
+	Manufacturer string     `json:"manufacturer"`
+	Model        string     `json:"model,omitempty"`
+	SWVersion    string     `json:"sw_version,omitempty"`
+}
+
+// DiscoveryConfig is the retained payload that makes Home Assistant create a
+// light entity. Name is null so the entity takes the device's name.
+type DiscoveryConfig struct {
+	Name                *string        `json:"name"`
+	UniqueID            string         `json:"unique_id"`
+	Schema              string         `json:"schema"`
+	StateTopic          string         `json:"state_topic"`
+	CommandTopic        string         `json:"command_topic"`
+	Availability        []Availability `json:"availability"`
+	AvailabilityMode    string         `json:"availability_mode"`
+	Brightness          bool           `json:"brightness"`
+	BrightnessScale     int            `json:"brightness_scale,omitempty"`
+	SupportedColorModes []string       `json:"supported_color_modes"`
+	ColorTempKelvin     bool           `json:"color_temp_kelvin,omitempty"`
+	MinKelvin           int            `json:"min_kelvin,omitempty"`
+	MaxKelvin           int            `json:"max_kelvin,omitempty"`
+	Effect              bool           `json:"effect,omitempty"`
+	EffectList          []string       `json:"effect_list,omitempty"`
+	Device              DeviceInfo     `json:"device"`
+}
+
+// colorModes lists the Home Assistant color modes a device supports.
+func colorModes(caps wiz.Capabilities) []string {
+	switch {
+	case caps.Color && caps.ColorTemp:
+		return []string{modeColorTemp, modeRGB}
+	case caps.Color:
+		return []string{modeRGB}
+	case caps.ColorTemp:
+		return []string{modeColorTemp}
+	case caps.Dimming:
+		return []string{modeBrightness}
+	default:
+		return []string{modeOnOff}
+	}
+}
+
+// effects lists the names of the scenes a device supports.
+func effects(caps wiz.Capabilities) []string {
+	var names []string
+	for _, scene := range wiz.Scenes {
+		if caps.SupportsScene(scene.ID) {
+			names = append(names, scene.Name)
+		}
+	}
+	return names
+}
+
+// newLightState converts a pilot state to a Home Assistant state payload.
+func newLightState(state wiz.PilotState, caps wiz.Capabilities) LightState {
+	light := LightState{State: "OFF"}
+	if state.Power {
+		light.State = "ON"
+	}
+	modes := colorModes(caps)
+	light.ColorMode = modes[len(modes)-1]
+	if caps.Dimming && state.Brightness > 0 {
+		light.Brightness = state.Brightness
+	}
+	switch {
+	case state.ColorHex != "" && caps.Color:
+		if r, g, b, err := wiz.HexToRGB(state.ColorHex); err == nil {
+			light.ColorMode, light.Color = modeRGB, &rgbColor{R: int(r), G: int(g), B: int(b)}
+		}
+	case state.Temp > 0 && caps.ColorTemp:
+		light.ColorMode, light.ColorTemp = modeColorTemp, state.Temp
+	}
+	if scene, ok := wiz.SceneByID(state.SceneID); ok && state.SceneID > 0 {
+		light.Effect = scene.Name
+	}
+	return light
+}
+
+// Action converts a command to a replayable action and its fade duration.
+// Brightness below the firmware minimum is raised to it.
+func (c Command) Action(caps wiz.Capabilities) (actions.Action, time.Duration, error) {
+	fade := time.Duration(c.Transition * float64(time.Second))
+	switch strings.ToUpper(c.State) {
+	case "OFF":
+		return actions.Action{Kind: actions.Off}, fade, nil
+	case "ON", "":
+	default:
+		return actions.Action{}, 0, fmt.Errorf("%w: state must be ON or OFF, got %q", actions.ErrInvalid, c.State)
+	}
+
+	action := actions.Action{Kind: actions.On}
+	if c.Brightness > 0 {
+		action.Brightness = min(max(c.Brightness, 10), 100)
+	}
+	switch {
+	case c.Effect != "":
+		action.Kind, action.Scene = actions.Scene, c.Effect
+	case c.Color != nil:
+		action.Kind, action.ColorHex = actions.Color, fmt.Sprintf("#%02X%02X%02X", clampByte(c.Color.R), clampByte(c.Color.G), clampByte(c.Color.B))
+	case c.ColorTemp > 0:
+		action.Kind, action.Temp = actions.White, caps.ClampKelvin(c.ColorTemp)
+	}
+	return action, fade, action.Validate()
+}
+
+func clampByte(value int) int {
+	return min(max(value, 0), 255)
+}
@@ -0,0 +1,412 @@
+// Package mqtttest runs a minimal in-process MQTT broker for tests.
+package mqtttest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// MQTT 3.1.1 control packet types.
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// Broker is a loopback MQTT 3.1.1 broker. It accepts QoS 0 and 1 publishes,
+// keeps retained messages, matches + and # wildcards, and sends last-will
+// messages when a client drops. Subscribers always receive QoS 0.
+type Broker struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	sessions map[*session]bool
+	retained map[string][]byte
+	wg       sync.WaitGroup
+}
+
+// Message is one retained message.
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+type session struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	// filters and will are guarded by Broker.mu.
+	filters map[string]bool
+	will    *Message
+	retain  bool
+}
+
+// StartBroker listens on a random loopback port.
+func StartBroker() (*Broker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	broker := &Broker{
+		listener: listener,
+		sessions: map[*session]bool{},
+		retained: map[string][]byte{},
+	}
+	broker.wg.Add(1)
+	go broker.accept()
+	return broker, nil
+}
+
+// URL returns the broker address in the tcp://host:port form MQTT clients expect.
+func (b *Broker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// Retained returns the retained message for a topic.
+func (b *Broker) Retained(topic string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+// RetainedMatching returns every retained message whose topic matches filter.
+func (b *Broker) RetainedMatching(filter string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var messages []Message
+	for topic, payload := range b.retained {
+		if Match(filter, topic) {
+			messages = append(messages, Message{Topic: topic, Payload: payload})
+		}
+	}
+	return messages
+}
+
+// Publish delivers a message as if a client had sent it.
+func (b *Broker) Publish(topic string, payload []byte, retain bool) {
+	b.route(Message{Topic: topic, Payload: payload}, retain)
+}
+
+// Close stops the broker and drops every connection without sending wills.
+func (b *Broker) Close() error {
+	err := b.listener.Close()
+	b.mu.Lock()
+	for s := range b.sessions {
+		s.will = nil
+		_ = s.conn.Close()
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+	return err
+}
+
+func (b *Broker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		s := &session{conn: conn, filters: map[string]bool{}}
+		b.mu.Lock()
+		b.sessions[s] = true
+		b.mu.Unlock()
+		b.wg.Add(1)
+		go b.serve(s)
+	}
+}
+
+func (b *Broker) serve(s *session) {
+	defer b.wg.Done()
+	reader := bufio.NewReader(s.conn)
+	clean := false
+	defer func() {
+		_ = s.conn.Close()
+		b.mu.Lock()
+		delete(b.sessions, s)
+		will, retain := s.will, s.retain
+		b.mu.Unlock()
+		if !clean && will != nil {
+			b.route(*will, retain)
+		}
+	}()
+
+	for {
+		header, body, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case packetConnect:
+			if err := b.connect(s, body); err != nil {
+				return
+			}
+		case packetPublish:
+			b.publish(s, header, body)
+		case packetSubscribe:
+			b.subscribe(s, body)
+		case packetUnsubscribe:
+			b.unsubscribe(s, body)
+		case packetPingreq:
+			_ = s.write(packetPingresp<<4, nil)
+		case packetDisconnect:
+			clean = true
+			return
+		}
+	}
+}
+
+func (b *Broker) connect(s *session, body []byte) error {
+	r := &fieldReader{data: body}
+	_ = r.string() // protocol name
+	_ = r.byte()   // protocol level
+	flags := r.byte()
+	r.uint16() // keep alive
+	_ = r.string()
+	if flags&0x04 != 0 {
+		will := &Message{Topic: r.string(), Payload: r.bytes()}
+		b.mu.Lock()
+		s.will, s.retain = will, flags&0x20 != 0
+		b.mu.Unlock()
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return s.write(packetConnack<<4, []byte{0, 0})
+}
+
+func (b *Broker) publish(s *session, header byte, body []byte) {
+	r := &fieldReader{data: body}
+	topic := r.string()
+	qos := (header >> 1) & 0x03
+	var id uint16
+	if qos > 0 {
+		id = r.uint16()
+	}
+	if r.err != nil {
+		return
+	}
+	payload := append([]byte(nil), r.rest()...)
+	if qos > 0 {
+		_ = s.write(packetPuback<<4, binary.BigEndian.AppendUint16(nil, id))
+	}
+	b.route(Message{Topic: topic, Payload: payload}, header&0x01 != 0)
+}
+
+func (b *Broker) subscribe(s *session, body []byte) {
+	r := &fieldReader{data: body}
+	id := r.uint16()
+	var filters []string
+	for r.err == nil && len(r.data) > 0 {
+		filters = append(filters, r.string())
+		_ = r.byte() // requested QoS
+	}
+	if r.err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	var retained []Message
+	for _, filter := range filters {
+		s.filters[filter] = true
+		for topic, payload := range b.retained {
+			if Match(filter, topic) {
+				retained = append(retained, Message{Topic: topic, Payload: payload})
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	ack := binary.BigEndian.AppendUint16(nil, id)
+	ack = append(ack, make([]byte, len(filters))...)
+	if s.write(packetSuback<<4, ack) != nil {
+		return
+	}
+	for _, message := range retained {
+		_ = s.deliver(message, true)
+	}
+}
+
+func (b *Broker) unsubscribe(s *session, body []byte) {
+	r := &fieldReader{data: body}
+	id := r.uint16()
+	b.mu.Lock()
+	for r.err == nil && len(r.data) > 0 {
+		delete(s.filters, r.string())
+	}
+	b.mu.Unlock()
+	_ = s.write(packetUnsuback<<4, binary.BigEndian.AppendUint16(nil, id))
+}
+
+// route stores retained messages and forwards a message to matching sessions.
+func (b *Broker) route(message Message, retain bool) {
+	b.mu.Lock()
+	if retain {
+		if len(message.Payload) == 0 {
+			delete(b.retained, message.Topic)
+		} else {
+			b.retained[message.Topic] = message.Payload
+		}
+	}
+	var targets []*session
+	for s := range b.sessions {
+		for filter := range s.filters {
+			if Match(filter, message.Topic) {
+				targets = append(targets, s)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for _, s := range targets {
+		_ = s.deliver(message, false)
+	}
+}
+
+func (s *session) deliver(message Message, retained bool) error {
+	header := byte(packetPublish << 4)
+	if retained {
+		header |= 0x01
+	}
+	body := appendString(nil, message.Topic)
+	body = append(body, message.Payload...)
+	return s.write(header, body)
+}
+
+func (s *session) write(header byte, body []byte) error {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+// Match reports whether an MQTT topic filter with + and # wildcards matches topic.
+func Match(filter, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+	for i, part := range filterParts {
+		if part == "#" {
+			return true
+		}
+		if i >= len(topicParts) {
+			return false
+		}
+		if part != "+" && part != topicParts[i] {
+			return false
+		}
+	}
+	return len(filterParts) == len(topicParts)
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("mqtttest: malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// fieldReader decodes MQTT fields, remembering the first error.
+type fieldReader struct {
+	data []byte
+	err  error
+}
+
+func (r *fieldReader) need(n int) bool {
+	if r.err == nil && len(r.data) < n {
+		r.err = fmt.Errorf("mqtttest: packet truncated")
+	}
+	return r.err == nil
+}
+
+func (r *fieldReader) byte() byte {
+	if !r.need(1) {
+		return 0
+	}
+	value := r.data[0]
+	r.data = r.data[1:]
+	return value
+}
+
+func (r *fieldReader) uint16() uint16 {
+	if !r.need(2) {
+		return 0
+	}
+	value := binary.BigEndian.Uint16(r.data)
+	r.data = r.data[2:]
+	return value
+}
+
+func (r *fieldReader) bytes() []byte {
+	length := int(r.uint16())
+	if !r.need(length) {
+		return nil
+	}
+	value := append([]byte(nil), r.data[:length]...)
+	r.data = r.data[length:]
+	return value
+}
+
+func (r *fieldReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *fieldReader) rest() []byte {
+	value := r.data
+	r.data = nil
+	return value
+}
+
+func appendString(dst []byte, value string) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(value)))
+	return append(dst, value...)
+}
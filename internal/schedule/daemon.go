@@ -0,0 +1,202 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInterval is how often the daemon compares the wall clock against the rules.
+	DefaultInterval = 15 * time.Second
+	// DefaultGrace is how late a trigger may still fire after a suspend or stall.
+	DefaultGrace = 15 * time.Minute
+)
+
+// Event statuses reported by Daemon.
+const (
+	// Fired means the trigger's action was applied.
+	Fired = "fired"
+	// Failed means applying the action returned an error.
+	Failed = "failed"
+	// Missed means the trigger was too late to run, e.g. after a long suspend.
+	Missed = "missed"
+)
+
+// Event reports what the daemon did with one trigger.
+type Event struct {
+	Trigger Trigger
+	Status  string
+	Err     error
+}
+
+// Daemon fires schedule rules as wall-clock time passes. It polls rather than
+// sleeping until the next trigger because timers stop while the machine is
+// suspended; each check covers everything since the previous one.
+type Daemon struct {
+	// Rules returns the current rules. It is called on every check so config
+	// edits apply without a restart.
+	Rules func() ([]Rule, error)
+	// Fire runs one trigger. Triggers fire in schedule order; rules with a
+	// fade run in the background so a long ramp does not hold up later rules,
+	// and a later trigger for the same target cancels a fade still running.
+	Fire func(ctx context.Context, trigger Trigger) error
+	// Interval between checks; DefaultInterval when zero.
+	Interval time.Duration
+	// Grace bounds how late a trigger may fire; DefaultGrace when zero.
+	Grace time.Duration
+	// Now returns the current time; time.Now when nil.
+	Now func() time.Time
+	// Logf reports fired, failed, and missed triggers when set.
+	Logf func(format string, args ...interface{})
+	// Events receives every fired, failed, and missed trigger when set.
+	Events func(Event)
+
+	last    time.Time
+	mu      sync.Mutex
+	fading  map[string]*fade
+	running sync.WaitGroup
+	// report serializes Logf and Events calls from background fades.
+	report sync.Mutex
+}
+
+// Run checks the rules every Interval until ctx is cancelled.
+func (d *Daemon) Run(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	d.Check(ctx, d.now())
+	for {
+		select {
+		case <-ctx.Done():
+			d.Wait()
+			return ctx.Err()
+		case <-ticker.C:
+			d.Check(ctx, d.now())
+		}
+	}
+}
+
+// Check fires every rule due since the previous check. The first call only
+// records the starting time, so triggers from before the daemon started are
+// not replayed.
+func (d *Daemon) Check(ctx context.Context, now time.Time) {
+	if d.last.IsZero() || now.Before(d.last) {
+		// Start, or the clock was set back: resume from here.
+		d.last = now
+		return
+	}
+	rules, err := d.Rules()
+	if err != nil {
+		d.logf("schedule: failed to load rules: %v", err)
+		return
+	}
+
+	grace := d.Grace
+	if grace <= 0 {
+		grace = DefaultGrace
+	}
+	due, missed := Due(rules, d.last, now, grace)
+	d.last = now
+	for _, trigger := range missed {
+		d.logf("schedule %q: skipped %s run, %s late", trigger.Rule.Name, trigger.At.Format("Mon 15:04"), now.Sub(trigger.At).Round(time.Second))
+		d.emit(Event{Trigger: trigger, Status: Missed})
+	}
+	for _, trigger := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		target := DescribeTarget(trigger.Rule.Schedule)
+		d.cancelFade(target)
+		if trigger.Rule.FadeMs <= 0 {
+			d.fire(ctx, trigger)
+			continue
+		}
+		d.startFade(ctx, target, trigger)
+	}
+}
+
+// Wait blocks until every fade started by Check has finished.
+func (d *Daemon) Wait() {
+	d.running.Wait()
+}
+
+// fade is one trigger running in the background.
+type fade struct {
+	cancel context.CancelFunc
+}
+
+// startFade fires a trigger in its own goroutine, cancelled with ctx or by a
+// later trigger for the same target.
+func (d *Daemon) startFade(ctx context.Context, target string, trigger Trigger) {
+	fadeCtx, cancel := context.WithCancel(ctx)
+	running := &fade{cancel: cancel}
+	d.mu.Lock()
+	if d.fading == nil {
+		d.fading = map[string]*fade{}
+	}
+	d.fading[target] = running
+	d.mu.Unlock()
+
+	d.running.Add(1)
+	go func() {
+		defer d.running.Done()
+		d.fire(fadeCtx, trigger)
+		cancel()
+		d.mu.Lock()
+		if d.fading[target] == running {
+			delete(d.fading, target)
+		}
+		d.mu.Unlock()
+	}()
+}
+
+// cancelFade stops a fade still running on target.
+func (d *Daemon) cancelFade(target string) {
+	d.mu.Lock()
+	running := d.fading[target]
+	delete(d.fading, target)
+	d.mu.Unlock()
+	if running != nil {
+		running.cancel()
+	}
+}
+
+// fire runs one trigger and reports the outcome.
+func (d *Daemon) fire(ctx context.Context, trigger Trigger) {
+	if err := d.Fire(ctx, trigger); err != nil {
+		d.logf("schedule %q: %s failed: %v", trigger.Rule.Name, trigger.Rule.Action, err)
+		d.emit(Event{Trigger: trigger, Status: Failed, Err: err})
+		return
+	}
+	d.logf("schedule %q: %s", trigger.Rule.Name, trigger.Rule.Action)
+	d.emit(Event{Trigger: trigger, Status: Fired})
+}
+
+func (d *Daemon) now() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	// Drop the monotonic reading so gaps include time spent suspended.
+	return time.Now().Round(0)
+}
+
+func (d *Daemon) logf(format string, args ...interface{}) {
+	d.report.Lock()
+	defer d.report.Unlock()
+	if d.Logf != nil {
+		d.Logf(format, args...)
+	}
+}
+
+func (d *Daemon) emit(event Event) {
+	d.report.Lock()
+	defer d.report.Unlock()
+	if d.Events != nil {
+		d.Events(event)
+	}
+}
@@ -0,0 +1,195 @@
+// Package schedule compiles recurring light rules from the config and runs
+// them as wall-clock time passes.
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/config"
+)
+
+// maxCatchUp bounds how far back Due looks after a long suspend.
+const maxCatchUp = 7 * 24 * time.Hour
+
+// Rule is a compiled config.Schedule.
+type Rule struct {
+	config.Schedule
+	Spec   Spec
+	Action actions.Action
+
+	solar    *solarTime
+	location config.Location
+}
+
+// Trigger is one occurrence of a rule.
+type Trigger struct {
+	Rule Rule
+	At   time.Time
+}
+
+// Compile validates a schedule and parses its timing and action. Sunrise and
+// sunset rules need a location.
+func Compile(schedule config.Schedule, location *config.Location) (Rule, error) {
+	rule := Rule{Schedule: schedule}
+	if strings.TrimSpace(schedule.Name) == "" {
+		return Rule{}, fmt.Errorf("%w: name is required", ErrInvalidSpec)
+	}
+
+	solar, isSolar, err := parseSolar(schedule.At)
+	switch {
+	case err != nil:
+	case strings.TrimSpace(schedule.Cron) != "":
+		rule.Spec, err = ParseCron(schedule.Cron)
+	case isSolar && location == nil:
+		err = fmt.Errorf("%w: %s needs a location in the config", ErrInvalidSpec, strings.TrimSpace(schedule.At))
+	case isSolar:
+		if err = location.Validate(); err != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+			break
+		}
+		rule.Spec, err = daySpec(schedule.Days)
+		rule.solar = &solar
+		rule.location = *location
+	case strings.TrimSpace(schedule.At) != "":
+		rule.Spec, err = ParseDaily(schedule.At, schedule.Days)
+	default:
+		err = fmt.Errorf("%w: set either cron or at", ErrInvalidSpec)
+	}
+	if err != nil {
+		return Rule{}, fmt.Errorf("schedule %q: %w", schedule.Name, err)
+	}
+
+	if rule.Action, err = actions.Parse(schedule.Action); err != nil {
+		return Rule{}, fmt.Errorf("schedule %q: %w", schedule.Name, err)
+	}
+	return rule, nil
+}
+
+// CompileAll compiles every enabled schedule, returning the valid rules and
+// an error for each invalid one.
+func CompileAll(schedules []config.Schedule, location *config.Location) ([]Rule, []error) {
+	rules := make([]Rule, 0, len(schedules))
+	var errs []error
+	for _, schedule := range schedules {
+		if schedule.Disabled {
+			continue
+		}
+		rule, err := Compile(schedule, location)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, errs
+}
+
+// Next returns the rule's first occurrence after the given instant, or the
+// zero time if it never occurs. Sun-relative times are recomputed for each
+// day, and days without a sunrise or sunset are skipped.
+func (r Rule) Next(after time.Time) time.Time {
+	if r.solar == nil {
+		return r.Spec.Next(after)
+	}
+	loc := after.Location()
+	// Start a day early: a large negative offset can move a trigger into the previous day.
+	day := time.Date(after.Year(), after.Month(), after.Day()-1, 0, 0, 0, 0, loc)
+	for i := 0; i < maxSearchDays; i++ {
+		if r.Spec.matchesDay(day) {
+			if at, ok := r.solar.on(day, r.location.Latitude, r.location.Longitude); ok && at.After(after) {
+				return at
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+	return time.Time{}
+}
+
+// Upcoming returns the next n triggers across all rules after the given
+// instant, in time order.
+func Upcoming(rules []Rule, after time.Time, n int) []Trigger {
+	next := make([]time.Time, len(rules))
+	for i, rule := range rules {
+		next[i] = rule.Next(after)
+	}
+	var triggers []Trigger
+	for len(triggers) < n {
+		earliest := -1
+		for i, at := range next {
+			if !at.IsZero() && (earliest < 0 || at.Before(next[earliest])) {
+				earliest = i
+			}
+		}
+		if earliest < 0 {
+			break
+		}
+		triggers = append(triggers, Trigger{Rule: rules[earliest], At: next[earliest]})
+		next[earliest] = rules[earliest].Next(next[earliest])
+	}
+	return triggers
+}
+
+// When describes the rule's timing the way it was configured.
+func (r Rule) When() string {
+	return Describe(r.Schedule)
+}
+
+// Describe renders a schedule's timing as "07:00 weekdays" or "cron 0 7 * * 1-5".
+func Describe(schedule config.Schedule) string {
+	if cron := strings.TrimSpace(schedule.Cron); cron != "" {
+		return "cron " + cron
+	}
+	days := strings.TrimSpace(schedule.Days)
+	if days == "" {
+		days = "daily"
+	}
+	return strings.TrimSpace(schedule.At) + " " + days
+}
+
+// DescribeTarget names what a schedule controls.
+func DescribeTarget(schedule config.Schedule) string {
+	switch {
+	case schedule.Group != "":
+		return "group " + schedule.Group
+	case schedule.IP != "":
+		return schedule.IP
+	default:
+		return "default device"
+	}
+}
+
+// Due returns, for each rule, the latest occurrence in (from, to]. Triggers
+// older than grace, such as ones that passed while the machine slept, are
+// returned as missed instead. Earlier occurrences in the same window are
+// collapsed because only the most recent state matters. Both slices are
+// ordered by time.
+func Due(rules []Rule, from, to time.Time, grace time.Duration) (due, missed []Trigger) {
+	if to.Sub(from) > maxCatchUp {
+		from = to.Add(-maxCatchUp)
+	}
+	for _, rule := range rules {
+		var latest time.Time
+		for next := rule.Next(from); !next.IsZero() && !next.After(to); next = rule.Next(next) {
+			latest = next
+		}
+		if latest.IsZero() {
+			continue
+		}
+		trigger := Trigger{Rule: rule, At: latest}
+		if to.Sub(latest) > grace {
+			missed = append(missed, trigger)
+			continue
+		}
+		due = append(due, trigger)
+	}
+	byTime := func(triggers []Trigger) {
+		sort.SliceStable(triggers, func(i, j int) bool { return triggers[i].At.Before(triggers[j].At) })
+	}
+	byTime(due)
+	byTime(missed)
+	return due, missed
+}
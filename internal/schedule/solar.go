@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Daylight classifies a day at a location.
+type Daylight int
+
+const (
+	// NormalDay has both a sunrise and a sunset.
+	NormalDay Daylight = iota
+	// PolarDay has the sun above the horizon all day.
+	PolarDay
+	// PolarNight has the sun below the horizon all day.
+	PolarNight
+)
+
+const (
+	julianUnixEpoch = 2440587.5
+	julian2000      = 2451545.0
+	// sunriseAltitude accounts for refraction and the solar disc radius.
+	sunriseAltitude = -0.833
+	axialTilt       = 23.4397
+)
+
+// SunTimes returns sunrise and sunset for the calendar day of date at the
+// given latitude and longitude in degrees (north and east positive), using
+// the NOAA sunrise equation. Results are accurate to about a minute and are
+// returned in date's location. On polar days and nights both times are zero.
+func SunTimes(date time.Time, latitude, longitude float64) (sunrise, sunset time.Time, daylight Daylight) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(day.Unix())/86400 + julianUnixEpoch - julian2000 + 0.0008)
+
+	meanNoon := n - longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*meanNoon, 360)
+	anomalyRad := radians(anomaly)
+	center := 1.9148*math.Sin(anomalyRad) + 0.02*math.Sin(2*anomalyRad) + 0.0003*math.Sin(3*anomalyRad)
+	eclipticLongitude := radians(math.Mod(anomaly+center+180+102.9372, 360))
+	transit := julian2000 + meanNoon + 0.0053*math.Sin(anomalyRad) - 0.0069*math.Sin(2*eclipticLongitude)
+
+	declination := math.Asin(math.Sin(eclipticLongitude) * math.Sin(radians(axialTilt)))
+	latitudeRad := radians(latitude)
+	cosHourAngle := (math.Sin(radians(sunriseAltitude)) - math.Sin(latitudeRad)*math.Sin(declination)) /
+		(math.Cos(latitudeRad) * math.Cos(declination))
+	switch {
+	case cosHourAngle < -1:
+		return time.Time{}, time.Time{}, PolarDay
+	case cosHourAngle > 1:
+		return time.Time{}, time.Time{}, PolarNight
+	}
+
+	hourAngle := math.Acos(cosHourAngle) * 180 / math.Pi
+	loc := date.Location()
+	return julianTime(transit - hourAngle/360).In(loc), julianTime(transit + hourAngle/360).In(loc), NormalDay
+}
+
+// solarTime is a trigger relative to sunrise or sunset, such as "sunset-30m".
+type solarTime struct {
+	sunset bool
+	offset time.Duration
+}
+
+// parseSolar parses "sunrise" or "sunset" with an optional signed offset.
+// It reports false when at is not sun-relative.
+func parseSolar(at string) (solarTime, bool, error) {
+	at = strings.ToLower(strings.TrimSpace(at))
+	var solar solarTime
+	switch {
+	case strings.HasPrefix(at, "sunrise"):
+		at = strings.TrimPrefix(at, "sunrise")
+	case strings.HasPrefix(at, "sunset"):
+		solar.sunset = true
+		at = strings.TrimPrefix(at, "sunset")
+	default:
+		return solarTime{}, false, nil
+	}
+	if at == "" {
+		return solar, true, nil
+	}
+	if at[0] != '+' && at[0] != '-' {
+		return solarTime{}, true, fmt.Errorf("%w: offset %q must start with + or -", ErrInvalidSpec, at)
+	}
+	offset, err := time.ParseDuration(at)
+	if err != nil {
+		return solarTime{}, true, fmt.Errorf("%w: offset %q: %v", ErrInvalidSpec, at, err)
+	}
+	if offset <= -12*time.Hour || offset >= 12*time.Hour {
+		return solarTime{}, true, fmt.Errorf("%w: offset %s must be under 12h", ErrInvalidSpec, offset)
+	}
+	solar.offset = offset
+	return solar, true, nil
+}
+
+// on returns the trigger time for the given day, or false on polar days and
+// nights when the sun event does not happen.
+func (s solarTime) on(day time.Time, latitude, longitude float64) (time.Time, bool) {
+	sunrise, sunset, daylight := SunTimes(day, latitude, longitude)
+	if daylight != NormalDay {
+		return time.Time{}, false
+	}
+	event := sunrise
+	if s.sunset {
+		event = sunset
+	}
+	return event.Add(s.offset).Truncate(time.Minute), true
+}
+
+func julianTime(julian float64) time.Time {
+	seconds := (julian - julianUnixEpoch) * 86400
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,239 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSpec is returned for malformed cron expressions, times, or day lists.
+var ErrInvalidSpec = errors.New("invalid schedule")
+
+// maxSearchDays bounds Next for specs that match rarely, such as Feb 29 on a Monday.
+const maxSearchDays = 366 * 8
+
+// Spec is a compiled minute-resolution recurrence in local time.
+type Spec struct {
+	minutes uint64
+	hours   uint32
+	doms    uint32
+	months  uint16
+	dows    uint8
+	anyDOM  bool
+	anyDOW  bool
+}
+
+var dayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// ParseCron parses a five-field cron expression: minute, hour, day of month,
+// month, and day of week. Fields accept *, lists, ranges, /steps, and day or
+// month names. As in cron, a day matches either restricted day field.
+func ParseCron(expr string) (Spec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Spec{}, fmt.Errorf("%w: cron %q needs 5 fields, got %d", ErrInvalidSpec, expr, len(fields))
+	}
+
+	var spec Spec
+	var err error
+	var bits uint64
+	if spec.minutes, _, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return Spec{}, fmt.Errorf("%w: minute: %v", ErrInvalidSpec, err)
+	}
+	if bits, _, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return Spec{}, fmt.Errorf("%w: hour: %v", ErrInvalidSpec, err)
+	}
+	spec.hours = uint32(bits)
+	if bits, spec.anyDOM, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return Spec{}, fmt.Errorf("%w: day of month: %v", ErrInvalidSpec, err)
+	}
+	spec.doms = uint32(bits)
+	if bits, _, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return Spec{}, fmt.Errorf("%w: month: %v", ErrInvalidSpec, err)
+	}
+	spec.months = uint16(bits)
+	if spec.dows, spec.anyDOW, err = parseDays(fields[4]); err != nil {
+		return Spec{}, fmt.Errorf("%w: day of week: %v", ErrInvalidSpec, err)
+	}
+	return spec, nil
+}
+
+// ParseDaily builds a spec that fires at a HH:MM time on the given days.
+// Days may be empty or "daily", "weekdays", "weekends", or a list of day
+// names and ranges such as "mon-fri" or "sat,sun".
+func ParseDaily(at, days string) (Spec, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(at))
+	if err != nil {
+		return Spec{}, fmt.Errorf("%w: time %q must be HH:MM", ErrInvalidSpec, at)
+	}
+	spec, err := daySpec(days)
+	if err != nil {
+		return Spec{}, err
+	}
+	spec.minutes = 1 << uint(clock.Minute())
+	spec.hours = 1 << uint(clock.Hour())
+	return spec, nil
+}
+
+// daySpec builds a spec matching the given days with no times set.
+func daySpec(days string) (Spec, error) {
+	spec := Spec{
+		doms:   uint32(allBits(1, 31)),
+		months: uint16(allBits(1, 12)),
+		anyDOM: true,
+	}
+	var err error
+	if spec.dows, spec.anyDOW, err = parseDays(days); err != nil {
+		return Spec{}, fmt.Errorf("%w: days: %v", ErrInvalidSpec, err)
+	}
+	return spec, nil
+}
+
+// Next returns the first time after the given instant that the spec matches,
+// or the zero time if it never does.
+func (s Spec) Next(after time.Time) time.Time {
+	loc := after.Location()
+	start := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, loc).Add(time.Minute)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i < maxSearchDays; i++ {
+		if s.matchesDay(day) {
+			for hour := 0; hour < 24; hour++ {
+				if s.hours&(1<<uint(hour)) == 0 {
+					continue
+				}
+				for minute := 0; minute < 60; minute++ {
+					if s.minutes&(1<<uint(minute)) == 0 {
+						continue
+					}
+					candidate := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+					if !candidate.Before(start) {
+						return candidate
+					}
+				}
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+	return time.Time{}
+}
+
+func (s Spec) matchesDay(day time.Time) bool {
+	if s.months&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	domMatch := s.doms&(1<<uint(day.Day())) != 0
+	dowMatch := s.dows&(1<<uint(day.Weekday())) != 0
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dowMatch
+	case s.anyDOW:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// parseDays parses a day-of-week field, also accepting the daily, weekdays,
+// and weekends shorthands. Sunday is 0 or 7.
+func parseDays(days string) (uint8, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(days)) {
+	case "", "*", "daily", "everyday":
+		return uint8(allBits(0, 6)), true, nil
+	case "weekdays":
+		days = "1-5"
+	case "weekends":
+		days = "0,6"
+	}
+	bits, wildcard, err := parseField(days, 0, 7, dayNames)
+	if err != nil {
+		return 0, false, err
+	}
+	if bits&(1<<7) != 0 {
+		bits |= 1
+	}
+	return uint8(bits & allBits(0, 6)), wildcard, nil
+}
+
+// parseField parses one cron field into a bit set and reports whether it was *.
+func parseField(field string, min, max int, names map[string]int) (uint64, bool, error) {
+	field = strings.ToLower(strings.TrimSpace(field))
+	if field == "" {
+		return 0, false, errors.New("empty field")
+	}
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+			if err != nil || parsed <= 0 {
+				return 0, false, fmt.Errorf("bad step %q", stepPart)
+			}
+			step = parsed
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = fieldValue(lowPart, min, max, names); err != nil {
+				return 0, false, err
+			}
+			if high, err = fieldValue(highPart, min, max, names); err != nil {
+				return 0, false, err
+			}
+			if low > high {
+				return 0, false, fmt.Errorf("range %q is reversed", rangePart)
+			}
+		default:
+			value, err := fieldValue(rangePart, min, max, names)
+			if err != nil {
+				return 0, false, err
+			}
+			low = value
+			if !hasStep {
+				high = value
+			}
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, field == "*", nil
+}
+
+func fieldValue(text string, min, max int, names map[string]int) (int, error) {
+	text = strings.TrimSpace(text)
+	if len(text) >= 3 {
+		if value, ok := names[text[:3]]; ok {
+			return value, nil
+		}
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", text)
+	}
+	if value < min || value > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", value, min, max)
+	}
+	return value, nil
+}
+
+func allBits(min, max int) uint64 {
+	var bits uint64
+	for value := min; value <= max; value++ {
+		bits |= 1 << uint(value)
+	}
+	return bits
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package timers
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// processAlive reports whether a process with pid exists on Unix systems.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// killProcess terminates a timer worker on Unix systems.
+func killProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// processStart returns an identifier for when pid started, empty when it
+// cannot be read. Linux reads the start tick from /proc; other Unix systems ask ps.
+func processStart(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	if data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat"); err == nil {
+		// The command name may contain spaces, so count fields after its closing paren.
+		stat := string(data)
+		if end := strings.LastIndexByte(stat, ')'); end >= 0 {
+			fields := strings.Fields(stat[end+1:])
+			// starttime is field 22 of the stat line; fields here begin at field 3.
+			if len(fields) > 19 {
+				return fields[19]
+			}
+		}
+		return ""
+	}
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// tryLockFile takes an exclusive flock on file without blocking, reporting
+// false when another process holds it.
+func tryLockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases a lock taken by tryLockFile.
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,276 @@
+// Package timers persists detached sleep timers so they can be listed and
+// cancelled across TUI restarts and from the CLI.
+package timers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wiz-tui/internal/config"
+)
+
+// ErrNotFound is returned when no active timer matches an id.
+var ErrNotFound = errors.New("timer not found")
+
+// Timer records one detached timer worker.
+type Timer struct {
+	ID       string    `json:"id"`
+	PID      int       `json:"pid"`
+	Name     string    `json:"name,omitempty"`
+	Group    string    `json:"group,omitempty"`
+	IP       string    `json:"ip,omitempty"`
+	Port     string    `json:"port,omitempty"`
+	Action   string    `json:"action"`
+	FadeMs   int       `json:"fadeMs,omitempty"`
+	Deadline time.Time `json:"deadline"`
+	Created  time.Time `json:"created"`
+	// Started identifies the worker's process start so a reused PID is not
+	// mistaken for the worker. Add fills it in when empty.
+	Started string `json:"started,omitempty"`
+}
+
+// Remaining returns the time left until the timer fires, never negative.
+func (t Timer) Remaining(now time.Time) time.Duration {
+	if remaining := t.Deadline.Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Target returns the timer group or target name, falling back to its address.
+func (t Timer) Target() string {
+	if strings.TrimSpace(t.Group) != "" {
+		return "group " + t.Group
+	}
+	if strings.TrimSpace(t.Name) != "" {
+		return t.Name
+	}
+	return t.IP + ":" + t.Port
+}
+
+// Path returns the registry file location, next to the config file.
+func Path() string {
+	return filepath.Join(filepath.Dir(config.Path()), ".lumina-timers.json")
+}
+
+// NewID returns a short random timer id.
+func NewID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// lockPath returns the lock file guarding registry updates.
+func lockPath() string {
+	return Path() + ".lock"
+}
+
+// staleAfter is how long past its deadline an entry is kept before it is
+// treated as abandoned; workers remove their own entry when they finish.
+const staleAfter = time.Minute
+
+// List returns active timers ordered by deadline, pruning entries whose
+// worker has exited.
+func List() ([]Timer, error) {
+	var active []Timer
+	err := update(func(all []Timer) ([]Timer, bool) {
+		now := time.Now()
+		active = make([]Timer, 0, len(all))
+		for _, timer := range all {
+			if workerAlive(timer) && now.Before(timer.Deadline.Add(staleAfter)) {
+				active = append(active, timer)
+			}
+		}
+		return active, len(active) != len(all)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Deadline.Before(active[j].Deadline) })
+	return active, nil
+}
+
+// Add records a timer, replacing any entry with the same id.
+func Add(timer Timer) error {
+	if timer.Started == "" {
+		timer.Started = processStart(timer.PID)
+	}
+	return update(func(all []Timer) ([]Timer, bool) {
+		return append(without(all, timer.ID), timer), true
+	})
+}
+
+// Remove deletes a timer entry without touching its worker.
+func Remove(id string) error {
+	return update(func(all []Timer) ([]Timer, bool) {
+		kept := without(all, id)
+		return kept, len(kept) != len(all)
+	})
+}
+
+// Cancel stops a timer's worker and removes it. The id may be a unique prefix.
+func Cancel(id string) (Timer, error) {
+	timer, err := Find(id)
+	if err != nil {
+		return Timer{}, err
+	}
+	// Find only returns timers whose worker is still the recorded process,
+	// so the signal never reaches an unrelated process that reused the PID.
+	if err := killProcess(timer.PID); err != nil && workerAlive(timer) {
+		return timer, fmt.Errorf("failed to stop timer %s (pid %d): %w", timer.ID, timer.PID, err)
+	}
+	return timer, Remove(timer.ID)
+}
+
+// Find returns the active timer whose id equals or uniquely starts with id.
+func Find(id string) (Timer, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	active, err := List()
+	if err != nil {
+		return Timer{}, err
+	}
+	var matches []Timer
+	for _, timer := range active {
+		if timer.ID == id {
+			return timer, nil
+		}
+		if id != "" && strings.HasPrefix(timer.ID, id) {
+			matches = append(matches, timer)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Timer{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	case 1:
+		return matches[0], nil
+	default:
+		return Timer{}, fmt.Errorf("timer id %q is ambiguous (%d matches)", id, len(matches))
+	}
+}
+
+// workerAlive reports whether the timer's worker process is still running,
+// rejecting a live PID that now belongs to a different process.
+func workerAlive(timer Timer) bool {
+	if !processAlive(timer.PID) {
+		return false
+	}
+	return timer.Started == "" || processStart(timer.PID) == timer.Started
+}
+
+func without(all []Timer, id string) []Timer {
+	kept := make([]Timer, 0, len(all))
+	for _, existing := range all {
+		if existing.ID != id {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
+
+// Lock timing for registry updates. The lock is an OS file lock, so it is
+// released when its holder exits, even mid-update.
+const (
+	lockRetry   = 10 * time.Millisecond
+	lockTimeout = 5 * time.Second
+)
+
+// update runs change on the registry while holding the cross-process lock and
+// saves the result when change reports it modified the entries.
+func update(change func([]Timer) ([]Timer, bool)) error {
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	all, err := load()
+	if err != nil {
+		return err
+	}
+	next, changed := change(all)
+	if !changed {
+		return nil
+	}
+	return save(next)
+}
+
+// lock takes the registry lock file, waiting for other processes to release it.
+func lock() (func(), error) {
+	path := lockPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		if locked {
+			return func() {
+				_ = unlockFile(file)
+				_ = file.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			_ = file.Close()
+			return nil, fmt.Errorf("timer registry is locked: %s", path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+func load() ([]Timer, error) {
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var all []Timer
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to read timer registry: %w", err)
+	}
+	return all, nil
+}
+
+// save writes the registry atomically so concurrent workers never see a partial file.
+func save(all []Timer) error {
+	if all == nil {
+		all = []Timer{}
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(Path()), ".lumina-timers-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), Path())
+}
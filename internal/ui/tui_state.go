@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,7 +10,10 @@ import (
 	"strings"
 	"time"
 
+	"wiz-tui/internal/actions"
 	"wiz-tui/internal/config"
+	"wiz-tui/internal/schedule"
+	"wiz-tui/internal/timers"
 	"wiz-tui/internal/wiz"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -25,10 +30,22 @@ const (
 	colorPickerView
 	hexInputView
 	brightnessView
+	temperatureView
+	sceneView
 	timerInputView
+	timerFadeView
 	discoveryView
 	savedDevicesView
 	saveDeviceNameView
+	groupsView
+	groupNameView
+	groupMembersView
+	presetNameView
+	transitionView
+	timersView
+	schedulesView
+	scheduleEditView
+	alarmView
 	helpView
 )
 
@@ -40,12 +57,69 @@ type discoveryResultMsg struct {
 	elapsed time.Duration
 }
 
+type capabilitiesResultMsg struct {
+	ip   string
+	caps wiz.Capabilities
+	err  error
+}
+
+type powerReadingMsg struct {
+	ip         string
+	generation int
+	watts      float64
+	err        error
+}
+
 type stateSyncResultMsg struct {
 	state   wiz.PilotState
 	err     error
 	elapsed time.Duration
 }
 
+// commandResultMsg reports a dispatched command; undo restores the light
+// state shown before its optimistic update.
+type commandResultMsg struct {
+	seq     int
+	action  string
+	success string
+	results []wiz.Result
+	undo    lightState
+}
+
+type timersLoadedMsg struct {
+	timers []timers.Timer
+	err    error
+}
+
+type timerCancelledMsg struct {
+	timer timers.Timer
+	err   error
+}
+
+type timersTickMsg struct{}
+
+type savedDeviceResolvedMsg struct {
+	device config.SavedDevice
+	found  bool
+	err    error
+}
+
+// pushMsg carries a syncPilot notification from the push listener.
+type pushMsg struct {
+	wiz.Push
+}
+
+// lightState is the optimistically updated part of the model.
+type lightState struct {
+	isOn         bool
+	brightness   int
+	currentColor string
+	colorTemp    int
+	whiteMode    bool
+	activeScene  int
+	sceneSpeed   int
+}
+
 var (
 	mauve   = lipgloss.Color("#CBA6F7")
 	blue    = lipgloss.Color("#89B4FA")
@@ -57,33 +131,67 @@ var (
 	base    = lipgloss.Color("#1E1E2E")
 )
 
-var colorPalette = []struct{ name, hex string }{
-	{"Warm", "#FFB56B"}, {"Day", "#FFE4CE"}, {"Cool", "#E0F7FA"},
-	{"Ruby", "#FF0033"}, {"Rose", "#FF66CC"}, {"Pink", "#FFB6C1"},
-	{"Peach", "#FF9966"}, {"Orng", "#FF8C00"}, {"Gold", "#FFD700"},
-	{"Lime", "#32CD32"}, {"Mint", "#98FF98"}, {"Emrld", "#00FF00"},
-	{"Teal", "#008080"}, {"Aqua", "#00FFFF"}, {"Sky", "#87CEEB"},
-	{"Ocean", "#006994"}, {"Blue", "#0000FF"}, {"Navy", "#000080"},
-	{"Lvndr", "#E6E6FA"}, {"Prple", "#800080"}, {"Mgnta", "#FF00FF"},
+// transitionSteps are the selectable fade durations, starting with instant.
+var transitionSteps = []time.Duration{0, 500 * time.Millisecond, time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second, time.Minute}
+
+// Per key press adjustments for brightness, temperature, and scene speed.
+const (
+	brightnessStep     = 10
+	fineBrightnessStep = 1
+	minBrightness      = 10
+	kelvinStep         = 100
+	sceneSpeedStep     = 10
+)
+
+// powerPollInterval is how often a metering plug is asked for its wattage.
+const powerPollInterval = 2 * time.Second
+
+// colorPalette entries with a kelvin value are sent as tunable white instead of RGB.
+var colorPalette = []struct {
+	name, hex string
+	kelvin    int
+}{
+	{"Warm", "#FFB56B", 2700}, {"Day", "#FFE4CE", 4200}, {"Cool", "#E0F7FA", 6500},
+	{"Ruby", "#FF0033", 0}, {"Rose", "#FF66CC", 0}, {"Pink", "#FFB6C1", 0},
+	{"Peach", "#FF9966", 0}, {"Orng", "#FF8C00", 0}, {"Gold", "#FFD700", 0},
+	{"Lime", "#32CD32", 0}, {"Mint", "#98FF98", 0}, {"Emrld", "#00FF00", 0},
+	{"Teal", "#008080", 0}, {"Aqua", "#00FFFF", 0}, {"Sky", "#87CEEB", 0},
+	{"Ocean", "#006994", 0}, {"Blue", "#0000FF", 0}, {"Navy", "#000080", 0},
+	{"Lvndr", "#E6E6FA", 0}, {"Prple", "#800080", 0}, {"Mgnta", "#FF00FF", 0},
 }
 
 type model struct {
-	state         sessionState
-	setupStep     int
-	choices       []string
-	icons         []string
-	cursor        int
-	colorCursor   int
-	status        string
-	ip, port      string
-	isOn          bool
-	currentColor  string
-	brightness    int
-	textInput     textinput.Model
-	spinner       spinner.Model
-	timerActive   bool
-	detachedTimer bool
-	syncingState  bool
+	state        sessionState
+	setupStep    int
+	choices      []string
+	icons        []string
+	cursor       int
+	colorCursor  int
+	status       string
+	ip, port     string
+	isOn         bool
+	currentColor string
+	brightness   int
+	colorTemp    int
+	whiteMode    bool
+	sceneCursor  int
+	sceneSpeed   int
+	activeScene  int
+	capabilities wiz.Capabilities
+	textInput    textinput.Model
+	spinner      spinner.Model
+	timerActive  bool
+	timerMinutes int
+	timerFade    time.Duration
+	timerAction  actions.Action
+	alarmMinute  int
+	alarmRamp    time.Duration
+	alarmField   int
+	syncingState bool
+	inFlight     int
+	commandSeq   int
+	resolvingMAC string
+	transition   time.Duration
 
 	discovering        bool
 	discoveredDevices  []wiz.Device
@@ -91,17 +199,57 @@ type model struct {
 	savedDevices       []config.SavedDevice
 	savedDeviceCursor  int
 	pendingSaveDevice  wiz.Device
+	groups             []config.Group
+	activeGroup        string
+	presets            []config.Preset
+	schedules          []config.Schedule
+	location           *config.Location
+	scheduleCursor     int
+	scheduleEditIndex  int
+	scheduleFields     []string
+	scheduleField      int
+	groupCursor        int
+	groupMemberCursor  int
+	editingGroup       config.Group
+	lastResults        []wiz.Result
 	discoveryRuns      int
 	lastDiscoveryCount int
 	lastDiscoveryMs    int
 
 	commandTotal       int
 	commandFailed      int
+	commandFailures    map[string]int
 	brightnessHistory  []int
 	commandLatencyMs   []int
 	discoveryLatencyMs []int
+	powerHistory       []int
+	powerWatts         float64
+	powerPollGen       int
 	windowWidth        int
 	windowHeight       int
+
+	ctx             context.Context
+	cancel          context.CancelFunc
+	discoveryCancel context.CancelFunc
+	syncCancel      context.CancelFunc
+	fadeCancel      context.CancelFunc
+
+	// push receives state changes the selected device reports on its own;
+	// nil when the push port could not be bound. pushIP and pushPort are the
+	// device currently registered with it.
+	push     *wiz.PushListener
+	pushIP   string
+	pushPort string
+	// pushDeferred records a push ignored while commands were in flight.
+	pushDeferred bool
+
+	// Detached timers come from the shared registry; the local timer only
+	// runs when a worker process could not be started.
+	timers             []timers.Timer
+	timerListCursor    int
+	timersTicking      bool
+	localTimerCancel   context.CancelFunc
+	localTimerDeadline time.Time
 }
 
 // NewModel creates the first TUI model from runtime config.
@@ -123,23 +271,39 @@ func NewModel(cfg config.Config, needsSetup bool) model {
 		ti.Focus()
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return model{
+		ctx:                ctx,
+		cancel:             cancel,
 		state:              state,
 		setupStep:          0,
-		choices:            []string{"Toggle Power", "Color Grid", "Hex Colors", "Brightness", "Sleep Timer", "Discover Devices", "Saved Devices", "Help", "Exit"},
-		icons:              []string{"PWR", "CLR", "HEX", "BRT", "TMR", "DSC", "SAV", "HLP", "EXT"},
+		choices:            []string{"Toggle Power", "Color Grid", "Hex Colors", "Brightness", "Color Temp", "Scenes", "Sleep Timer", "Discover Devices", "Saved Devices", "Groups", "Transitions", "Timers", "Schedules", "Wake Alarm", "Help", "Exit"},
+		icons:              []string{"PWR", "CLR", "HEX", "BRT", "KEL", "SCN", "TMR", "DSC", "SAV", "GRP", "FDE", "TMS", "SCH", "ALM", "HLP", "EXT"},
 		status:             "Ready.",
 		ip:                 cfg.IP,
 		port:               cfg.Port,
 		isOn:               true,
 		currentColor:       "#CBA6F7",
 		brightness:         100,
+		colorTemp:          4200,
+		sceneSpeed:         wiz.DefaultSceneSpeed,
+		capabilities:       wiz.CapabilitiesFromModule(""),
 		textInput:          ti,
 		spinner:            s,
 		discoveredDevices:  []wiz.Device{},
 		deviceCursor:       0,
 		savedDevices:       cfg.SavedDevices,
+		groups:             cfg.Groups,
+		activeGroup:        cfg.ActiveGroup,
+		presets:            cfg.Presets,
+		schedules:          cfg.Schedules,
+		location:           cfg.Location,
+		transition:         time.Duration(cfg.TransitionMs) * time.Millisecond,
+		alarmMinute:        7 * 60,
+		alarmRamp:          30 * time.Minute,
 		syncingState:       !needsSetup && strings.TrimSpace(cfg.IP) != "" && strings.TrimSpace(cfg.Port) != "",
+		commandFailures:    map[string]int{},
 		brightnessHistory:  []int{100},
 		commandLatencyMs:   []int{},
 		discoveryLatencyMs: []int{},
@@ -148,20 +312,614 @@ func NewModel(cfg config.Config, needsSetup bool) model {
 	}
 }
 
-// persistConfig saves current target and saved devices to config storage.
+// WithPushListener makes the dashboard follow state changes the selected
+// device pushes, such as those made from the phone app or a wall switch.
+func (m model) WithPushListener(listener *wiz.PushListener) model {
+	m.push = listener
+	// Init registers the starting target.
+	m.pushIP, m.pushPort = m.ip, m.port
+	return m
+}
+
+// persistConfig saves current target, saved devices, and groups to config storage.
 func (m *model) persistConfig() {
-	_ = config.Save(config.Config{IP: m.ip, Port: m.port, SavedDevices: m.savedDevices})
+	_ = config.Save(m.config())
+}
+
+// config snapshots the persisted settings held by the model.
+func (m model) config() config.Config {
+	return config.Config{
+		IP:           m.ip,
+		Port:         m.port,
+		SavedDevices: m.savedDevices,
+		Groups:       m.groups,
+		ActiveGroup:  m.activeGroup,
+		TransitionMs: int(m.transition / time.Millisecond),
+		Presets:      m.presets,
+		Schedules:    m.schedules,
+		Location:     m.location,
+	}
+}
+
+// commandTargets returns every member of the active group, or the single active target.
+func (m model) commandTargets() []wiz.Target {
+	cfg := m.config()
+	if group, ok := cfg.FindGroup(m.activeGroup); ok && m.activeGroup != "" {
+		members := cfg.GroupMembers(group)
+		targets := make([]wiz.Target, 0, len(members))
+		for _, member := range members {
+			targets = append(targets, wiz.Target{Name: member.Name, IP: member.IP, Port: member.Port})
+		}
+		return targets
+	}
+	return []wiz.Target{{Name: m.currentTargetSavedName(), IP: m.ip, Port: m.port}}
+}
+
+// applyAction updates the model to the state a resolved action produces.
+func (m *model) applyAction(action actions.Action) {
+	if action.Brightness > 0 {
+		m.brightness = action.Brightness
+	}
+	switch action.Kind {
+	case actions.Off:
+		m.isOn = false
+		return
+	case actions.Color:
+		m.currentColor = strings.ToUpper(action.ColorHex)
+		m.whiteMode = false
+		m.activeScene = 0
+	case actions.White:
+		m.colorTemp = action.Temp
+		m.whiteMode = true
+		m.activeScene = 0
+	case actions.Sunrise:
+		m.brightness = 100
+		m.colorTemp = 6500
+		m.whiteMode = true
+		m.activeScene = 0
+	case actions.Scene:
+		if scene, ok := wiz.SceneByName(action.Scene); ok {
+			m.activeScene = scene.ID
+		} else if id, err := strconv.Atoi(action.Scene); err == nil {
+			m.activeScene = id
+		}
+		if action.Speed > 0 {
+			m.sceneSpeed = action.Speed
+		}
+	}
+	m.isOn = true
+}
+
+// timerLead is how early an in-process timer fires so the armed action's fade
+// ends on the deadline. It is zero when fireTimerAction will not fade.
+func (m model) timerLead() time.Duration {
+	if m.timerFade <= 0 || !m.capabilities.Dimming {
+		return 0
+	}
+	action := m.timerAction
+	if action.Kind == "" {
+		action.Kind = actions.Off
+	}
+	action, err := action.Resolve(m.presets)
+	if err != nil {
+		return 0
+	}
+	if _, ok := action.Target(); !ok {
+		return 0
+	}
+	return m.timerFade
+}
+
+// fireTimerAction applies the armed timer action when an in-process timer ends.
+func (m *model) fireTimerAction() tea.Cmd {
+	action := m.timerAction
+	if action.Kind == "" {
+		action.Kind = actions.Off
+	}
+	action, err := action.Resolve(m.presets)
+	if err != nil {
+		m.status = fmt.Sprintf("Timer action failed: %v", err)
+		return nil
+	}
+	success := "Timer finished. Power off."
+	if action.Kind != actions.Off {
+		success = fmt.Sprintf("Timer finished. Applied %s.", action)
+	}
+	undo := m.lightState()
+	if to, ok := action.Target(); ok && m.timerFade > 0 && m.capabilities.Dimming {
+		m.applyAction(action)
+		return m.fadeCommand("Timer fade", success, to, m.timerFade, undo)
+	}
+	method, params, err := action.Request()
+	if err != nil {
+		m.status = fmt.Sprintf("Timer action failed: %v", err)
+		return nil
+	}
+	m.applyAction(action)
+	return m.dispatchCommand("Timer "+action.String(), success, method, params, undo)
+}
+
+// currentPreset captures the current light state under a preset name.
+func (m model) currentPreset(name string) config.Preset {
+	preset := config.Preset{Name: name, Power: m.isOn}
+	if m.capabilities.Dimming {
+		preset.Brightness = m.brightness
+	}
+	switch {
+	case m.activeScene > 0 && m.capabilities.Scenes:
+		if scene, ok := wiz.SceneByID(m.activeScene); ok {
+			preset.Scene = scene.Name
+			preset.Speed = m.sceneSpeed
+		}
+	case m.whiteMode && m.capabilities.ColorTemp:
+		preset.Temp = m.colorTemp
+	case !m.whiteMode && m.capabilities.Color:
+		preset.ColorHex = m.currentColor
+	}
+	return preset
+}
+
+// upsertPreset adds a preset or replaces the one with the same name.
+func (m *model) upsertPreset(preset config.Preset) {
+	for i := range m.presets {
+		if strings.EqualFold(m.presets[i].Name, preset.Name) {
+			m.presets[i] = preset
+			return
+		}
+	}
+	m.presets = append(m.presets, preset)
+}
+
+// scheduleFieldLabels names the schedule editor fields in order.
+var scheduleFieldLabels = []string{"Name", "When", "Action", "Target", "Fade mins"}
+
+// scheduleFormFields renders a schedule into editor field values.
+func (m model) scheduleFormFields(rule config.Schedule) []string {
+	when := strings.TrimSpace(rule.Cron)
+	if when == "" {
+		when = strings.TrimSpace(rule.At + " " + rule.Days)
+	}
+	target := rule.Group
+	if target == "" {
+		target = rule.IP
+	}
+	fade := ""
+	if rule.FadeMs > 0 {
+		fade = strconv.Itoa(rule.FadeMs / int(time.Minute/time.Millisecond))
+	}
+	return []string{rule.Name, when, rule.Action, target, fade}
+}
+
+// scheduleFromForm parses editor field values into a validated schedule.
+// When is either "HH:MM [days]", "sunset-30m [days]", or a five-field cron expression; Target is a
+// group name, an IP, or empty for the configured device.
+func (m model) scheduleFromForm(fields []string, disabled bool) (config.Schedule, error) {
+	rule := config.Schedule{Name: strings.TrimSpace(fields[0]), Action: strings.TrimSpace(fields[2]), Disabled: disabled}
+
+	when := strings.Fields(fields[1])
+	if len(when) > 0 && strings.EqualFold(when[0], "cron") {
+		when = when[1:]
+	}
+	switch {
+	case len(when) == 5:
+		rule.Cron = strings.Join(when, " ")
+	case len(when) > 0:
+		rule.At = when[0]
+		rule.Days = strings.Join(when[1:], ",")
+	}
+
+	target := strings.TrimSpace(fields[3])
+	cfg := m.config()
+	if group, ok := cfg.FindGroup(target); ok && target != "" {
+		rule.Group = group.Name
+	} else if target != "" {
+		if err := config.Validate(target, m.port); err != nil {
+			return config.Schedule{}, fmt.Errorf("target %q is not a group or IP", target)
+		}
+		rule.IP = target
+	}
+
+	if fade := strings.TrimSpace(fields[4]); fade != "" {
+		mins, err := strconv.Atoi(fade)
+		if err != nil || mins < 0 {
+			return config.Schedule{}, fmt.Errorf("fade %q must be whole minutes", fade)
+		}
+		rule.FadeMs = mins * int(time.Minute/time.Millisecond)
+	}
+
+	if _, err := schedule.Compile(rule, m.location); err != nil {
+		return config.Schedule{}, err
+	}
+	return rule, nil
+}
+
+// editSchedule opens the schedule editor for an existing rule, or a new one when index is -1.
+func (m *model) editSchedule(index int) {
+	rule := config.Schedule{At: "22:00", Action: "off"}
+	if index >= 0 {
+		rule = m.schedules[index]
+	}
+	m.scheduleEditIndex = index
+	m.scheduleFields = m.scheduleFormFields(rule)
+	m.scheduleField = 0
+	m.state = scheduleEditView
+	m.focusScheduleField()
+}
+
+// focusScheduleField loads the selected editor field into the text input.
+func (m *model) focusScheduleField() {
+	m.textInput.CharLimit = 64
+	m.textInput.Placeholder = scheduleFieldLabels[m.scheduleField]
+	m.textInput.SetValue(m.scheduleFields[m.scheduleField])
+	m.textInput.CursorEnd()
+	m.textInput.Focus()
+}
+
+// moveScheduleField stores the current field and moves the editor by delta fields.
+func (m *model) moveScheduleField(delta int) {
+	m.scheduleFields[m.scheduleField] = m.textInput.Value()
+	m.scheduleField = (m.scheduleField + delta + len(scheduleFieldLabels)) % len(scheduleFieldLabels)
+	m.focusScheduleField()
+}
+
+// saveScheduleForm validates the editor and stores the rule in the config.
+func (m *model) saveScheduleForm() {
+	m.scheduleFields[m.scheduleField] = m.textInput.Value()
+	disabled := m.scheduleEditIndex >= 0 && m.schedules[m.scheduleEditIndex].Disabled
+	rule, err := m.scheduleFromForm(m.scheduleFields, disabled)
+	if err != nil {
+		m.status = fmt.Sprintf("Invalid schedule: %v", err)
+		return
+	}
+	if m.scheduleEditIndex >= 0 {
+		m.schedules[m.scheduleEditIndex] = rule
+	} else {
+		m.schedules = append(m.schedules, rule)
+		m.scheduleCursor = len(m.schedules) - 1
+	}
+	m.persistConfig()
+	m.textInput.Blur()
+	m.status = fmt.Sprintf("Saved schedule: %s (applied by lumina daemon)", rule.Name)
+	m.state = schedulesView
+}
+
+const (
+	alarmStep     = 5
+	minAlarmRamp  = 5 * time.Minute
+	maxAlarmRamp  = 2 * time.Hour
+	alarmRampStep = 5 * time.Minute
+)
+
+// alarmClock formats the alarm time as HH:MM.
+func (m model) alarmClock() string {
+	return fmt.Sprintf("%02d:%02d", m.alarmMinute/60, m.alarmMinute%60)
+}
+
+// adjustAlarm moves the selected alarm field by delta steps.
+func (m *model) adjustAlarm(delta int) {
+	if m.alarmField == 0 {
+		m.alarmMinute = ((m.alarmMinute+delta*alarmStep)%(24*60) + 24*60) % (24 * 60)
+		return
+	}
+	m.alarmRamp += time.Duration(delta) * alarmRampStep
+	if m.alarmRamp < minAlarmRamp {
+		m.alarmRamp = minAlarmRamp
+	}
+	if m.alarmRamp > maxAlarmRamp {
+		m.alarmRamp = maxAlarmRamp
+	}
+}
+
+// nextAlarm returns when the alarm next goes off.
+func (m model) nextAlarm(now time.Time) time.Time {
+	spec, err := schedule.ParseDaily(m.alarmClock(), "")
+	if err != nil {
+		return time.Time{}
+	}
+	return spec.Next(now)
+}
+
+// sunrisePreview renders the wake-up ramp for the active device as a strip of color blocks.
+func sunrisePreview(caps wiz.Capabilities, width int) string {
+	stages := wiz.SunriseStages(caps)
+	if len(stages) < 2 || width < 2 {
+		return ""
+	}
+	var strip strings.Builder
+	for i := 0; i < width; i++ {
+		progress := float64(i) / float64(width-1)
+		segment := 1
+		for segment < len(stages)-1 && progress > stages[segment].At {
+			segment++
+		}
+		from, to := stages[segment-1], stages[segment]
+		local := 1.0
+		if span := to.At - from.At; span > 0 {
+			local = (progress - from.At) / span
+		}
+		frame := wiz.Transition{From: from.State, To: to.State}.Frame(local)
+		strip.WriteString(lipgloss.NewStyle().Background(lipgloss.Color(frameHex(frame))).Render(" "))
+	}
+	return strip.String()
+}
+
+// frameHex approximates the display color of a setPilot frame, scaled by its dimming.
+func frameHex(frame map[string]interface{}) string {
+	r, g, b := 255, 255, 255
+	if temp, ok := frame["temp"].(int); ok {
+		kr, kg, kb := wiz.KelvinToRGB(temp)
+		r, g, b = int(kr), int(kg), int(kb)
+	}
+	if red, ok := frame["r"].(int); ok {
+		r, g, b = red, frame["g"].(int), frame["b"].(int)
+	}
+	level, _ := frame["dimming"].(int)
+	scale := func(value int) int { return value * (40 + level*60/100) / 100 }
+	return fmt.Sprintf("#%02X%02X%02X", scale(r), scale(g), scale(b))
+}
+
+// timerActionTitle names a timer action for status messages.
+func timerActionTitle(action actions.Action) string {
+	if action.Kind == "" || action.Kind == actions.Off {
+		return "Sleep"
+	}
+	return "Run " + action.String()
+}
+
+// lightState snapshots the state a command may optimistically change.
+func (m model) lightState() lightState {
+	return lightState{
+		isOn:         m.isOn,
+		brightness:   m.brightness,
+		currentColor: m.currentColor,
+		colorTemp:    m.colorTemp,
+		whiteMode:    m.whiteMode,
+		activeScene:  m.activeScene,
+		sceneSpeed:   m.sceneSpeed,
+	}
+}
+
+// restoreLightState rolls back an optimistic update.
+func (m *model) restoreLightState(state lightState) {
+	m.isOn = state.isOn
+	m.brightness = state.brightness
+	m.currentColor = state.currentColor
+	m.colorTemp = state.colorTemp
+	m.whiteMode = state.whiteMode
+	m.activeScene = state.activeScene
+	m.sceneSpeed = state.sceneSpeed
+}
+
+// dispatchCommand sends an acknowledged command to every active target in the
+// background. Callers update the model first and pass the prior state as undo,
+// which is restored if the command fails and no newer command was sent since.
+func (m *model) dispatchCommand(action, success, method string, params map[string]interface{}, undo lightState) tea.Cmd {
+	m.stopFade()
+	return m.dispatch(wiz.Broadcast, action, success, method, params, undo)
+}
+
+// streamCommand is dispatchCommand through the per-device coalescer, for
+// controls that fire on every key repeat.
+func (m *model) streamCommand(action, success, method string, params map[string]interface{}, undo lightState) tea.Cmd {
+	m.stopFade()
+	return m.dispatch(wiz.BroadcastCoalesced, action, success, method, params, undo)
+}
+
+// transitionCommand fades every active target to the given state over the
+// configured transition, or sends it instantly when transitions are off or
+// the device cannot dim. A newer command cancels a fade in progress.
+func (m *model) transitionCommand(action, success string, to wiz.PilotState, method string, params map[string]interface{}, undo lightState) tea.Cmd {
+	if m.transition <= 0 || !m.capabilities.Dimming {
+		return m.dispatchCommand(action, success, method, params, undo)
+	}
+	return m.fadeCommand(action, success, to, m.transition, undo)
+}
+
+// fadeCommand fades every active target from its current state to the given
+// state over duration. A newer command cancels the fade in progress.
+func (m *model) fadeCommand(action, success string, to wiz.PilotState, duration time.Duration, undo lightState) tea.Cmd {
+	m.stopFade()
+	ctx, cancel := context.WithCancel(m.context())
+	m.fadeCancel = cancel
+	fade := func(_ context.Context, targets []wiz.Target, _ string, _ map[string]interface{}) []wiz.Result {
+		defer cancel()
+		return wiz.BroadcastFade(ctx, targets, to, duration)
+	}
+	return m.dispatch(fade, action, success, "", nil, undo)
+}
+
+// stepTransition moves the fade duration to the previous or next preset.
+func (m *model) stepTransition(delta int) {
+	index := 0
+	for i, step := range transitionSteps {
+		if step <= m.transition {
+			index = i
+		}
+	}
+	index += delta
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(transitionSteps) {
+		index = len(transitionSteps) - 1
+	}
+	m.transition = transitionSteps[index]
+	m.status = "Transition: " + transitionLabel(m.transition)
+}
+
+// transitionLabel renders a fade duration for the menu and dashboard.
+func transitionLabel(d time.Duration) string {
+	if d <= 0 {
+		return "instant"
+	}
+	return d.String()
+}
+
+// stopFade cancels a transition still playing.
+func (m *model) stopFade() {
+	if m.fadeCancel != nil {
+		m.fadeCancel()
+		m.fadeCancel = nil
+	}
+}
+
+// broadcastFunc sends one command to several targets.
+type broadcastFunc func(ctx context.Context, targets []wiz.Target, method string, params map[string]interface{}) []wiz.Result
+
+func (m *model) dispatch(broadcast broadcastFunc, action, success, method string, params map[string]interface{}, undo lightState) tea.Cmd {
+	targets := m.commandTargets()
+	if len(targets) == 0 {
+		m.restoreLightState(undo)
+		m.lastResults = nil
+		m.status = fmt.Sprintf("%s failed: group %s has no saved members", action, m.activeGroup)
+		return nil
+	}
+
+	m.commandSeq++
+	m.inFlight++
+	m.status = success + " ..."
+	return tea.Batch(broadcastCmd(m.context(), broadcast, m.commandSeq, action, success, targets, method, params, undo), m.spinner.Tick)
+}
+
+// adjustBrightness moves brightness by delta within the dimmable range and
+// streams the new level through the coalescer.
+func (m *model) adjustBrightness(delta int) tea.Cmd {
+	level := m.brightness + delta
+	if level < minBrightness {
+		level = minBrightness
+	}
+	if level > 100 {
+		level = 100
+	}
+	if level == m.brightness {
+		return nil
+	}
+	undo := m.lightState()
+	m.brightness = level
+	m.brightnessHistory = appendBounded(m.brightnessHistory, m.brightness, 30)
+	return m.streamCommand("Brightness change", fmt.Sprintf("Bright: %d%%", m.brightness), "setPilot", map[string]interface{}{"dimming": m.brightness}, undo)
+}
+
+// busy reports whether any background work should keep the spinner running.
+func (m model) busy() bool {
+	return m.timerActive || len(m.timers) > 0 || m.discovering || m.syncingState || m.inFlight > 0 || m.resolvingMAC != ""
+}
+
+// commandOutcome reduces per-target results to an error, ignoring failures
+// when at least one group member accepted the command.
+func commandOutcome(results []wiz.Result) error {
+	if len(results) == 1 {
+		return results[0].Err
+	}
+	err := wiz.JoinResults(results)
+	var broadcastErr *wiz.BroadcastError
+	if errors.As(err, &broadcastErr) && broadcastErr.Partial() {
+		return nil
+	}
+	return err
+}
+
+// withGroupSummary appends per-device success counts to a status when a group is active.
+func (m model) withGroupSummary(status string) string {
+	if m.activeGroup == "" || len(m.lastResults) == 0 {
+		return status
+	}
+	var failed []string
+	for _, result := range m.lastResults {
+		if result.Err != nil {
+			failed = append(failed, result.Target.String())
+		}
+	}
+	summary := fmt.Sprintf("%s · %d/%d ok", status, len(m.lastResults)-len(failed), len(m.lastResults))
+	if len(failed) > 0 {
+		summary += " · failed: " + strings.Join(failed, ", ")
+	}
+	return summary
+}
+
+// selectSavedDevice targets a saved device, updating its stored address.
+func (m *model) selectSavedDevice(device config.SavedDevice) tea.Cmd {
+	mac := config.NormalizeMAC(device.Mac)
+	for index := range m.savedDevices {
+		if mac != "" && config.NormalizeMAC(m.savedDevices[index].Mac) == mac {
+			m.savedDevices[index].IP = device.IP
+			m.savedDevices[index].Port = device.Port
+		}
+	}
+	m.ip = device.IP
+	if device.Port != "" {
+		m.port = device.Port
+	}
+	m.activeGroup = ""
+	m.lastResults = nil
+	m.persistConfig()
+	return tea.Batch(m.startStateSync(), detectCapabilitiesCmd(m.context(), m.ip, m.port), m.spinner.Tick)
+}
+
+// setActiveGroup makes a group the command target, or clears it when name is empty.
+func (m *model) setActiveGroup(name string) tea.Cmd {
+	m.activeGroup = name
+	m.lastResults = nil
+	m.persistConfig()
+	if name == "" {
+		return tea.Batch(detectCapabilitiesCmd(m.context(), m.ip, m.port), m.startStateSync())
+	}
+	// Members may differ, so allow every control and let each bulb reject what it cannot do.
+	m.setCapabilities(wiz.CapabilitiesFromModule(""))
+	return m.startPowerPolling()
+}
+
+// groupIndex returns the index of a group by name, or -1.
+func (m model) groupIndex(name string) int {
+	for index, group := range m.groups {
+		if strings.EqualFold(group.Name, name) {
+			return index
+		}
+	}
+	return -1
+}
+
+// groupHasMember reports whether the group being edited includes a MAC.
+func (m model) groupHasMember(mac string) bool {
+	mac = config.NormalizeMAC(mac)
+	for _, member := range m.editingGroup.Members {
+		if mac != "" && config.NormalizeMAC(member) == mac {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleGroupMember adds or removes a MAC from the group being edited.
+func (m *model) toggleGroupMember(mac string) {
+	mac = config.NormalizeMAC(mac)
+	if mac == "" {
+		return
+	}
+	members := make([]string, 0, len(m.editingGroup.Members)+1)
+	found := false
+	for _, member := range m.editingGroup.Members {
+		if config.NormalizeMAC(member) == mac {
+			found = true
+			continue
+		}
+		members = append(members, member)
+	}
+	if !found {
+		members = append(members, mac)
+	}
+	m.editingGroup.Members = members
 }
 
 // upsertSavedDevice inserts or updates a saved device record keyed by MAC.
 func (m *model) upsertSavedDevice(device config.SavedDevice) {
-	if strings.TrimSpace(device.Mac) == "" {
+	device.Mac = config.NormalizeMAC(device.Mac)
+	if device.Mac == "" {
 		return
 	}
-	device.Mac = strings.ToLower(strings.TrimSpace(device.Mac))
 
 	for index := range m.savedDevices {
-		if strings.ToLower(strings.TrimSpace(m.savedDevices[index].Mac)) == device.Mac {
+		if config.NormalizeMAC(m.savedDevices[index].Mac) == device.Mac {
 			m.savedDevices[index] = device
 			return
 		}
@@ -177,7 +935,7 @@ func (m *model) applySavedNamesToDiscovered() {
 
 	savedNameByMAC := map[string]string{}
 	for _, saved := range m.savedDevices {
-		mac := strings.ToLower(strings.TrimSpace(saved.Mac))
+		mac := config.NormalizeMAC(saved.Mac)
 		name := strings.TrimSpace(saved.Name)
 		if mac == "" || name == "" {
 			continue
@@ -186,7 +944,7 @@ func (m *model) applySavedNamesToDiscovered() {
 	}
 
 	for index := range m.discoveredDevices {
-		mac := strings.ToLower(strings.TrimSpace(m.discoveredDevices[index].Mac))
+		mac := config.NormalizeMAC(m.discoveredDevices[index].Mac)
 		if mac == "" {
 			continue
 		}
@@ -200,15 +958,15 @@ func (m *model) applySavedNamesToDiscovered() {
 func (m model) currentTargetSavedName() string {
 	activeMAC := ""
 	for _, device := range m.discoveredDevices {
-		if device.IP == m.ip {
-			activeMAC = strings.ToLower(strings.TrimSpace(device.Mac))
+		if m.isTarget(device.IP, device.Port) {
+			activeMAC = config.NormalizeMAC(device.Mac)
 			break
 		}
 	}
 
 	if activeMAC != "" {
 		for _, saved := range m.savedDevices {
-			savedMAC := strings.ToLower(strings.TrimSpace(saved.Mac))
+			savedMAC := config.NormalizeMAC(saved.Mac)
 			if savedMAC != "" && savedMAC == activeMAC {
 				return saved.Name
 			}
@@ -216,7 +974,7 @@ func (m model) currentTargetSavedName() string {
 	}
 
 	for _, saved := range m.savedDevices {
-		if saved.IP == m.ip {
+		if m.isTarget(saved.IP, saved.Port) {
 			return saved.Name
 		}
 	}
@@ -224,6 +982,41 @@ func (m model) currentTargetSavedName() string {
 	return ""
 }
 
+// currentTargetMAC returns the active target's MAC from discovered or saved
+// devices, or "" when it is not known.
+func (m model) currentTargetMAC() string {
+	for _, device := range m.discoveredDevices {
+		if m.isTarget(device.IP, device.Port) {
+			if mac := config.NormalizeMAC(device.Mac); mac != "" {
+				return mac
+			}
+		}
+	}
+	for _, saved := range m.savedDevices {
+		if m.isTarget(saved.IP, saved.Port) {
+			if mac := config.NormalizeMAC(saved.Mac); mac != "" {
+				return mac
+			}
+		}
+	}
+	return ""
+}
+
+// isPushFromTarget reports whether a push came from the active target. Pushes
+// are matched by MAC when it is known, since a device's push source port is
+// not guaranteed to be the one it answers requests on, and by IP otherwise.
+func (m model) isPushFromTarget(push wiz.Push) bool {
+	if mac := m.currentTargetMAC(); mac != "" {
+		return config.NormalizeMAC(push.Mac) == mac
+	}
+	return push.IP == m.ip
+}
+
+// isTarget reports whether an endpoint is the active target; an empty port matches any.
+func (m model) isTarget(ip, port string) bool {
+	return ip == m.ip && (port == "" || port == m.port)
+}
+
 // deleteSavedDevice removes a saved device at the selected cursor position.
 func (m *model) deleteSavedDevice() {
 	if len(m.savedDevices) == 0 || m.savedDeviceCursor < 0 || m.savedDeviceCursor >= len(m.savedDevices) {
@@ -235,44 +1028,356 @@ func (m *model) deleteSavedDevice() {
 	}
 }
 
+// setCapabilities applies the active target's capabilities and clamps dependent controls.
+func (m *model) setCapabilities(caps wiz.Capabilities) {
+	m.capabilities = caps
+	if caps.ColorTemp {
+		m.colorTemp = caps.ClampKelvin(m.colorTemp)
+	}
+	if m.sceneCursor >= len(m.sceneOptions()) {
+		m.sceneCursor = 0
+	}
+}
+
+// startPowerPolling restarts the wattage poll loop for the active target when it meters power.
+// Bumping the generation retires any loop still running for a previous target.
+func (m *model) startPowerPolling() tea.Cmd {
+	m.powerPollGen++
+	if !m.capabilities.PowerMetering || strings.TrimSpace(m.ip) == "" {
+		return nil
+	}
+	m.powerHistory = nil
+	return powerPollCmd(m.context(), m.ip, m.port, m.powerPollGen, 0)
+}
+
+// menuSupported reports whether the menu action at index works on the active target.
+func (m model) menuSupported(index int) bool {
+	switch index {
+	case 1, 2:
+		return m.capabilities.Color
+	case 3:
+		return m.capabilities.Dimming
+	case 4:
+		return m.capabilities.ColorTemp
+	case 5:
+		return len(m.sceneOptions()) > 0
+	default:
+		return true
+	}
+}
+
+// sceneOptions lists the catalogue scenes the active target supports.
+func (m model) sceneOptions() []wiz.Scene {
+	scenes := make([]wiz.Scene, 0, len(wiz.Scenes))
+	for _, scene := range wiz.Scenes {
+		if m.capabilities.SupportsScene(scene.ID) {
+			scenes = append(scenes, scene)
+		}
+	}
+	return scenes
+}
+
+// quit cancels all in-flight network work and returns the Bubble Tea quit command.
+func (m *model) quit() tea.Cmd {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	return tea.Quit
+}
+
+// startDiscovery cancels any running scan and returns a command for a fresh one.
+func (m *model) startDiscovery() tea.Cmd {
+	m.stopDiscovery()
+	ctx, cancel := context.WithCancel(m.context())
+	m.discoveryCancel = cancel
+	m.discovering = true
+	return discoverDevicesCmd(ctx)
+}
+
+// stopDiscovery cancels a scan still in flight.
+func (m *model) stopDiscovery() {
+	if m.discoveryCancel != nil {
+		m.discoveryCancel()
+		m.discoveryCancel = nil
+	}
+	m.discovering = false
+}
+
+// startStateSync cancels any pending sync and returns a command that fetches target state.
+func (m *model) startStateSync() tea.Cmd {
+	if m.syncCancel != nil {
+		m.syncCancel()
+	}
+	ctx, cancel := context.WithCancel(m.context())
+	m.syncCancel = cancel
+	m.syncingState = true
+	return tea.Batch(syncDeviceStateCmd(ctx, m.ip, m.port), m.registerPush())
+}
+
+// registerPush returns a command that asks the selected device to push its
+// state changes and the previously selected one to stop, or nil without a
+// push listener.
+func (m *model) registerPush() tea.Cmd {
+	if m.push == nil || m.ip == "" || m.port == "" {
+		return nil
+	}
+	listener, ip, port := m.push, m.ip, m.port
+	previousIP, previousPort := m.pushIP, m.pushPort
+	m.pushIP, m.pushPort = ip, port
+	return func() tea.Msg {
+		if previousIP != "" && (previousIP != ip || previousPort != port) {
+			_ = listener.Unregister(previousIP, previousPort)
+		}
+		// Without a registration the dashboard still works; it only stops
+		// updating on its own.
+		_ = listener.Register(ip, port)
+		return nil
+	}
+}
+
+// applyPilotState shows a state read from or pushed by the selected device.
+func (m *model) applyPilotState(state wiz.PilotState) {
+	m.isOn = state.Power
+	if state.Brightness > 0 {
+		m.brightness = state.Brightness
+		m.brightnessHistory = appendBounded(m.brightnessHistory, m.brightness, 30)
+	}
+	if strings.TrimSpace(state.ColorHex) != "" {
+		m.currentColor = state.ColorHex
+		m.whiteMode = false
+	} else if state.Temp > 0 {
+		m.colorTemp = m.capabilities.ClampKelvin(state.Temp)
+		m.whiteMode = true
+	}
+	m.activeScene = state.SceneID
+	if state.SceneID > 0 && state.Speed > 0 {
+		m.sceneSpeed = wiz.ClampSceneSpeed(state.Speed)
+	}
+}
+
+// context returns the model root context, tolerating zero-value models.
+func (m model) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
 // startTimer returns a command that emits when a timer duration has elapsed.
-func startTimer(d time.Duration) tea.Cmd {
+func startTimer(ctx context.Context, d time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(d):
+			return timerFinishedMsg{}
+		}
+	}
+}
+
+// loadTimersCmd reads the detached timer registry asynchronously.
+func loadTimersCmd() tea.Cmd {
 	return func() tea.Msg {
-		time.Sleep(d)
-		return timerFinishedMsg{}
+		active, err := timers.List()
+		return timersLoadedMsg{timers: active, err: err}
 	}
 }
 
+// cancelTimerCmd stops a detached timer worker asynchronously.
+func cancelTimerCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		timer, err := timers.Cancel(id)
+		return timerCancelledMsg{timer: timer, err: err}
+	}
+}
+
+// timersTickCmd schedules the next registry refresh for countdowns.
+func timersTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return timersTickMsg{}
+	})
+}
+
+// scheduleTimersRefresh starts the once-a-second registry refresh unless one is pending.
+func (m *model) scheduleTimersRefresh() tea.Cmd {
+	if m.timersTicking {
+		return nil
+	}
+	m.timersTicking = true
+	return timersTickCmd()
+}
+
+// timerRows counts the entries listed in the timers view.
+func (m model) timerRows() int {
+	rows := len(m.timers)
+	if m.timerActive {
+		rows++
+	}
+	return rows
+}
+
+// nextTimerDeadline returns the soonest local or detached timer deadline.
+func (m model) nextTimerDeadline() (time.Time, bool) {
+	var next time.Time
+	if m.timerActive {
+		next = m.localTimerDeadline
+	}
+	for _, timer := range m.timers {
+		if next.IsZero() || timer.Deadline.Before(next) {
+			next = timer.Deadline
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// cancelLocalTimer stops the in-process fallback timer.
+func (m *model) cancelLocalTimer() {
+	if m.localTimerCancel != nil {
+		m.localTimerCancel()
+		m.localTimerCancel = nil
+	}
+	m.timerActive = false
+}
+
 // discoverDevicesCmd runs network discovery asynchronously.
-func discoverDevicesCmd() tea.Cmd {
+func discoverDevicesCmd(ctx context.Context) tea.Cmd {
 	return func() tea.Msg {
 		start := time.Now()
-		devices, err := wiz.DiscoverDevices()
+		devices, err := wiz.DiscoverDevicesContext(ctx)
 		return discoveryResultMsg{devices: devices, err: err, elapsed: time.Since(start)}
 	}
 }
 
+// detectCapabilitiesCmd probes the target's model configuration asynchronously.
+func detectCapabilitiesCmd(ctx context.Context, ip, port string) tea.Cmd {
+	return func() tea.Msg {
+		caps, err := wiz.DetectCapabilitiesContext(ctx, ip, port)
+		return capabilitiesResultMsg{ip: ip, caps: caps, err: err}
+	}
+}
+
+// powerPollCmd waits for delay, then reads the plug wattage asynchronously.
+func powerPollCmd(ctx context.Context, ip, port string, generation int, delay time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		watts, err := wiz.GetPowerContext(ctx, ip, port)
+		return powerReadingMsg{ip: ip, generation: generation, watts: watts, err: err}
+	}
+}
+
+// broadcastCmd sends a command to every target asynchronously.
+func broadcastCmd(ctx context.Context, broadcast broadcastFunc, seq int, action, success string, targets []wiz.Target, method string, params map[string]interface{}, undo lightState) tea.Cmd {
+	return func() tea.Msg {
+		results := broadcast(ctx, targets, method, params)
+		return commandResultMsg{seq: seq, action: action, success: success, results: results, undo: undo}
+	}
+}
+
+// resolveSavedDeviceCmd looks up a saved device's current address by MAC asynchronously.
+func resolveSavedDeviceCmd(ctx context.Context, device config.SavedDevice) tea.Cmd {
+	return func() tea.Msg {
+		discovered, err := wiz.DiscoverDevicesContext(ctx)
+		if err != nil {
+			return savedDeviceResolvedMsg{device: device, err: err}
+		}
+		mac := config.NormalizeMAC(device.Mac)
+		for _, candidate := range discovered {
+			if mac != "" && config.NormalizeMAC(candidate.Mac) == mac {
+				device.IP = candidate.IP
+				if candidate.Port != "" {
+					device.Port = candidate.Port
+				}
+				return savedDeviceResolvedMsg{device: device, found: true}
+			}
+		}
+		return savedDeviceResolvedMsg{device: device}
+	}
+}
+
 // syncDeviceStateCmd fetches current target state asynchronously.
-func syncDeviceStateCmd(ip, port string) tea.Cmd {
+func syncDeviceStateCmd(ctx context.Context, ip, port string) tea.Cmd {
 	return func() tea.Msg {
 		start := time.Now()
-		state, err := wiz.GetPilotState(ip, port)
+		state, err := wiz.GetPilotStateContext(ctx, ip, port)
 		return stateSyncResultMsg{state: state, err: err, elapsed: time.Since(start)}
 	}
 }
 
+// pushRefreshCmd reads the device after a push was deferred and reports
+// the state as a push, so it is applied only if no command is in flight.
+func pushRefreshCmd(ctx context.Context, ip, port, mac string) tea.Cmd {
+	return func() tea.Msg {
+		state, err := wiz.GetPilotStateContext(ctx, ip, port)
+		if err != nil {
+			return nil
+		}
+		return pushMsg{Push: wiz.Push{Mac: mac, IP: ip, Port: port, State: state}}
+	}
+}
+
+// waitForPushCmd waits for the next push from the listener.
+func waitForPushCmd(ctx context.Context, listener *wiz.PushListener) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-ctx.Done():
+			return nil
+		case push, ok := <-listener.Pushes():
+			if !ok {
+				return nil
+			}
+			return pushMsg{Push: push}
+		}
+	}
+}
+
 // startDetachedTimer launches a detached worker process for timer actions.
-func startDetachedTimer(mins int, ip, port string) error {
+func startDetachedTimer(mins int, fade time.Duration, action actions.Action, name, group, ip, port string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if action.Kind == "" {
+		action.Kind = actions.Off
+	}
+	args := []string{"--timer", strconv.Itoa(mins), "--action", action.JSON()}
+	if fade > 0 {
+		args = append(args, "--fade", fade.String())
+	}
+	return startDetachedWorker(exe, args, name, group, ip, port)
+}
+
+// startDetachedAlarm launches a detached worker that plays a sunrise ramp ending at a HH:MM time.
+func startDetachedAlarm(at string, ramp time.Duration, name, group, ip, port string) error {
 	exe, err := os.Executable()
 	if err != nil {
 		return err
 	}
-	args := []string{"--timer", strconv.Itoa(mins), "--ip", ip, "--port", port, "--off"}
+	args := []string{"--alarm", at, "--fade", ramp.String()}
+	return startDetachedWorker(exe, args, name, group, ip, port)
+}
+
+// startDetachedWorker starts a headless worker for the active group or device.
+func startDetachedWorker(exe string, args []string, name, group, ip, port string) error {
+	args = append(args, "--ip", ip, "--port", port)
+	if group != "" {
+		args = append(args, "--group", group)
+	} else if name != "" {
+		args = append(args, "--name", name)
+	}
 	cmd := exec.Command(exe, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	setDetachedProcessAttrs(cmd)
-	return cmd.Start()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the worker when it exits so it is not mistaken for a live timer.
+	go func() { _ = cmd.Wait() }()
+	return nil
 }
 
 // recordCommand updates command success and latency telemetry.
@@ -280,10 +1385,53 @@ func (m *model) recordCommand(latency time.Duration, err error) {
 	m.commandTotal++
 	if err != nil {
 		m.commandFailed++
+		if m.commandFailures == nil {
+			m.commandFailures = map[string]int{}
+		}
+		m.commandFailures[failureKind(err)]++
 	}
 	m.commandLatencyMs = appendBounded(m.commandLatencyMs, int(latency.Milliseconds()), 30)
 }
 
+// failureKind classifies a command error for the telemetry breakdown.
+func failureKind(err error) string {
+	var deviceErr *wiz.DeviceError
+	switch {
+	case errors.As(err, &deviceErr), errors.Is(err, wiz.ErrNotAcknowledged):
+		return "device"
+	case errors.Is(err, wiz.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, wiz.ErrUnreachable):
+		return "network"
+	case errors.Is(err, wiz.ErrNoResult), errors.Is(err, wiz.ErrMalformedResponse):
+		return "malformed"
+	default:
+		return "other"
+	}
+}
+
+// commandErrorStatus builds a targeted status message for a failed action.
+func commandErrorStatus(action string, err error) string {
+	var deviceErr *wiz.DeviceError
+	var broadcastErr *wiz.BroadcastError
+	switch {
+	case errors.As(err, &broadcastErr):
+		return fmt.Sprintf("%s failed on all %d devices", action, broadcastErr.Total)
+	case errors.As(err, &deviceErr):
+		return fmt.Sprintf("%s failed: bulb rejected it (%s, code %d)", action, deviceErr.Message, deviceErr.Code)
+	case errors.Is(err, wiz.ErrNotAcknowledged):
+		return fmt.Sprintf("%s failed: bulb did not confirm the change", action)
+	case errors.Is(err, wiz.ErrTimeout):
+		return fmt.Sprintf("%s failed: bulb did not respond", action)
+	case errors.Is(err, wiz.ErrUnreachable):
+		return fmt.Sprintf("%s failed: host unreachable", action)
+	case errors.Is(err, wiz.ErrNoResult), errors.Is(err, wiz.ErrMalformedResponse):
+		return fmt.Sprintf("%s failed: unexpected reply from bulb", action)
+	default:
+		return fmt.Sprintf("%s failed: %v", action, err)
+	}
+}
+
 // appendBounded appends to a history slice and keeps it capped.
 func appendBounded(history []int, value, maxLen int) []int {
 	if maxLen <= 0 {
@@ -424,23 +1572,45 @@ func (m model) renderDashboard() string {
 		}
 	}
 
+	targetLine := fmt.Sprintf("Target   %s:%s", m.ip, m.port)
+	if m.activeGroup != "" {
+		targetLine = fmt.Sprintf("Group    %s (%d)", m.activeGroup, len(m.commandTargets()))
+	}
+
 	targetAlias := m.currentTargetSavedName()
 	aliasLine := "Alias    -"
 	if strings.TrimSpace(targetAlias) != "" {
 		aliasLine = fmt.Sprintf("Alias    %s", targetAlias)
 	}
 
+	swatchHex := m.currentColor
+	colorLabel := m.currentColor
+	if m.whiteMode {
+		swatchHex = wiz.KelvinToHex(m.colorTemp)
+		colorLabel = fmt.Sprintf("%dK white", m.colorTemp)
+	}
+
 	colorSwatch := lipgloss.NewStyle().
-		Background(lipgloss.Color(m.currentColor)).
+		Background(lipgloss.Color(swatchHex)).
 		Foreground(base).
 		Padding(0, 2).
 		Render("  ")
 
+	sceneLine := "Scene    -"
+	if scene, ok := wiz.SceneByID(m.activeScene); ok {
+		sceneLine = fmt.Sprintf("Scene    %s", scene.Name)
+		if scene.Dynamic {
+			sceneLine += fmt.Sprintf(" @%d%%", m.sceneSpeed)
+		}
+	}
+
 	core := metricBlock("Core", []string{
 		fmt.Sprintf("Power    %s", powerStyle.Bold(true).Render(powerState)),
-		fmt.Sprintf("Target   %s:%s", m.ip, m.port),
+		targetLine,
 		aliasLine,
-		fmt.Sprintf("Color    %s %s", colorSwatch, lipgloss.NewStyle().Foreground(mauve).Render(m.currentColor)),
+		fmt.Sprintf("Type     %s", m.capabilities.Kind),
+		fmt.Sprintf("Color    %s %s", colorSwatch, lipgloss.NewStyle().Foreground(mauve).Render(colorLabel)),
+		sceneLine,
 	}, blue, 34)
 
 	brightnessBlock := metricBlock("Brightness", []string{
@@ -452,6 +1622,7 @@ func (m model) renderDashboard() string {
 	commandBlock := metricBlock("Command Health", []string{
 		lipgloss.NewStyle().Foreground(green).Render(bar(successRate, 100, 22)),
 		fmt.Sprintf("OK/Fail  %d/%d", successCount, m.commandFailed),
+		fmt.Sprintf("Causes   to:%d net:%d dev:%d bad:%d", m.commandFailures["timeout"], m.commandFailures["network"], m.commandFailures["device"], m.commandFailures["malformed"]),
 		fmt.Sprintf("Latency  %s", lipgloss.NewStyle().Foreground(latencyColor).Bold(true).Render(fmt.Sprintf("%dms", latestCmdLatency))),
 		lipgloss.NewStyle().Foreground(blue).Render(sparkline(m.commandLatencyMs, 22)),
 	}, green, 34)
@@ -463,5 +1634,43 @@ func (m model) renderDashboard() string {
 		lipgloss.NewStyle().Foreground(blue).Render(sparkline(m.discoveryLatencyMs, 22)),
 	}, blue, 34)
 
-	return strings.Join([]string{core, brightnessBlock, commandBlock, discoveryBlock}, "\n")
+	blocks := []string{core, brightnessBlock, commandBlock, discoveryBlock}
+	if m.activeGroup != "" {
+		resultByAddr := map[string]error{}
+		for _, result := range m.lastResults {
+			resultByAddr[result.Target.IP+":"+result.Target.Port] = result.Err
+		}
+		var lines []string
+		for _, target := range m.commandTargets() {
+			marker := lipgloss.NewStyle().Foreground(subtext).Render("·")
+			if err, ok := resultByAddr[target.IP+":"+target.Port]; ok {
+				if err != nil {
+					marker = lipgloss.NewStyle().Foreground(red).Render("✗")
+				} else {
+					marker = lipgloss.NewStyle().Foreground(green).Render("✓")
+				}
+			}
+			lines = append(lines, fmt.Sprintf("%s %s", marker, clipText(target.String(), 26)))
+		}
+		if len(lines) == 0 {
+			lines = []string{"No saved members"}
+		}
+		blocks = append(blocks[:1], append([]string{metricBlock("Group Members", lines, mauve, 34)}, blocks[1:]...)...)
+	}
+	if m.capabilities.PowerMetering {
+		peak := 0
+		for _, sample := range m.powerHistory {
+			if sample > peak {
+				peak = sample
+			}
+		}
+		powerBlock := metricBlock("Power Meter", []string{
+			fmt.Sprintf("Draw     %s", lipgloss.NewStyle().Foreground(green).Bold(true).Render(fmt.Sprintf("%.1fW", m.powerWatts))),
+			fmt.Sprintf("Peak     %.1fW", float64(peak)/10),
+			lipgloss.NewStyle().Foreground(blue).Render(sparkline(m.powerHistory, 22)),
+		}, green, 34)
+		blocks = append(blocks[:1], append([]string{powerBlock}, blocks[1:]...)...)
+	}
+
+	return strings.Join(blocks, "\n")
 }
@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"wiz-tui/internal/actions"
 	"wiz-tui/internal/config"
 	"wiz-tui/internal/wiz"
 
@@ -18,7 +21,17 @@ import (
 func (m model) Init() tea.Cmd {
 	cmds := []tea.Cmd{textinput.Blink, m.spinner.Tick}
 	if m.state != setupView && m.ip != "" && m.port != "" {
-		cmds = append(cmds, syncDeviceStateCmd(m.ip, m.port))
+		cmds = append(cmds, syncDeviceStateCmd(m.context(), m.ip, m.port), m.registerPush())
+		// A group keeps every control enabled; one member's model must not narrow it.
+		if m.activeGroup == "" {
+			cmds = append(cmds, detectCapabilitiesCmd(m.context(), m.ip, m.port))
+		}
+	}
+	if m.push != nil {
+		cmds = append(cmds, waitForPushCmd(m.context(), m.push))
+	}
+	if m.state != setupView {
+		cmds = append(cmds, loadTimersCmd())
 	}
 	return tea.Batch(cmds...)
 }
@@ -38,28 +51,99 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case spinner.TickMsg:
-		if m.timerActive || m.discovering || m.syncingState {
+		if m.busy() {
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
 	case timerFinishedMsg:
 		m.timerActive = false
-		m.isOn = false
-		if m.detachedTimer {
-			m.status = "Timer finished (handled in background)"
+		m.localTimerCancel = nil
+		return m, m.fireTimerAction()
+	case timersTickMsg:
+		m.timersTicking = false
+		return m, loadTimersCmd()
+	case timersLoadedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Timers unavailable: %v", msg.err)
+			return m, nil
+		}
+		remaining := map[string]bool{}
+		for _, timer := range msg.timers {
+			remaining[timer.ID] = true
+		}
+		for _, timer := range m.timers {
+			if !remaining[timer.ID] {
+				// The worker finished and removed itself; refresh what the bulb is doing now.
+				m.status = fmt.Sprintf("Timer finished: %s %s", timer.Action, timer.Target())
+				cmds = append(cmds, m.startStateSync())
+			}
+		}
+		m.timers = msg.timers
+		if m.timerListCursor >= m.timerRows() && m.timerListCursor > 0 {
+			m.timerListCursor = m.timerRows() - 1
+		}
+		if len(m.timers) > 0 || m.state == timersView {
+			cmds = append(cmds, m.scheduleTimersRefresh(), m.spinner.Tick)
+		}
+		return m, tea.Batch(cmds...)
+	case timerCancelledMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Cancel failed: %v", msg.err)
 		} else {
-			start := time.Now()
-			err := wiz.SendCommand(m.ip, m.port, "setState", map[string]interface{}{"state": false})
-			m.recordCommand(time.Since(start), err)
-			if err != nil {
-				m.status = fmt.Sprintf("Timer finished. Power off failed: %v", err)
-			} else {
-				m.status = "Timer finished. Power off."
+			kept := m.timers[:0]
+			for _, timer := range m.timers {
+				if timer.ID != msg.timer.ID {
+					kept = append(kept, timer)
+				}
 			}
+			m.timers = kept
+			m.status = fmt.Sprintf("Cancelled timer %s (%s %s)", msg.timer.ID, msg.timer.Action, msg.timer.Target())
 		}
-		return m, nil
+		return m, loadTimersCmd()
+	case commandResultMsg:
+		m.inFlight--
+		var refresh tea.Cmd
+		if m.inFlight == 0 && m.pushDeferred {
+			m.pushDeferred = false
+			refresh = pushRefreshCmd(m.context(), m.ip, m.port, m.currentTargetMAC())
+		}
+		err := commandOutcome(msg.results)
+		if errors.Is(err, context.Canceled) {
+			return m, refresh
+		}
+		for _, result := range msg.results {
+			m.recordCommand(result.Latency, result.Err)
+		}
+		m.lastResults = msg.results
+		if err != nil {
+			if msg.seq == m.commandSeq {
+				m.restoreLightState(msg.undo)
+			}
+			m.status = commandErrorStatus(msg.action, err)
+		} else if msg.seq == m.commandSeq {
+			m.status = m.withGroupSummary(msg.success)
+		}
+		return m, refresh
+	case savedDeviceResolvedMsg:
+		if errors.Is(msg.err, context.Canceled) || !strings.EqualFold(msg.device.Mac, m.resolvingMAC) {
+			return m, nil
+		}
+		m.resolvingMAC = ""
+		switch {
+		case msg.found:
+			m.status = fmt.Sprintf("Selected saved device: %s", msg.device.Name)
+		case msg.err != nil:
+			m.status = fmt.Sprintf("Selected saved device: %s (lookup failed, using %s)", msg.device.Name, msg.device.IP)
+		default:
+			m.status = fmt.Sprintf("Selected saved device: %s (not found, using %s)", msg.device.Name, msg.device.IP)
+		}
+		return m, m.selectSavedDevice(msg.device)
 	case discoveryResultMsg:
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
 		m.discovering = false
+		m.discoveryCancel = nil
 		m.discoveryRuns++
 		m.lastDiscoveryMs = int(msg.elapsed.Milliseconds())
 		m.discoveryLatencyMs = appendBounded(m.discoveryLatencyMs, m.lastDiscoveryMs, 30)
@@ -80,25 +164,60 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.status = fmt.Sprintf("Discovery complete: %d bulb(s)", len(m.discoveredDevices))
 		}
 	case stateSyncResultMsg:
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
 		m.syncingState = false
+		m.syncCancel = nil
 		m.commandLatencyMs = appendBounded(m.commandLatencyMs, int(msg.elapsed.Milliseconds()), 30)
 		if msg.err != nil {
-			m.status = fmt.Sprintf("State sync failed: %v", msg.err)
+			m.status = commandErrorStatus("State sync", msg.err)
 			return m, nil
 		}
-		m.isOn = msg.state.Power
-		if msg.state.Brightness > 0 {
-			m.brightness = msg.state.Brightness
-			m.brightnessHistory = appendBounded(m.brightnessHistory, m.brightness, 30)
+		m.applyPilotState(msg.state)
+		m.status = "State synced"
+		return m, nil
+	case pushMsg:
+		// The device reported a change on its own, e.g. from the phone app
+		// or a wall switch; pushes from other devices are ignored.
+		if !m.isPushFromTarget(msg.Push) {
+			return m, waitForPushCmd(m.context(), m.push)
 		}
-		if strings.TrimSpace(msg.state.ColorHex) != "" {
-			m.currentColor = msg.state.ColorHex
+		if m.inFlight > 0 {
+			// This may echo an older command and undo newer optimistic
+			// values, so read the device once commands settle instead.
+			m.pushDeferred = true
+			return m, waitForPushCmd(m.context(), m.push)
+		}
+		m.applyPilotState(msg.State)
+		return m, waitForPushCmd(m.context(), m.push)
+	case capabilitiesResultMsg:
+		if errors.Is(msg.err, context.Canceled) || msg.ip != m.ip || m.activeGroup != "" {
+			return m, nil
+		}
+		if msg.err == nil {
+			m.setCapabilities(msg.caps)
+			return m, m.startPowerPolling()
 		}
-		m.status = "State synced"
 		return m, nil
+	case powerReadingMsg:
+		if msg.generation != m.powerPollGen || msg.ip != m.ip || errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		var deviceErr *wiz.DeviceError
+		if errors.As(msg.err, &deviceErr) {
+			// The plug has no meter; stop asking.
+			m.capabilities.PowerMetering = false
+			return m, nil
+		}
+		if msg.err == nil {
+			m.powerWatts = msg.watts
+			m.powerHistory = appendBounded(m.powerHistory, int(msg.watts*10), 30)
+		}
+		return m, powerPollCmd(m.context(), m.ip, m.port, m.powerPollGen, powerPollInterval)
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
-			return m, tea.Quit
+			return m, m.quit()
 		}
 
 		if m.state == setupView {
@@ -126,7 +245,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.status = "Config saved"
 				}
 			case "esc":
-				return m, tea.Quit
+				return m, m.quit()
 			default:
 				m.textInput, cmd = m.textInput.Update(msg)
 				cmds = append(cmds, cmd)
@@ -138,7 +257,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case menuView:
 			switch msg.String() {
 			case "q":
-				return m, tea.Quit
+				return m, m.quit()
 			case "up", "k":
 				if m.cursor > 0 {
 					m.cursor--
@@ -147,21 +266,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.cursor < len(m.choices)-1 {
 					m.cursor++
 				}
+			case "p":
+				m.state = presetNameView
+				m.textInput.CharLimit = 32
+				m.textInput.Placeholder = "Preset name"
+				m.textInput.SetValue("")
+				m.textInput.Focus()
 			case "enter", " ":
+				if !m.menuSupported(m.cursor) {
+					m.status = fmt.Sprintf("%s not supported on %s", m.choices[m.cursor], m.capabilities.Kind)
+					break
+				}
 				switch m.cursor {
 				case 0:
+					undo := m.lightState()
 					m.isOn = !m.isOn
-					start := time.Now()
-					err := wiz.SendCommand(m.ip, m.port, "setState", map[string]interface{}{"state": m.isOn})
-					m.recordCommand(time.Since(start), err)
-					if err != nil {
-						m.status = fmt.Sprintf("Power toggle failed: %v", err)
-						m.isOn = !m.isOn
-					} else if m.isOn {
-						m.status = "Power: ON"
-					} else {
-						m.status = "Power: OFF"
+					success := "Power: OFF"
+					if m.isOn {
+						success = "Power: ON"
 					}
+					to := wiz.PilotState{Power: m.isOn}
+					cmds = append(cmds, m.transitionCommand("Power toggle", success, to, "setState", map[string]interface{}{"state": m.isOn}, undo))
 				case 1:
 					m.state = colorPickerView
 				case 2:
@@ -173,22 +298,40 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case 3:
 					m.state = brightnessView
 				case 4:
+					m.state = temperatureView
+				case 5:
+					m.state = sceneView
+				case 6:
 					m.state = timerInputView
-					m.textInput.CharLimit = 5
-					m.textInput.Placeholder = "Mins (e.g. 15)"
+					m.textInput.CharLimit = 64
+					m.textInput.Placeholder = "Mins [action] (e.g. 15 or 30 scene=Ocean)"
 					m.textInput.SetValue("")
 					m.textInput.Focus()
-				case 5:
+				case 7:
 					m.state = discoveryView
-					m.discovering = true
 					m.status = "Scanning local network..."
-					cmds = append(cmds, discoverDevicesCmd(), m.spinner.Tick)
-				case 6:
+					cmds = append(cmds, m.startDiscovery(), m.spinner.Tick)
+				case 8:
 					m.state = savedDevicesView
-				case 7:
+				case 9:
+					m.state = groupsView
+					if idx := m.groupIndex(m.activeGroup); idx >= 0 {
+						m.groupCursor = idx
+					}
+				case 10:
+					m.state = transitionView
+				case 11:
+					m.state = timersView
+					cmds = append(cmds, loadTimersCmd())
+				case 12:
+					m.state = schedulesView
+				case 13:
+					m.state = alarmView
+					m.alarmField = 0
+				case 14:
 					m.state = helpView
-				case 8:
-					return m, tea.Quit
+				case 15:
+					return m, m.quit()
 				}
 			}
 		case colorPickerView:
@@ -212,18 +355,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.colorCursor++
 				}
 			case "enter":
-				selectedHex := colorPalette[m.colorCursor].hex
-				r, g, b, _ := wiz.HexToRGB(selectedHex)
-				start := time.Now()
-				err := wiz.SendCommand(m.ip, m.port, "setPilot", map[string]interface{}{"r": r, "g": g, "b": b, "dimming": m.brightness})
-				m.recordCommand(time.Since(start), err)
-				if err != nil {
-					m.status = fmt.Sprintf("Color change failed: %v", err)
+				selected := colorPalette[m.colorCursor]
+				params := map[string]interface{}{"temp": selected.kelvin, "dimming": m.brightness}
+				if selected.kelvin == 0 {
+					r, g, b, _ := wiz.HexToRGB(selected.hex)
+					params = map[string]interface{}{"r": r, "g": g, "b": b, "dimming": m.brightness}
+				}
+				undo := m.lightState()
+				if selected.kelvin > 0 {
+					m.colorTemp = selected.kelvin
+					m.whiteMode = true
 				} else {
-					m.currentColor = selectedHex
-					m.isOn = true
-					m.status = "Color: " + colorPalette[m.colorCursor].name
+					m.currentColor = selected.hex
+					m.whiteMode = false
+				}
+				m.activeScene = 0
+				m.isOn = true
+				to := wiz.PilotState{Power: true, Brightness: m.brightness, ColorHex: selected.hex}
+				if selected.kelvin > 0 {
+					to = wiz.PilotState{Power: true, Brightness: m.brightness, Temp: selected.kelvin}
 				}
+				cmds = append(cmds, m.transitionCommand("Color change", "Color: "+selected.name, to, "setPilot", params, undo))
 				m.state = menuView
 			}
 		case hexInputView:
@@ -236,16 +388,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if err != nil {
 					m.status = "Err: Invalid Hex"
 				} else {
-					start := time.Now()
-					cmdErr := wiz.SendCommand(m.ip, m.port, "setPilot", map[string]interface{}{"r": r, "g": g, "b": b, "dimming": m.brightness})
-					m.recordCommand(time.Since(start), cmdErr)
-					if cmdErr != nil {
-						m.status = fmt.Sprintf("Color change failed: %v", cmdErr)
-					} else {
-						m.currentColor = val
-						m.isOn = true
-						m.status = fmt.Sprintf("Color: %s", val)
-					}
+					undo := m.lightState()
+					m.currentColor = val
+					m.whiteMode = false
+					m.activeScene = 0
+					m.isOn = true
+					to := wiz.PilotState{Power: true, Brightness: m.brightness, ColorHex: fmt.Sprintf("#%02X%02X%02X", r, g, b)}
+					cmds = append(cmds, m.transitionCommand("Color change", fmt.Sprintf("Color: %s", val), to, "setPilot", map[string]interface{}{"r": r, "g": g, "b": b, "dimming": m.brightness}, undo))
 				}
 				m.state = menuView
 			default:
@@ -257,56 +406,150 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "esc", "q", "enter":
 				m.state = menuView
 			case "left", "h":
-				if m.brightness > 10 {
-					m.brightness -= 10
-					start := time.Now()
-					err := wiz.SendCommand(m.ip, m.port, "setPilot", map[string]interface{}{"dimming": m.brightness})
-					m.recordCommand(time.Since(start), err)
-					if err != nil {
-						m.status = fmt.Sprintf("Brightness change failed: %v", err)
-						m.brightness += 10
-					} else {
-						m.status = fmt.Sprintf("Bright: %d%%", m.brightness)
-						m.brightnessHistory = appendBounded(m.brightnessHistory, m.brightness, 30)
-					}
+				cmds = append(cmds, m.adjustBrightness(-brightnessStep))
+			case "right", "l":
+				cmds = append(cmds, m.adjustBrightness(brightnessStep))
+			case "shift+left", "H":
+				cmds = append(cmds, m.adjustBrightness(-fineBrightnessStep))
+			case "shift+right", "L":
+				cmds = append(cmds, m.adjustBrightness(fineBrightnessStep))
+			}
+		case temperatureView:
+			switch msg.String() {
+			case "esc", "q", "enter":
+				m.state = menuView
+			case "left", "h":
+				if m.colorTemp > m.capabilities.ClampKelvin(wiz.MinKelvin) {
+					undo := m.lightState()
+					m.colorTemp = m.capabilities.ClampKelvin(m.colorTemp - kelvinStep)
+					m.whiteMode = true
+					m.activeScene = 0
+					m.isOn = true
+					cmds = append(cmds, m.streamCommand("Temperature change", fmt.Sprintf("White: %dK", m.colorTemp), "setPilot", map[string]interface{}{"temp": m.colorTemp, "dimming": m.brightness}, undo))
 				}
 			case "right", "l":
-				if m.brightness < 100 {
-					m.brightness += 10
-					start := time.Now()
-					err := wiz.SendCommand(m.ip, m.port, "setPilot", map[string]interface{}{"dimming": m.brightness})
-					m.recordCommand(time.Since(start), err)
-					if err != nil {
-						m.status = fmt.Sprintf("Brightness change failed: %v", err)
-						m.brightness -= 10
-					} else {
-						m.status = fmt.Sprintf("Bright: %d%%", m.brightness)
-						m.brightnessHistory = appendBounded(m.brightnessHistory, m.brightness, 30)
-					}
+				if m.colorTemp < m.capabilities.ClampKelvin(wiz.MaxKelvin) {
+					undo := m.lightState()
+					m.colorTemp = m.capabilities.ClampKelvin(m.colorTemp + kelvinStep)
+					m.whiteMode = true
+					m.activeScene = 0
+					m.isOn = true
+					cmds = append(cmds, m.streamCommand("Temperature change", fmt.Sprintf("White: %dK", m.colorTemp), "setPilot", map[string]interface{}{"temp": m.colorTemp, "dimming": m.brightness}, undo))
+				}
+			}
+		case sceneView:
+			switch msg.String() {
+			case "esc", "q":
+				m.state = menuView
+			case "up", "k":
+				if m.sceneCursor > 0 {
+					m.sceneCursor--
+				}
+			case "down", "j":
+				if m.sceneCursor < len(m.sceneOptions())-1 {
+					m.sceneCursor++
 				}
+			case "left", "h":
+				m.sceneSpeed = wiz.ClampSceneSpeed(m.sceneSpeed - sceneSpeedStep)
+			case "right", "l":
+				m.sceneSpeed = wiz.ClampSceneSpeed(m.sceneSpeed + sceneSpeedStep)
+			case "enter":
+				scenes := m.sceneOptions()
+				if len(scenes) == 0 {
+					break
+				}
+				if m.sceneCursor >= len(scenes) {
+					m.sceneCursor = len(scenes) - 1
+				}
+				selected := scenes[m.sceneCursor]
+				params := wiz.SceneParams(selected, m.sceneSpeed)
+				params["dimming"] = m.brightness
+				undo := m.lightState()
+				m.activeScene = selected.ID
+				m.isOn = true
+				cmds = append(cmds, m.dispatchCommand("Scene change", "Scene: "+selected.Name, "setPilot", params, undo))
 			}
 		case timerInputView:
 			switch msg.String() {
 			case "esc", "q":
 				m.state = menuView
 			case "enter":
-				val := m.textInput.Value()
-				mins, err := strconv.Atoi(val)
-				if err == nil && mins > 0 {
-					m.timerActive = true
-					m.detachedTimer = false
-					m.status = fmt.Sprintf("Sleep in %dm", mins)
-					cmds = append(cmds, startTimer(time.Duration(mins)*time.Minute), m.spinner.Tick)
-					if spawnErr := startDetachedTimer(mins, m.ip, m.port); spawnErr == nil {
-						m.detachedTimer = true
-						m.status = fmt.Sprintf("Sleep in %dm (background armed)", mins)
-					} else {
-						m.status = fmt.Sprintf("Sleep in %dm (local only): %v", mins, spawnErr)
-					}
-				} else {
+				fields := strings.Fields(m.textInput.Value())
+				if len(fields) == 0 {
 					m.status = "Invalid timer value"
+					m.state = menuView
+					break
+				}
+				mins, err := strconv.Atoi(fields[0])
+				if err != nil || mins <= 0 {
+					m.status = "Invalid timer value"
+					m.state = menuView
+					break
+				}
+				action := actions.Action{Kind: actions.Off}
+				if len(fields) > 1 {
+					action, err = actions.Parse(strings.Join(fields[1:], " "))
+					if err == nil {
+						_, err = action.Resolve(m.presets)
+					}
+					if err != nil {
+						m.status = fmt.Sprintf("Invalid timer action: %v", err)
+						m.state = menuView
+						break
+					}
+				}
+				m.timerMinutes = mins
+				m.timerAction = action
+				m.textInput.CharLimit = 5
+				m.textInput.Placeholder = "Fade mins (0 = instant)"
+				m.textInput.SetValue("")
+				m.textInput.Focus()
+				m.state = timerFadeView
+			default:
+				m.textInput, cmd = m.textInput.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		case timerFadeView:
+			switch msg.String() {
+			case "esc":
+				m.state = menuView
+			case "enter":
+				val := strings.TrimSpace(m.textInput.Value())
+				fadeMins := 0
+				if val != "" {
+					parsed, err := strconv.Atoi(val)
+					if err != nil || parsed < 0 {
+						m.status = "Invalid fade value"
+						m.state = menuView
+						break
+					}
+					fadeMins = parsed
+				}
+				if fadeMins > m.timerMinutes {
+					fadeMins = m.timerMinutes
+				}
+				mins := m.timerMinutes
+				m.timerFade = time.Duration(fadeMins) * time.Minute
+				label := fmt.Sprintf("%s in %dm", timerActionTitle(m.timerAction), mins)
+				if m.timerFade > 0 {
+					label += fmt.Sprintf(", fading over last %dm", fadeMins)
 				}
 				m.state = menuView
+				spawnErr := startDetachedTimer(mins, m.timerFade, m.timerAction, m.currentTargetSavedName(), m.activeGroup, m.ip, m.port)
+				if spawnErr == nil {
+					// The worker registers itself; pick it up on the next refresh.
+					m.status = label + " (background armed)"
+					cmds = append(cmds, m.scheduleTimersRefresh())
+					break
+				}
+				// Without a worker, run the timer in-process; it fires when the fade should begin.
+				m.cancelLocalTimer()
+				ctx, cancel := context.WithCancel(m.context())
+				m.localTimerCancel = cancel
+				m.localTimerDeadline = time.Now().Add(time.Duration(mins) * time.Minute)
+				m.timerActive = true
+				m.status = fmt.Sprintf("%s (local only): %v", label, spawnErr)
+				cmds = append(cmds, startTimer(ctx, time.Duration(mins)*time.Minute-m.timerLead()), m.spinner.Tick)
 			default:
 				m.textInput, cmd = m.textInput.Update(msg)
 				cmds = append(cmds, cmd)
@@ -314,12 +557,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case discoveryView:
 			switch msg.String() {
 			case "esc", "q":
+				if m.discovering {
+					m.status = "Discovery cancelled"
+				}
+				m.stopDiscovery()
 				m.state = menuView
 			case "r":
 				if !m.discovering {
-					m.discovering = true
 					m.status = "Rescanning local network..."
-					cmds = append(cmds, discoverDevicesCmd(), m.spinner.Tick)
+					cmds = append(cmds, m.startDiscovery(), m.spinner.Tick)
 				}
 			case "up", "k":
 				if m.deviceCursor > 0 {
@@ -333,11 +579,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if len(m.discoveredDevices) > 0 {
 					selectedDevice := m.discoveredDevices[m.deviceCursor]
 					m.ip = selectedDevice.IP
+					if selectedDevice.Port != "" {
+						m.port = selectedDevice.Port
+					}
+					m.setCapabilities(selectedDevice.Capabilities)
+					m.activeGroup = ""
+					m.lastResults = nil
 					m.persistConfig()
+					m.stopDiscovery()
 					m.status = fmt.Sprintf("Selected: %s (%s)", selectedDevice.Name, selectedDevice.IP)
 					m.state = menuView
-					m.syncingState = true
-					cmds = append(cmds, syncDeviceStateCmd(m.ip, m.port), m.spinner.Tick)
+					cmds = append(cmds, m.startStateSync(), m.startPowerPolling(), m.spinner.Tick)
 				}
 			case "s":
 				if len(m.discoveredDevices) > 0 {
@@ -364,33 +616,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				if len(m.savedDevices) > 0 {
 					selected := m.savedDevices[m.savedDeviceCursor]
-					if strings.TrimSpace(selected.Mac) != "" {
-						resolvedIP := ""
-						discovered, err := wiz.DiscoverDevices()
-						if err == nil {
-							selectedMAC := strings.ToLower(strings.TrimSpace(selected.Mac))
-							for _, device := range discovered {
-								if strings.ToLower(strings.TrimSpace(device.Mac)) == selectedMAC {
-									resolvedIP = device.IP
-									break
-								}
-							}
-						}
-						if resolvedIP != "" {
-							m.savedDevices[m.savedDeviceCursor].IP = resolvedIP
-							selected.IP = resolvedIP
-						}
-					}
-
-					m.ip = selected.IP
-					if selected.Port != "" {
-						m.port = selected.Port
-					}
-					m.persistConfig()
-					m.status = fmt.Sprintf("Selected saved device: %s", selected.Name)
 					m.state = menuView
-					m.syncingState = true
-					cmds = append(cmds, syncDeviceStateCmd(m.ip, m.port), m.spinner.Tick)
+					if strings.TrimSpace(selected.Mac) == "" {
+						m.status = fmt.Sprintf("Selected saved device: %s", selected.Name)
+						cmds = append(cmds, m.selectSavedDevice(selected))
+						break
+					}
+					// The bulb may have a new DHCP lease; look it up by MAC without blocking input.
+					m.resolvingMAC = selected.Mac
+					m.status = fmt.Sprintf("Locating %s...", selected.Name)
+					cmds = append(cmds, resolveSavedDeviceCmd(m.context(), selected), m.spinner.Tick)
 				}
 			case "d":
 				if len(m.savedDevices) > 0 {
@@ -421,15 +656,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					name = "WiZ Device"
 				}
 
+				port := m.pendingSaveDevice.Port
+				if port == "" {
+					port = m.port
+				}
 				saved := config.SavedDevice{
 					Name: name,
 					IP:   m.pendingSaveDevice.IP,
-					Port: m.port,
+					Port: port,
 					Mac:  m.pendingSaveDevice.Mac,
 				}
 				m.upsertSavedDevice(saved)
 				m.applySavedNamesToDiscovered()
 				m.ip = saved.IP
+				m.port = saved.Port
 				m.persistConfig()
 				m.textInput.Blur()
 				m.status = fmt.Sprintf("Saved device: %s", saved.Name)
@@ -438,6 +678,249 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.textInput, cmd = m.textInput.Update(msg)
 				cmds = append(cmds, cmd)
 			}
+		case groupsView:
+			switch msg.String() {
+			case "esc", "q":
+				m.state = menuView
+			case "up", "k":
+				if m.groupCursor > 0 {
+					m.groupCursor--
+				}
+			case "down", "j":
+				if m.groupCursor < len(m.groups)-1 {
+					m.groupCursor++
+				}
+			case "enter":
+				if len(m.groups) > 0 {
+					group := m.groups[m.groupCursor]
+					cmds = append(cmds, m.setActiveGroup(group.Name))
+					m.status = fmt.Sprintf("Active group: %s (%d devices)", group.Name, len(m.commandTargets()))
+					m.state = menuView
+				}
+			case "u":
+				if m.activeGroup != "" {
+					cmds = append(cmds, m.setActiveGroup(""), m.spinner.Tick)
+					m.status = "Group cleared, controlling single device"
+				}
+			case "n":
+				m.editingGroup = config.Group{}
+				m.textInput.CharLimit = 24
+				m.textInput.Placeholder = "Group name"
+				m.textInput.SetValue("")
+				m.textInput.Focus()
+				m.state = groupNameView
+			case "e":
+				if len(m.groups) > 0 {
+					m.editingGroup = m.groups[m.groupCursor]
+					m.editingGroup.Members = append([]string(nil), m.editingGroup.Members...)
+					m.groupMemberCursor = 0
+					m.state = groupMembersView
+				}
+			case "d":
+				if len(m.groups) > 0 {
+					name := m.groups[m.groupCursor].Name
+					m.groups = append(m.groups[:m.groupCursor], m.groups[m.groupCursor+1:]...)
+					if m.groupCursor >= len(m.groups) && m.groupCursor > 0 {
+						m.groupCursor--
+					}
+					if strings.EqualFold(m.activeGroup, name) {
+						cmds = append(cmds, m.setActiveGroup(""))
+					} else {
+						m.persistConfig()
+					}
+					m.status = fmt.Sprintf("Removed group: %s", name)
+				}
+			}
+		case groupNameView:
+			switch msg.String() {
+			case "esc":
+				m.textInput.Blur()
+				m.state = groupsView
+			case "enter":
+				name := strings.TrimSpace(m.textInput.Value())
+				if name == "" {
+					m.status = "Group name cannot be empty"
+					break
+				}
+				if m.groupIndex(name) >= 0 {
+					m.status = fmt.Sprintf("Group %s already exists", name)
+					break
+				}
+				m.textInput.Blur()
+				m.editingGroup = config.Group{Name: name}
+				m.groupMemberCursor = 0
+				m.state = groupMembersView
+			default:
+				m.textInput, cmd = m.textInput.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		case schedulesView:
+			switch msg.String() {
+			case "esc", "q":
+				m.state = menuView
+			case "up", "k":
+				if m.scheduleCursor > 0 {
+					m.scheduleCursor--
+				}
+			case "down", "j":
+				if m.scheduleCursor < len(m.schedules)-1 {
+					m.scheduleCursor++
+				}
+			case "n":
+				m.editSchedule(-1)
+			case "enter", "e":
+				if len(m.schedules) > 0 {
+					m.editSchedule(m.scheduleCursor)
+				}
+			case " ":
+				if len(m.schedules) > 0 {
+					rule := &m.schedules[m.scheduleCursor]
+					rule.Disabled = !rule.Disabled
+					m.persistConfig()
+					state := "enabled"
+					if rule.Disabled {
+						state = "disabled"
+					}
+					m.status = fmt.Sprintf("Schedule %s %s", rule.Name, state)
+				}
+			case "d":
+				if len(m.schedules) > 0 {
+					name := m.schedules[m.scheduleCursor].Name
+					m.schedules = append(m.schedules[:m.scheduleCursor], m.schedules[m.scheduleCursor+1:]...)
+					if m.scheduleCursor >= len(m.schedules) && m.scheduleCursor > 0 {
+						m.scheduleCursor--
+					}
+					m.persistConfig()
+					m.status = fmt.Sprintf("Removed schedule: %s", name)
+				}
+			}
+		case scheduleEditView:
+			switch msg.String() {
+			case "esc":
+				m.textInput.Blur()
+				m.state = schedulesView
+			case "tab", "down":
+				m.moveScheduleField(1)
+			case "shift+tab", "up":
+				m.moveScheduleField(-1)
+			case "enter":
+				m.saveScheduleForm()
+			default:
+				m.textInput, cmd = m.textInput.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		case alarmView:
+			switch msg.String() {
+			case "esc", "q":
+				m.state = menuView
+			case "up", "k", "down", "j", "tab":
+				m.alarmField = 1 - m.alarmField
+			case "left", "h":
+				m.adjustAlarm(-1)
+			case "right", "l":
+				m.adjustAlarm(1)
+			case "H":
+				m.adjustAlarm(-12)
+			case "L":
+				m.adjustAlarm(12)
+			case "enter":
+				m.state = menuView
+				at := m.alarmClock()
+				start := m.nextAlarm(time.Now()).Add(-m.alarmRamp)
+				if err := startDetachedAlarm(at, m.alarmRamp, m.currentTargetSavedName(), m.activeGroup, m.ip, m.port); err != nil {
+					m.status = fmt.Sprintf("Wake alarm not armed: %v", err)
+					break
+				}
+				m.status = fmt.Sprintf("Wake alarm %s armed · sunrise from %s", at, start.Format("15:04"))
+				cmds = append(cmds, m.scheduleTimersRefresh())
+			}
+		case presetNameView:
+			switch msg.String() {
+			case "esc":
+				m.textInput.Blur()
+				m.state = menuView
+			case "enter":
+				name := strings.TrimSpace(m.textInput.Value())
+				if name == "" {
+					m.status = "Preset name cannot be empty"
+					break
+				}
+				m.upsertPreset(m.currentPreset(name))
+				m.persistConfig()
+				m.textInput.Blur()
+				m.status = fmt.Sprintf("Saved preset: %s", name)
+				m.state = menuView
+			default:
+				m.textInput, cmd = m.textInput.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		case groupMembersView:
+			switch msg.String() {
+			case "esc", "q":
+				m.state = groupsView
+			case "up", "k":
+				if m.groupMemberCursor > 0 {
+					m.groupMemberCursor--
+				}
+			case "down", "j":
+				if m.groupMemberCursor < len(m.savedDevices)-1 {
+					m.groupMemberCursor++
+				}
+			case " ", "x":
+				if len(m.savedDevices) > 0 {
+					m.toggleGroupMember(m.savedDevices[m.groupMemberCursor].Mac)
+				}
+			case "enter":
+				if idx := m.groupIndex(m.editingGroup.Name); idx >= 0 {
+					m.groups[idx] = m.editingGroup
+					m.groupCursor = idx
+				} else {
+					m.groups = append(m.groups, m.editingGroup)
+					m.groupCursor = len(m.groups) - 1
+				}
+				m.persistConfig()
+				m.status = fmt.Sprintf("Saved group: %s (%d members)", m.editingGroup.Name, len(m.editingGroup.Members))
+				m.state = groupsView
+			}
+		case timersView:
+			switch msg.String() {
+			case "esc", "q":
+				m.state = menuView
+			case "up", "k":
+				if m.timerListCursor > 0 {
+					m.timerListCursor--
+				}
+			case "down", "j":
+				if m.timerListCursor < m.timerRows()-1 {
+					m.timerListCursor++
+				}
+			case "r":
+				cmds = append(cmds, loadTimersCmd())
+			case "c", "d", "x":
+				index := m.timerListCursor
+				if m.timerActive {
+					if index == 0 {
+						m.cancelLocalTimer()
+						m.status = "Cancelled in-app timer"
+						break
+					}
+					index--
+				}
+				if index < len(m.timers) {
+					m.status = fmt.Sprintf("Cancelling timer %s...", m.timers[index].ID)
+					cmds = append(cmds, cancelTimerCmd(m.timers[index].ID))
+				}
+			}
+		case transitionView:
+			switch msg.String() {
+			case "esc", "q", "enter":
+				m.persistConfig()
+				m.state = menuView
+			case "left", "h":
+				m.stepTransition(-1)
+			case "right", "l":
+				m.stepTransition(1)
+			}
 		case helpView:
 			switch msg.String() {
 			case "esc", "q", "enter":
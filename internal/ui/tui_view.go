@@ -3,12 +3,19 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/schedule"
 	"wiz-tui/internal/version"
+	"wiz-tui/internal/wiz"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
+// sceneListRows is how many scenes the scene browser shows at once.
+const sceneListRows = 12
+
 // View renders the complete application UI for the current model state.
 func (m model) View() string {
 	if m.state == setupView {
@@ -73,9 +80,16 @@ func (m model) View() string {
 	switch m.state {
 	case menuView:
 		leftPanel = sectionHeader("Control Board", "Main actions") + "\n\n"
+		disabledStyle := lipgloss.NewStyle().Foreground(surface).PaddingLeft(1)
 		for i, choice := range m.choices {
 			icon := m.icons[i]
-			if m.cursor == i {
+			if !m.menuSupported(i) {
+				prefix := "  "
+				if m.cursor == i {
+					prefix = "> "
+				}
+				leftPanel += disabledStyle.Render(fmt.Sprintf("%s%-3s %s  n/a", prefix, icon, choice)) + "\n"
+			} else if m.cursor == i {
 				leftPanel += selectedStyle.Render(fmt.Sprintf("> %-3s %s", icon, choice)) + "\n"
 			} else {
 				leftPanel += itemStyle.Render(fmt.Sprintf("  %-3s %s", icon, choice)) + "\n"
@@ -111,24 +125,169 @@ func (m model) View() string {
 		leftPanel += lipgloss.NewStyle().Foreground(mauve).Render(bar(m.brightness, 100, 28)) + "\n"
 		leftPanel += lipgloss.NewStyle().Foreground(blue).Render(sparkline(m.brightnessHistory, 28)) + "\n"
 		leftPanel += fmt.Sprintf("Level  %d%%\n\n", m.brightness)
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Left/Right ±10% · Shift+Left/Right or H/L ±1% · Enter/Esc to return")
+	case temperatureView:
+		swatch := lipgloss.NewStyle().Background(lipgloss.Color(wiz.KelvinToHex(m.colorTemp))).Foreground(base).Padding(0, 3).Render("   ")
+		leftPanel = sectionHeader("Color Temp", "Tunable white") + "\n\n"
+		minKelvin := m.capabilities.ClampKelvin(wiz.MinKelvin)
+		maxKelvin := m.capabilities.ClampKelvin(wiz.MaxKelvin)
+		leftPanel += lipgloss.NewStyle().Foreground(mauve).Render(bar(m.colorTemp-minKelvin, maxKelvin-minKelvin, 28)) + "\n"
+		leftPanel += "Warm " + swatch + " Cool\n"
+		leftPanel += fmt.Sprintf("Kelvin %dK\n\n", m.colorTemp)
 		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Left/Right to adjust · Enter/Esc to return")
-	case timerInputView:
+	case sceneView:
+		leftPanel = sectionHeader("Scenes", "Built-in light modes") + "\n\n"
+		leftPanel += fmt.Sprintf("Speed  %s %d%%\n\n", lipgloss.NewStyle().Foreground(mauve).Render(bar(m.sceneSpeed-wiz.MinSceneSpeed, wiz.MaxSceneSpeed-wiz.MinSceneSpeed, 20)), m.sceneSpeed)
+		scenes := m.sceneOptions()
+		start, end := listWindow(m.sceneCursor, len(scenes), sceneListRows)
+		for i := start; i < end; i++ {
+			scene := scenes[i]
+			label := fmt.Sprintf("%2d %s", scene.ID, scene.Name)
+			if scene.Dynamic {
+				label += lipgloss.NewStyle().Foreground(subtext).Render("  ~")
+			}
+			if scene.ID == m.activeScene {
+				label += lipgloss.NewStyle().Foreground(green).Render("  active")
+			}
+			if i == m.sceneCursor {
+				leftPanel += selectedStyle.Render("> "+label) + "\n"
+			} else {
+				leftPanel += itemStyle.Render("  "+label) + "\n"
+			}
+		}
+		leftPanel += "\n" + lipgloss.NewStyle().Foreground(subtext).Render("Enter apply · Left/Right speed · ~ animated · Esc back")
+	case timerInputView, timerFadeView:
 		leftPanel = sectionHeader("Sleep Timer", "Minutes") + "\n\n"
-		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Set minutes until automatic power off") + "\n\n"
+		if m.state == timerFadeView {
+			prompt := fmt.Sprintf("Off in %dm. Dim gradually over the last how many minutes?", m.timerMinutes)
+			if m.timerAction.Kind != actions.Off {
+				prompt = fmt.Sprintf("%s in %dm. Fade over the last how many minutes?", timerActionTitle(m.timerAction), m.timerMinutes)
+			}
+			leftPanel += lipgloss.NewStyle().Foreground(subtext).Render(prompt) + "\n\n"
+		} else {
+			leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Minutes until power off, optionally followed by an action:\ncolor=#FF8800 · white=2700 · scene=Ocean · brightness=30 · preset=Name · on") + "\n\n"
+		}
 		leftPanel += m.textInput.View() + "\n\n"
-		if m.timerActive {
-			leftPanel += lipgloss.NewStyle().Foreground(blue).Render("Timer running in background")
+		if m.timerActive || len(m.timers) > 0 {
+			leftPanel += lipgloss.NewStyle().Foreground(blue).Render("Timers running · see Timers to cancel")
+		}
+	case timersView:
+		leftPanel = sectionHeader("Timers", "Active countdowns") + "\n\n"
+		if m.timerRows() == 0 {
+			leftPanel += "No active timers.\nArm one from Sleep Timer."
+		} else {
+			now := time.Now()
+			row := 0
+			if m.timerActive {
+				style := lipgloss.NewStyle().Foreground(textCol)
+				if row == m.timerListCursor {
+					style = lipgloss.NewStyle().Foreground(mauve).Bold(true)
+				}
+				remaining := m.localTimerDeadline.Sub(now).Round(time.Second)
+				leftPanel += renderDeviceCard("In-app timer", fmt.Sprintf("off in %s", remaining), "stops if Lumina exits", "local", style, row == m.timerListCursor, cardWidth) + "\n"
+				row++
+			}
+			for _, timer := range m.timers {
+				style := lipgloss.NewStyle().Foreground(textCol)
+				if row == m.timerListCursor {
+					style = lipgloss.NewStyle().Foreground(mauve).Bold(true)
+				}
+				detail := "id " + timer.ID
+				if timer.FadeMs > 0 {
+					detail += fmt.Sprintf(" · fade %s", time.Duration(timer.FadeMs)*time.Millisecond)
+				}
+				endpoint := fmt.Sprintf("%s in %s", timer.Action, timer.Remaining(now).Round(time.Second))
+				leftPanel += renderDeviceCard(clipText(timer.Target(), 20), endpoint, detail, "armed", style, row == m.timerListCursor, cardWidth) + "\n"
+				row++
+			}
+		}
+		leftPanel += "\nc cancel · r refresh · Esc back"
+	case schedulesView:
+		leftPanel = sectionHeader("Schedules", "Daily routines") + "\n\n"
+		if len(m.schedules) == 0 {
+			leftPanel += "No schedules yet.\nPress 'n' to add one."
+		} else {
+			now := time.Now()
+			for i, rule := range m.schedules {
+				style := lipgloss.NewStyle().Foreground(textCol)
+				if i == m.scheduleCursor {
+					style = lipgloss.NewStyle().Foreground(mauve).Bold(true)
+				}
+				stateLabel := "on"
+				detail := schedule.DescribeTarget(rule)
+				if compiled, err := schedule.Compile(rule, m.location); err != nil {
+					stateLabel = "invalid"
+				} else if rule.Disabled {
+					stateLabel = "off"
+				} else {
+					detail += " · next " + compiled.Next(now).Format("Mon 15:04")
+				}
+				endpoint := clipText(schedule.Describe(rule)+" → "+rule.Action, cardWidth-8)
+				leftPanel += renderDeviceCard(clipText(rule.Name, 20), endpoint, clipText(detail, cardWidth-8), stateLabel, style, i == m.scheduleCursor, cardWidth) + "\n"
+			}
+		}
+		leftPanel += "\nn new · Enter edit · Space on/off · d delete · Esc back\n"
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Schedules run while 'lumina daemon' is running")
+	case scheduleEditView:
+		title := "New schedule"
+		if m.scheduleEditIndex >= 0 {
+			title = "Edit schedule"
+		}
+		leftPanel = sectionHeader("Schedules", title) + "\n\n"
+		for i, label := range scheduleFieldLabels {
+			value := m.scheduleFields[i]
+			if i == m.scheduleField {
+				leftPanel += selectedStyle.Render(fmt.Sprintf("> %-10s", label)) + m.textInput.View() + "\n"
+				continue
+			}
+			leftPanel += itemStyle.Render(fmt.Sprintf("  %-10s", label)) + value + "\n"
+		}
+		leftPanel += "\n" + lipgloss.NewStyle().Foreground(subtext).Render("When: 07:00 weekdays · sunset-30m sat,sun · 0 7 * * 1-5 (cron)\nTarget: group name, IP, or blank for the current device") + "\n\n"
+		leftPanel += "Tab/↑↓ field · Enter save · Esc cancel"
+	case alarmView:
+		leftPanel = sectionHeader("Wake Alarm", "Sunrise ramp") + "\n\n"
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Light rises from deep red through orange to cool white, ending at the alarm time") + "\n\n"
+		rows := []string{fmt.Sprintf("Alarm   %s", m.alarmClock()), fmt.Sprintf("Ramp    %d min", int(m.alarmRamp/time.Minute))}
+		for i, row := range rows {
+			if i == m.alarmField {
+				leftPanel += selectedStyle.Render("> "+row) + "\n"
+			} else {
+				leftPanel += itemStyle.Render("  "+row) + "\n"
+			}
 		}
+		next := m.nextAlarm(time.Now())
+		leftPanel += "\n" + fmt.Sprintf("Starts %s · ends %s", next.Add(-m.alarmRamp).Format("Mon 15:04"), next.Format("Mon 15:04")) + "\n"
+		if preview := sunrisePreview(m.capabilities, 32); preview != "" {
+			leftPanel += preview + "\n"
+		}
+		leftPanel += "\n↑↓ field · ←→ ±5 min · H/L ±1 h · Enter arm · Esc back\n"
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Runs in the background; cancel it from Timers")
+	case transitionView:
+		leftPanel = sectionHeader("Transitions", "Fade duration") + "\n\n"
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Power and color changes fade over this time") + "\n\n"
+		for _, step := range transitionSteps {
+			label := transitionLabel(step)
+			if step == m.transition {
+				leftPanel += selectedStyle.Render("> "+label) + "\n"
+			} else {
+				leftPanel += itemStyle.Render("  "+label) + "\n"
+			}
+		}
+		leftPanel += "\n" + lipgloss.NewStyle().Foreground(subtext).Render("Left/Right to change · Enter/Esc to save")
 	case helpView:
 		leftPanel = sectionHeader("Help", "Key reference") + "\n\n" +
 			lipgloss.NewStyle().Foreground(textCol).Render("Navigation:\n") +
 			"↑↓/jk   Move cursor\n" +
 			"Enter    Select/Confirm\n" +
 			"Esc      Cancel/Back\n" +
+			"Shift+←→ Fine brightness (1%)\n" +
 			"q/Ctrl+C Quit\n" +
 			"r        Refresh discovery\n" +
 			"s        Save discovered device\n" +
-			"d        Delete saved device\n\n" +
+			"d        Delete saved device\n" +
+			"p        Save state as preset\n" +
+			"Schedules n new · Space on/off\n" +
+			"Groups   n new · e edit · u ungroup\n\n" +
 			lipgloss.NewStyle().Foreground(textCol).Render("Discovery:\n") +
 			"Auto scan on open\n" +
 			"Dedupe by MAC/IP\n" +
@@ -157,10 +316,14 @@ func (m model) View() string {
 					mac = "-"
 				}
 				stateLabel := "unknown"
-				if device.IP == m.ip {
+				if m.isTarget(device.IP, device.Port) {
 					stateLabel = "active"
 				}
-				leftPanel += renderDeviceCard(name, device.IP, mac, stateLabel, style, i == m.deviceCursor, cardWidth) + "\n"
+				endpoint := device.IP
+				if device.Port != "" {
+					endpoint += ":" + device.Port
+				}
+				leftPanel += renderDeviceCard(name, endpoint, mac+" · "+device.Capabilities.Kind.String(), stateLabel, style, i == m.deviceCursor, cardWidth) + "\n"
 			}
 			leftPanel += "\nEnter select · s save name · r refresh"
 		}
@@ -192,14 +355,76 @@ func (m model) View() string {
 		leftPanel = sectionHeader("Save Device", "Enter display name") + "\n\n"
 		leftPanel += m.textInput.View() + "\n\n"
 		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Enter to save · Esc to cancel")
+	case groupsView:
+		leftPanel = sectionHeader("Groups", "Control several bulbs at once") + "\n\n"
+		if len(m.groups) == 0 {
+			leftPanel += "No groups yet.\nPress 'n' to create one from saved devices."
+		} else {
+			for i, group := range m.groups {
+				style := lipgloss.NewStyle().Foreground(textCol)
+				if i == m.groupCursor {
+					style = lipgloss.NewStyle().Foreground(mauve).Bold(true)
+				}
+				stateLabel := "idle"
+				if strings.EqualFold(group.Name, m.activeGroup) {
+					stateLabel = "active"
+				}
+				members := m.config().GroupMembers(group)
+				names := make([]string, 0, len(members))
+				for _, member := range members {
+					names = append(names, member.Name)
+				}
+				summary := fmt.Sprintf("%d of %d saved", len(members), len(group.Members))
+				leftPanel += renderDeviceCard(clipText(group.Name, 20), summary, clipText(strings.Join(names, ", "), cardWidth-8), stateLabel, style, i == m.groupCursor, cardWidth) + "\n"
+			}
+		}
+		leftPanel += "\nEnter activate · n new · e edit · d delete · u ungroup"
+	case groupNameView:
+		leftPanel = sectionHeader("New Group", "Enter group name") + "\n\n"
+		leftPanel += m.textInput.View() + "\n\n"
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Enter to pick members · Esc to cancel")
+	case presetNameView:
+		leftPanel = sectionHeader("Save Preset", "Enter preset name") + "\n\n"
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Saves the current state; replay it with preset=<name> in timers") + "\n\n"
+		leftPanel += m.textInput.View() + "\n\n"
+		leftPanel += lipgloss.NewStyle().Foreground(subtext).Render("Enter to save · Esc to cancel")
+	case groupMembersView:
+		leftPanel = sectionHeader("Group Members", m.editingGroup.Name) + "\n\n"
+		if len(m.savedDevices) == 0 {
+			leftPanel += "No saved devices yet.\nDiscover a bulb and press 's' to save."
+		} else {
+			for i, device := range m.savedDevices {
+				check := "[ ]"
+				if m.groupHasMember(device.Mac) {
+					check = "[x]"
+				}
+				label := fmt.Sprintf("%s %s  %s", check, clipText(device.Name, 20), device.IP)
+				if i == m.groupMemberCursor {
+					leftPanel += selectedStyle.Render("> "+label) + "\n"
+				} else {
+					leftPanel += itemStyle.Render("  "+label) + "\n"
+				}
+			}
+		}
+		leftPanel += "\n" + lipgloss.NewStyle().Foreground(subtext).Render("Space toggle · Enter save · Esc back")
 	}
 
 	rightPanel := m.renderDashboard()
 	actionBlock := renderStatusBlock(m.status, rightWidth-10)
 	rightPanel += "\n" + actionBlock
 
-	if m.timerActive {
-		rightPanel += fmt.Sprintf("\n%s %s", m.spinner.View(), lipgloss.NewStyle().Foreground(blue).Render("Timer Active"))
+	if m.timerActive || len(m.timers) > 0 {
+		label := "Timer Active"
+		if next, ok := m.nextTimerDeadline(); ok {
+			label = fmt.Sprintf("Timer Active · next in %s", time.Until(next).Round(time.Second))
+		}
+		rightPanel += fmt.Sprintf("\n%s %s", m.spinner.View(), lipgloss.NewStyle().Foreground(blue).Render(label))
+	}
+	if m.inFlight > 0 {
+		rightPanel += fmt.Sprintf("\n%s %s", m.spinner.View(), lipgloss.NewStyle().Foreground(blue).Render(fmt.Sprintf("Sending %d command(s)", m.inFlight)))
+	}
+	if m.resolvingMAC != "" {
+		rightPanel += fmt.Sprintf("\n%s %s", m.spinner.View(), lipgloss.NewStyle().Foreground(blue).Render("Locating saved device"))
 	}
 
 	leftBox := leftPanelStyle.Render(strings.TrimSpace(leftPanel))
@@ -226,7 +451,12 @@ func (m model) View() string {
 	healthBadge := lipgloss.NewStyle().Background(base).Foreground(green).Padding(0, 1).Render(fmt.Sprintf("OK %d%%", successRate))
 	versionBadge := lipgloss.NewStyle().Background(base).Foreground(subtext).Padding(0, 1).Render(version.Version)
 
-	statusBar := lipgloss.JoinHorizontal(lipgloss.Top, modeBadge, infoBadge, deviceBadge, healthBadge, versionBadge)
+	badges := []string{modeBadge, infoBadge, deviceBadge}
+	if m.activeGroup != "" {
+		badges = append(badges, lipgloss.NewStyle().Background(surface).Foreground(mauve).Padding(0, 1).Render("Group "+clipText(m.activeGroup, 12)))
+	}
+	badges = append(badges, healthBadge, versionBadge)
+	statusBar := lipgloss.JoinHorizontal(lipgloss.Top, badges...)
 	return "\n" + mainUI + "\n" + statusBar + "\n"
 }
 
@@ -237,6 +467,21 @@ func maxInt(a, b int) int {
 	return b
 }
 
+// listWindow returns the visible [start, end) range of a scrolling list around cursor.
+func listWindow(cursor, total, rows int) (int, int) {
+	if total <= rows {
+		return 0, total
+	}
+	start := cursor - rows/2
+	if start < 0 {
+		start = 0
+	}
+	if start+rows > total {
+		start = total - rows
+	}
+	return start, start + rows
+}
+
 func clipText(value string, limit int) string {
 	if limit <= 0 || len(value) <= limit {
 		return value
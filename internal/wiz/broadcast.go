@@ -0,0 +1,146 @@
+package wiz
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target addresses one device in a multi-device command.
+type Target struct {
+	Name string `json:"name,omitempty"`
+	IP   string `json:"ip"`
+	Port string `json:"port"`
+}
+
+// String returns the target name, falling back to its address.
+func (t Target) String() string {
+	if strings.TrimSpace(t.Name) != "" {
+		return t.Name
+	}
+	return net.JoinHostPort(t.IP, t.Port)
+}
+
+// Result reports the outcome of a command for one target.
+type Result struct {
+	Target  Target
+	Err     error
+	Latency time.Duration
+}
+
+// Outcome is the serializable form of a Result, optionally carrying the
+// action that was sent and the state read back from the device.
+type Outcome struct {
+	Target    Target      `json:"target"`
+	OK        bool        `json:"ok"`
+	Error     string      `json:"error,omitempty"`
+	LatencyMs int64       `json:"latencyMs"`
+	Action    string      `json:"action,omitempty"`
+	State     *PilotState `json:"state,omitempty"`
+}
+
+// Outcome converts the result for JSON output.
+func (r Result) Outcome() Outcome {
+	outcome := Outcome{Target: r.Target, OK: r.Err == nil, LatencyMs: r.Latency.Milliseconds()}
+	if r.Err != nil {
+		outcome.Error = r.Err.Error()
+	}
+	return outcome
+}
+
+// BroadcastError reports which targets of a multi-device command failed.
+type BroadcastError struct {
+	Total  int
+	Failed []Result
+}
+
+// Error summarizes the failing targets.
+func (e *BroadcastError) Error() string {
+	parts := make([]string, 0, len(e.Failed))
+	for _, result := range e.Failed {
+		parts = append(parts, fmt.Sprintf("%s: %v", result.Target, result.Err))
+	}
+	return fmt.Sprintf("%d of %d devices failed: %s", len(e.Failed), e.Total, strings.Join(parts, "; "))
+}
+
+// Partial reports whether at least one target succeeded.
+func (e *BroadcastError) Partial() bool {
+	return len(e.Failed) < e.Total
+}
+
+// Unwrap exposes the per-device errors to errors.Is and errors.As.
+func (e *BroadcastError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, result := range e.Failed {
+		errs = append(errs, result.Err)
+	}
+	return errs
+}
+
+// JoinResults returns nil when every result succeeded, otherwise a *BroadcastError.
+func JoinResults(results []Result) error {
+	var failed []Result
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BroadcastError{Total: len(results), Failed: failed}
+}
+
+// Broadcast sends an acknowledged command to every target concurrently and
+// returns one result per target in input order.
+func (c *Client) Broadcast(ctx context.Context, targets []Target, method string, params map[string]interface{}) []Result {
+	return fanOut(targets, func(_ int, target Target) error {
+		return c.SendCommandAckContext(ctx, target.IP, target.Port, method, params)
+	})
+}
+
+// BroadcastCoalesced is Broadcast through each target's SendCoalesced queue,
+// for streams of rapid updates such as brightness drags.
+func (c *Client) BroadcastCoalesced(ctx context.Context, targets []Target, method string, params map[string]interface{}) []Result {
+	return fanOut(targets, func(_ int, target Target) error {
+		return c.SendCoalesced(ctx, target.IP, target.Port, method, params)
+	})
+}
+
+// ForEach runs fn for every target concurrently and returns the results in
+// target order, for per-device work that is not a single command. fn receives
+// each target's position so it can store per-target output without locking,
+// even when two targets share an address.
+func ForEach(targets []Target, fn func(int, Target) error) []Result {
+	return fanOut(targets, fn)
+}
+
+// fanOut runs send for every target concurrently and collects timed results in input order.
+func fanOut(targets []Target, send func(int, Target) error) []Result {
+	results := make([]Result, len(targets))
+	var wg sync.WaitGroup
+	for index, target := range targets {
+		wg.Add(1)
+		go func(index int, target Target) {
+			defer wg.Done()
+			start := time.Now()
+			err := send(index, target)
+			results[index] = Result{Target: target, Err: err, Latency: time.Since(start)}
+		}(index, target)
+	}
+	wg.Wait()
+	return results
+}
+
+// Broadcast sends an acknowledged command to every target using the default client.
+func Broadcast(ctx context.Context, targets []Target, method string, params map[string]interface{}) []Result {
+	return defaultClient.Broadcast(ctx, targets, method, params)
+}
+
+// BroadcastCoalesced sends a rate-limited, merged command to every target using the default client.
+func BroadcastCoalesced(ctx context.Context, targets []Target, method string, params map[string]interface{}) []Result {
+	return defaultClient.BroadcastCoalesced(ctx, targets, method, params)
+}
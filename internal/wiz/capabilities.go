@@ -0,0 +1,213 @@
+package wiz
+
+import (
+	"context"
+	"strings"
+)
+
+// DeviceKind is the hardware class inferred from a WiZ module name.
+type DeviceKind int
+
+const (
+	KindUnknown DeviceKind = iota
+	KindRGB
+	KindTunableWhite
+	KindDimmable
+	KindPlug
+)
+
+// String returns a short human-readable label for the device kind.
+func (k DeviceKind) String() string {
+	switch k {
+	case KindRGB:
+		return "RGB bulb"
+	case KindTunableWhite:
+		return "Tunable white"
+	case KindDimmable:
+		return "Dimmable"
+	case KindPlug:
+		return "Smart plug"
+	default:
+		return "Unknown"
+	}
+}
+
+// kindNames are the stable identifiers used when a DeviceKind is serialized.
+var kindNames = map[DeviceKind]string{
+	KindUnknown:      "unknown",
+	KindRGB:          "rgb",
+	KindTunableWhite: "tunable-white",
+	KindDimmable:     "dimmable",
+	KindPlug:         "plug",
+}
+
+// MarshalText encodes the kind as a stable identifier such as "rgb" or "plug".
+func (k DeviceKind) MarshalText() ([]byte, error) {
+	name, ok := kindNames[k]
+	if !ok {
+		name = kindNames[KindUnknown]
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText decodes an identifier written by MarshalText; unrecognized
+// names decode as KindUnknown.
+func (k *DeviceKind) UnmarshalText(text []byte) error {
+	*k = KindUnknown
+	for kind, name := range kindNames {
+		if name == string(text) {
+			*k = kind
+		}
+	}
+	return nil
+}
+
+// Capabilities describes which controls a device supports.
+// Unknown devices report everything as supported so nothing is hidden by mistake.
+type Capabilities struct {
+	Kind          DeviceKind `json:"kind"`
+	Color         bool       `json:"color"`
+	ColorTemp     bool       `json:"colorTemp"`
+	MinKelvin     int        `json:"minKelvin,omitempty"`
+	MaxKelvin     int        `json:"maxKelvin,omitempty"`
+	Dimming       bool       `json:"dimming"`
+	Scenes        bool       `json:"scenes"`
+	PowerMetering bool       `json:"powerMetering"`
+}
+
+// Scene ids the firmware accepts on white-only bulbs.
+var (
+	tunableWhiteScenes = []int{6, 9, 10, 11, 12, 13, 14, 15, 16, 18, 29, 30, 31, 32}
+	dimmableScenes     = []int{9, 10, 13, 14, 29, 30, 31, 32}
+)
+
+// CapabilitiesFromModule infers capabilities from a moduleName such as
+// "ESP01_SHRGB1C_31", "ESP01_SHTW1C_31", "ESP01_SHDW1_31" or "ESP10_SOCKET_06".
+func CapabilitiesFromModule(moduleName string) Capabilities {
+	name := strings.ToUpper(strings.TrimSpace(moduleName))
+	switch {
+	case strings.Contains(name, "SOCKET"), strings.Contains(name, "PLUG"):
+		// Only some plugs have a meter; DetectCapabilities finds out by asking.
+		return Capabilities{Kind: KindPlug}
+	case strings.Contains(name, "RGB"):
+		return Capabilities{Kind: KindRGB, Color: true, ColorTemp: true, MinKelvin: MinKelvin, MaxKelvin: MaxKelvin, Dimming: true, Scenes: true}
+	case strings.Contains(name, "TW"):
+		return Capabilities{Kind: KindTunableWhite, ColorTemp: true, MinKelvin: 2700, MaxKelvin: MaxKelvin, Dimming: true, Scenes: true}
+	case strings.Contains(name, "DW"):
+		return Capabilities{Kind: KindDimmable, Dimming: true, Scenes: true}
+	default:
+		return Capabilities{Kind: KindUnknown, Color: true, ColorTemp: true, MinKelvin: MinKelvin, MaxKelvin: MaxKelvin, Dimming: true, Scenes: true}
+	}
+}
+
+// SupportsScene reports whether a scene id is available on this device.
+func (c Capabilities) SupportsScene(id int) bool {
+	if !c.Scenes {
+		return false
+	}
+	switch c.Kind {
+	case KindTunableWhite:
+		return containsInt(tunableWhiteScenes, id)
+	case KindDimmable:
+		return containsInt(dimmableScenes, id)
+	default:
+		_, ok := SceneByID(id)
+		return ok
+	}
+}
+
+// ClampKelvin limits a temperature to the device range, falling back to the protocol range.
+func (c Capabilities) ClampKelvin(kelvin int) int {
+	kelvin = ClampKelvin(kelvin)
+	if c.MinKelvin > 0 && kelvin < c.MinKelvin {
+		kelvin = c.MinKelvin
+	}
+	if c.MaxKelvin > 0 && kelvin > c.MaxKelvin {
+		kelvin = c.MaxKelvin
+	}
+	return kelvin
+}
+
+// applyKelvinRange narrows the Kelvin range from a config result's
+// cctRange, whiteRange, or extRange field when present.
+func (c *Capabilities) applyKelvinRange(result map[string]interface{}) {
+	if !c.ColorTemp {
+		return
+	}
+	for _, key := range []string{"cctRange", "whiteRange", "extRange"} {
+		values, ok := result[key].([]interface{})
+		if !ok || len(values) < 2 {
+			continue
+		}
+		low, high := 0, 0
+		for _, value := range values {
+			kelvin := asInt(value)
+			if kelvin <= 0 {
+				continue
+			}
+			if low == 0 || kelvin < low {
+				low = kelvin
+			}
+			if kelvin > high {
+				high = kelvin
+			}
+		}
+		if low > 0 && high > low {
+			c.MinKelvin = ClampKelvin(low)
+			c.MaxKelvin = ClampKelvin(high)
+			return
+		}
+	}
+}
+
+// DetectCapabilities reads getSystemConfig and getModelConfig to describe a device.
+// getModelConfig is optional; older firmware that rejects it keeps module-name defaults.
+// Plugs report PowerMetering only when they answer getPower.
+func (c *Client) DetectCapabilities(ip, port string) (Capabilities, error) {
+	return c.DetectCapabilitiesContext(context.Background(), ip, port)
+}
+
+// DetectCapabilitiesContext is DetectCapabilities that stops waiting when ctx is done.
+func (c *Client) DetectCapabilitiesContext(ctx context.Context, ip, port string) (Capabilities, error) {
+	system, err := c.call(ctx, ip, port, "getSystemConfig", map[string]interface{}{})
+	if err != nil {
+		return CapabilitiesFromModule(""), err
+	}
+	caps := CapabilitiesFromModule(asString(system["moduleName"]))
+	caps.applyKelvinRange(system)
+
+	model, err := c.call(ctx, ip, port, "getModelConfig", map[string]interface{}{})
+	if err == nil {
+		caps.applyKelvinRange(model)
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		return caps, ctxErr
+	}
+
+	if caps.Kind == KindPlug {
+		_, err := c.GetPowerContext(ctx, ip, port)
+		caps.PowerMetering = err == nil
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return caps, ctxErr
+		}
+	}
+	return caps, nil
+}
+
+// DetectCapabilities describes a device using the default client.
+func DetectCapabilities(ip, port string) (Capabilities, error) {
+	return defaultClient.DetectCapabilities(ip, port)
+}
+
+// DetectCapabilitiesContext is DetectCapabilities that stops waiting when ctx is done.
+func DetectCapabilitiesContext(ctx context.Context, ip, port string) (Capabilities, error) {
+	return defaultClient.DetectCapabilitiesContext(ctx, ip, port)
+}
+
+func containsInt(values []int, target int) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -1,17 +1,21 @@
 package wiz
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
-	"sort"
+	"net/netip"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type payload struct {
+	ID     int                    `json:"id,omitempty"`
 	Method string                 `json:"method"`
 	Params map[string]interface{} `json:"params"`
 }
@@ -22,130 +26,163 @@ type discoveryPayload struct {
 }
 
 // PilotState describes current runtime light state from getPilot.
+// ColorHex is empty and Temp is set when the bulb is in tunable-white mode;
+// SceneID is non-zero while a built-in scene is running.
 type PilotState struct {
-	Power      bool
-	Brightness int
-	ColorHex   string
+	Power      bool   `json:"power"`
+	Brightness int    `json:"brightness"`
+	ColorHex   string `json:"color,omitempty"`
+	Temp       int    `json:"temp,omitempty"`
+	SceneID    int    `json:"sceneId,omitempty"`
+	Speed      int    `json:"speed,omitempty"`
 }
 
 // Device describes a discovered WiZ device.
 type Device struct {
-	IP       string
-	Mac      string
-	Name     string
-	Model    string
-	Firmware string
+	IP           string       `json:"ip"`
+	Port         string       `json:"port"`
+	Mac          string       `json:"mac"`
+	Name         string       `json:"name"`
+	Model        string       `json:"model,omitempty"`
+	Firmware     string       `json:"firmware,omitempty"`
+	Capabilities Capabilities `json:"capabilities"`
 }
 
-// DiscoverDevices scans local network broadcast targets and returns detected bulbs.
-func DiscoverDevices() ([]Device, error) {
-	listenAddr := &net.UDPAddr{IP: net.IPv4zero, Port: 0}
-	conn, err := net.ListenUDP("udp4", listenAddr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create discovery socket: %w", err)
-	}
-	defer conn.Close()
-
-	_ = conn.SetWriteBuffer(8 * 1024)
-	_ = conn.SetReadBuffer(16 * 1024)
+// Options configures timeouts and retry behaviour for a Client.
+type Options struct {
+	// ReadTimeout bounds how long a single attempt waits for a reply.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds a single datagram write.
+	WriteTimeout time.Duration
+	// Attempts is the total number of tries per request, including the first.
+	Attempts int
+	// Backoff returns the pause before retry attempt n (starting at 1).
+	Backoff func(attempt int) time.Duration
+	// DiscoveryWindow is how long discovery listens for replies after probing.
+	DiscoveryWindow time.Duration
+	// DiscoveryTargets replaces the broadcast probe list with explicit host:port
+	// addresses, e.g. simulated bulbs on loopback.
+	DiscoveryTargets []string
+	// CoalesceInterval is the minimum gap between sends to one device through
+	// SendCoalesced.
+	CoalesceInterval time.Duration
+}
 
-	discovery := discoveryPayload{Method: "getSystemConfig", Params: map[string]string{}}
-	jsonData, err := json.Marshal(discovery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal discovery payload: %w", err)
+// DefaultOptions returns the retry and timeout policy used by package-level helpers.
+func DefaultOptions() Options {
+	return Options{
+		ReadTimeout:      2 * time.Second,
+		WriteTimeout:     2 * time.Second,
+		Attempts:         3,
+		Backoff:          LinearBackoff(100 * time.Millisecond),
+		DiscoveryWindow:  3 * time.Second,
+		CoalesceInterval: 100 * time.Millisecond,
 	}
+}
 
-	targets := discoveryTargets(38899)
-	for i := 0; i < 3; i++ {
-		for _, target := range targets {
-			_, _ = conn.WriteToUDP(jsonData, target)
-		}
-		time.Sleep(150 * time.Millisecond)
+// LinearBackoff returns a backoff that waits step multiplied by the attempt number.
+func LinearBackoff(step time.Duration) func(int) time.Duration {
+	return func(attempt int) time.Duration {
+		return time.Duration(attempt) * step
 	}
+}
 
-	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
-
-	devicesByKey := make(map[string]Device)
-	buffer := make([]byte, 2048)
-	for {
-		n, addr, err := conn.ReadFromUDP(buffer)
-		if err != nil {
-			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-				break
-			}
-			return nil, fmt.Errorf("error reading discovery response: %w", err)
-		}
+// Client talks to WiZ devices over one long-lived UDP socket and matches
+// replies to the requests that triggered them.
+type Client struct {
+	opts Options
+
+	mu      sync.Mutex
+	conn    *net.UDPConn
+	closed  bool
+	nextID  int
+	pending map[int]*pendingRequest
+	lanes   map[string]*lane
+}
 
-		var response map[string]interface{}
-		if err := json.Unmarshal(buffer[:n], &response); err != nil {
-			continue
-		}
+type pendingRequest struct {
+	addr   netip.AddrPort
+	method string
+	reply  chan []byte
+}
 
-		if result, ok := response["result"].(map[string]interface{}); ok {
-			mac := asString(result["mac"])
-			name := asString(result["moduleName"])
-			model := asString(result["moduleName"])
-			firmware := asString(result["fwVersion"])
+var defaultClient = NewClient(DefaultOptions())
 
-			if name == "" {
-				name = asString(result["deviceName"])
-			}
-			if name == "" {
-				name = makeFallbackName(mac, addr.IP.String())
-			}
+// SetDefault replaces the client behind the package-level helpers and returns the previous one.
+func SetDefault(client *Client) *Client {
+	previous := defaultClient
+	defaultClient = client
+	return previous
+}
 
-			device := Device{IP: addr.IP.String(), Mac: mac, Name: name, Model: model, Firmware: firmware}
-			key := strings.ToLower(strings.TrimSpace(mac))
-			if key == "" {
-				key = "ip:" + device.IP
-			}
-			devicesByKey[key] = device
-		}
+// NewClient creates a client; its socket is opened lazily on first use.
+func NewClient(opts Options) *Client {
+	defaults := DefaultOptions()
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = defaults.ReadTimeout
 	}
-
-	devices := make([]Device, 0, len(devicesByKey))
-	for _, device := range devicesByKey {
-		devices = append(devices, device)
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaults.WriteTimeout
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = defaults.Attempts
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = defaults.Backoff
+	}
+	if opts.DiscoveryWindow <= 0 {
+		opts.DiscoveryWindow = defaults.DiscoveryWindow
 	}
+	if opts.CoalesceInterval <= 0 {
+		opts.CoalesceInterval = defaults.CoalesceInterval
+	}
+	return &Client{opts: opts, pending: map[int]*pendingRequest{}}
+}
 
-	sort.Slice(devices, func(i, j int) bool {
-		if devices[i].Name == devices[j].Name {
-			return devices[i].IP < devices[j].IP
-		}
-		return devices[i].Name < devices[j].Name
-	})
+// Close releases the client socket. Further requests fail.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
 
-	return devices, nil
+// SendCommand writes a command datagram with retries on write failure.
+func (c *Client) SendCommand(ip, port, method string, params map[string]interface{}) error {
+	return c.SendCommandContext(context.Background(), ip, port, method, params)
 }
 
-// SendCommand sends a UDP command payload with retries and timeout handling.
-func SendCommand(ip, port, method string, params map[string]interface{}) error {
-	payload := payload{Method: method, Params: params}
-	jsonData, err := json.Marshal(payload)
+// SendCommandContext is SendCommand with cancellation between attempts.
+func (c *Client) SendCommandContext(ctx context.Context, ip, port, method string, params map[string]interface{}) error {
+	addr, err := resolveAddr(ip, port)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(payload{Method: method, Params: params})
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON payload: %w", err)
 	}
 
-	address := net.JoinHostPort(ip, port)
 	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		conn, err := net.Dial("udp", address)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to connect to %s (attempt %d): %w", address, attempt+1, err)
-			if attempt < 2 {
-				time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
+	for attempt := 0; attempt < c.opts.Attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.opts.Backoff(attempt)); err != nil {
+				return err
 			}
-			continue
+		} else if err := ctx.Err(); err != nil {
+			return err
 		}
-
-		_ = conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
-		_, err = conn.Write(jsonData)
-		_ = conn.Close()
-		if err != nil {
-			lastErr = fmt.Errorf("failed to send data to %s (attempt %d): %w", address, attempt+1, err)
-			if attempt < 2 {
-				time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
+		if err := c.write(jsonData, addr); err != nil {
+			if errors.Is(err, ErrClosed) {
+				return err
 			}
+			lastErr = fmt.Errorf("failed to send data to %s (attempt %d): %w: %w", addr, attempt+1, ErrUnreachable, err)
 			continue
 		}
 		return nil
@@ -157,135 +194,338 @@ func SendCommand(ip, port, method string, params map[string]interface{}) error {
 	return lastErr
 }
 
-// GetPilotState fetches current power, brightness, and RGB color from a device.
-func GetPilotState(ip, port string) (PilotState, error) {
-	request := payload{Method: "getPilot", Params: map[string]interface{}{}}
-	jsonData, err := json.Marshal(request)
+// SendCommandAck sends a command and waits for the device to confirm success,
+// retrying when no reply arrives.
+func (c *Client) SendCommandAck(ip, port, method string, params map[string]interface{}) error {
+	return c.SendCommandAckContext(context.Background(), ip, port, method, params)
+}
+
+// SendCommandAckContext is SendCommandAck that stops retrying when ctx is done.
+func (c *Client) SendCommandAckContext(ctx context.Context, ip, port, method string, params map[string]interface{}) error {
+	result, err := c.call(ctx, ip, port, method, params)
+	if err != nil {
+		return err
+	}
+	if !asBool(result["success"]) {
+		return fmt.Errorf("%s to %s: %w", method, net.JoinHostPort(ip, port), ErrNotAcknowledged)
+	}
+	return nil
+}
+
+// GetPilotState fetches current power, brightness, RGB color, and white temperature from a device.
+func (c *Client) GetPilotState(ip, port string) (PilotState, error) {
+	return c.GetPilotStateContext(context.Background(), ip, port)
+}
+
+// GetPilotStateContext is GetPilotState that stops waiting when ctx is done.
+func (c *Client) GetPilotStateContext(ctx context.Context, ip, port string) (PilotState, error) {
+	result, err := c.call(ctx, ip, port, "getPilot", map[string]interface{}{})
+	if err != nil {
+		return PilotState{}, err
+	}
+	return parsePilotState(result), nil
+}
+
+// call sends a request and waits for its matching result object, retrying on
+// write failures, timeouts, and malformed replies.
+func (c *Client) call(ctx context.Context, ip, port, method string, params map[string]interface{}) (map[string]interface{}, error) {
+	addr, err := resolveAddr(ip, port)
+	if err != nil {
+		return nil, err
+	}
+
+	id, reply := c.register(addr, method)
+	defer c.unregister(id)
+
+	jsonData, err := json.Marshal(payload{ID: id, Method: method, Params: params})
 	if err != nil {
-		return PilotState{}, fmt.Errorf("failed to marshal getPilot payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", method, err)
 	}
 
-	address := net.JoinHostPort(ip, port)
 	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		conn, err := net.Dial("udp", address)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to connect to %s (attempt %d): %w", address, attempt+1, err)
-			time.Sleep(time.Duration(attempt+1) * 120 * time.Millisecond)
-			continue
+	for attempt := 0; attempt < c.opts.Attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.opts.Backoff(attempt)); err != nil {
+				return nil, err
+			}
+		} else if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
-		_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
-		if _, err := conn.Write(jsonData); err != nil {
-			_ = conn.Close()
-			lastErr = fmt.Errorf("failed to send getPilot to %s (attempt %d): %w", address, attempt+1, err)
-			time.Sleep(time.Duration(attempt+1) * 120 * time.Millisecond)
+		if err := c.write(jsonData, addr); err != nil {
+			if errors.Is(err, ErrClosed) {
+				return nil, err
+			}
+			lastErr = fmt.Errorf("failed to send %s to %s (attempt %d): %w: %w", method, addr, attempt+1, ErrUnreachable, err)
 			continue
 		}
 
-		buffer := make([]byte, 4096)
-		n, err := conn.Read(buffer)
-		_ = conn.Close()
-		if err != nil {
-			lastErr = fmt.Errorf("failed reading getPilot response from %s (attempt %d): %w", address, attempt+1, err)
-			time.Sleep(time.Duration(attempt+1) * 120 * time.Millisecond)
+		var data []byte
+		timer := time.NewTimer(c.opts.ReadTimeout)
+		select {
+		case data = <-reply:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+			lastErr = fmt.Errorf("%s to %s (attempt %d): %w", method, addr, attempt+1, ErrTimeout)
 			continue
 		}
 
 		var response map[string]interface{}
-		if err := json.Unmarshal(buffer[:n], &response); err != nil {
-			lastErr = fmt.Errorf("failed to decode getPilot response: %w", err)
+		if err := json.Unmarshal(data, &response); err != nil {
+			lastErr = fmt.Errorf("failed to decode %s response: %w: %w", method, ErrMalformedResponse, err)
 			continue
 		}
 
+		// The device answered, so a rejection is final rather than retryable.
+		if deviceErr := parseDeviceError(method, response); deviceErr != nil {
+			return nil, deviceErr
+		}
+
 		result, ok := response["result"].(map[string]interface{})
 		if !ok {
-			lastErr = fmt.Errorf("getPilot response missing result")
+			lastErr = fmt.Errorf("%s response: %w", method, ErrNoResult)
 			continue
 		}
-
-		power := asBool(result["state"])
-		brightness := asInt(result["dimming"])
-		if brightness < 0 {
-			brightness = 0
-		}
-		if brightness > 100 {
-			brightness = 100
-		}
-
-		r := asInt(result["r"])
-		g := asInt(result["g"])
-		b := asInt(result["b"])
-		colorHex := fmt.Sprintf("#%02X%02X%02X", clampColor(r), clampColor(g), clampColor(b))
-
-		return PilotState{Power: power, Brightness: brightness, ColorHex: colorHex}, nil
+		return result, nil
 	}
 
 	if lastErr == nil {
-		lastErr = fmt.Errorf("unknown getPilot failure")
+		lastErr = fmt.Errorf("unknown %s failure", method)
 	}
-	return PilotState{}, lastErr
+	return nil, lastErr
 }
 
-// HexToRGB converts a six-digit hex color string to RGB values.
-func HexToRGB(h string) (uint8, uint8, uint8, error) {
-	h = strings.TrimPrefix(h, "#")
-	if len(h) != 6 {
-		return 0, 0, 0, fmt.Errorf("invalid hex")
+// socket returns the shared UDP socket, opening it and its reader on first use.
+func (c *Client) socket() (*net.UDPConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
 	}
-	b, err := hex.DecodeString(h)
-	if err != nil {
-		return 0, 0, 0, err
+	if c.conn != nil {
+		return c.conn, nil
 	}
-	return b[0], b[1], b[2], nil
-}
 
-func discoveryTargets(port int) []*net.UDPAddr {
-	targets := map[string]*net.UDPAddr{
-		net.JoinHostPort("255.255.255.255", fmt.Sprintf("%d", port)): &net.UDPAddr{IP: net.IPv4bcast.To4(), Port: port},
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UDP socket: %w", err)
 	}
+	_ = conn.SetReadBuffer(16 * 1024)
+	c.conn = conn
+	go c.readLoop(conn)
+	return conn, nil
+}
 
-	ifaces, err := net.Interfaces()
+// write sends one datagram to addr on the shared socket.
+func (c *Client) write(data []byte, addr netip.AddrPort) error {
+	conn, err := c.socket()
 	if err != nil {
-		return []*net.UDPAddr{{IP: net.IPv4bcast, Port: port}}
+		return err
 	}
+	_ = conn.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout))
+	_, err = conn.WriteToUDPAddrPort(data, addr)
+	return err
+}
 
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagBroadcast == 0 {
-			continue
-		}
-
-		addrs, err := iface.Addrs()
+// readLoop delivers incoming datagrams to waiting requests until the socket closes.
+func (c *Client) readLoop(conn *net.UDPConn) {
+	buffer := make([]byte, 4096)
+	for {
+		n, addr, err := conn.ReadFromUDPAddrPort(buffer)
 		if err != nil {
-			continue
+			c.mu.Lock()
+			if c.conn == conn {
+				c.conn = nil
+				_ = conn.Close()
+			}
+			c.mu.Unlock()
+			return
 		}
 
-		for _, addr := range addrs {
-			ipNet, ok := addr.(*net.IPNet)
-			if !ok || ipNet.IP == nil || ipNet.Mask == nil {
+		data := make([]byte, n)
+		copy(data, buffer[:n])
+		c.dispatch(normalizeAddrPort(addr), data)
+	}
+}
+
+// dispatch routes a reply to its request by echoed id. Replies without an id
+// go to the oldest idle request from the same address and method; replies
+// whose id is no longer pending, such as late answers to an abandoned
+// request, are dropped so they cannot answer a newer call.
+func (c *Client) dispatch(addr netip.AddrPort, data []byte) {
+	var response map[string]interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		response = map[string]interface{}{}
+	}
+	id := asInt(response["id"])
+	method := asString(response["method"])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var match *pendingRequest
+	if id != 0 {
+		if request, ok := c.pending[id]; ok && request.addr == addr {
+			match = request
+		}
+	} else {
+		matchID := 0
+		for requestID, request := range c.pending {
+			if request.addr != addr || len(request.reply) > 0 {
 				continue
 			}
-
-			ipv4 := ipNet.IP.To4()
-			if ipv4 == nil || len(ipNet.Mask) < 4 {
+			if method != "" && request.method != method {
 				continue
 			}
-
-			broadcast := make(net.IP, len(ipv4))
-			for idx := 0; idx < 4; idx++ {
-				broadcast[idx] = ipv4[idx] | ^ipNet.Mask[idx]
+			if match == nil || requestID < matchID {
+				match = request
+				matchID = requestID
 			}
-
-			target := &net.UDPAddr{IP: broadcast, Port: port}
-			targets[target.String()] = target
 		}
 	}
+	if match == nil {
+		return
+	}
+
+	select {
+	case match.reply <- data:
+	default:
+	}
+}
+
+// register records a pending request and returns its id and reply channel.
+func (c *Client) register(addr netip.AddrPort, method string) (int, chan []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	id := c.nextID
+	reply := make(chan []byte, 1)
+	c.pending[id] = &pendingRequest{addr: addr, method: method, reply: reply}
+	return id, reply
+}
+
+// unregister removes a pending request once its caller stops waiting.
+func (c *Client) unregister(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// sleepContext pauses for d or until ctx is done, returning ctx.Err() on cancellation.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// resolveAddr resolves a device host and port into a comparable UDP address.
+func resolveAddr(ip, port string) (netip.AddrPort, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(ip, port))
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("failed to resolve %s: %w: %w", net.JoinHostPort(ip, port), ErrUnreachable, err)
+	}
+	return normalizeAddrPort(udpAddr.AddrPort()), nil
+}
+
+// normalizeAddrPort unmaps IPv4-in-IPv6 addresses so replies compare equal to targets.
+func normalizeAddrPort(addr netip.AddrPort) netip.AddrPort {
+	return netip.AddrPortFrom(addr.Addr().Unmap(), addr.Port())
+}
+
+// parsePilotState converts a getPilot result object into a PilotState.
+func parsePilotState(result map[string]interface{}) PilotState {
+	power := asBool(result["state"])
+	brightness := asInt(result["dimming"])
+	if brightness < 0 {
+		brightness = 0
+	}
+	if brightness > 100 {
+		brightness = 100
+	}
+
+	state := PilotState{Power: power, Brightness: brightness}
+
+	_, hasR := result["r"]
+	_, hasG := result["g"]
+	_, hasB := result["b"]
+	if hasR || hasG || hasB {
+		r := asInt(result["r"])
+		g := asInt(result["g"])
+		b := asInt(result["b"])
+		state.ColorHex = fmt.Sprintf("#%02X%02X%02X", clampColor(r), clampColor(g), clampColor(b))
+	}
+
+	if temp := asInt(result["temp"]); temp > 0 {
+		state.Temp = temp
+	}
+	state.SceneID = asInt(result["sceneId"])
+	state.Speed = asInt(result["speed"])
+
+	return state
+}
+
+// DiscoverDevices scans local network broadcast targets using the default client.
+func DiscoverDevices() ([]Device, error) {
+	return defaultClient.DiscoverDevices()
+}
+
+// SendCommand sends a UDP command payload using the default client.
+func SendCommand(ip, port, method string, params map[string]interface{}) error {
+	return defaultClient.SendCommand(ip, port, method, params)
+}
+
+// SendCommandAck sends a command and waits for its success reply using the default client.
+func SendCommandAck(ip, port, method string, params map[string]interface{}) error {
+	return defaultClient.SendCommandAck(ip, port, method, params)
+}
+
+// GetPilotState fetches current power, brightness, and RGB color using the default client.
+func GetPilotState(ip, port string) (PilotState, error) {
+	return defaultClient.GetPilotState(ip, port)
+}
+
+// DiscoverDevicesContext is DiscoverDevices that stops scanning when ctx is done.
+func DiscoverDevicesContext(ctx context.Context) ([]Device, error) {
+	return defaultClient.DiscoverDevicesContext(ctx)
+}
+
+// SendCommandContext is SendCommand that stops retrying when ctx is done.
+func SendCommandContext(ctx context.Context, ip, port, method string, params map[string]interface{}) error {
+	return defaultClient.SendCommandContext(ctx, ip, port, method, params)
+}
+
+// SendCommandAckContext is SendCommandAck that stops retrying when ctx is done.
+func SendCommandAckContext(ctx context.Context, ip, port, method string, params map[string]interface{}) error {
+	return defaultClient.SendCommandAckContext(ctx, ip, port, method, params)
+}
+
+// GetPilotStateContext is GetPilotState that stops waiting when ctx is done.
+func GetPilotStateContext(ctx context.Context, ip, port string) (PilotState, error) {
+	return defaultClient.GetPilotStateContext(ctx, ip, port)
+}
 
-	result := make([]*net.UDPAddr, 0, len(targets))
-	for _, target := range targets {
-		result = append(result, target)
+// HexToRGB converts a six-digit hex color string to RGB values.
+func HexToRGB(h string) (uint8, uint8, uint8, error) {
+	h = strings.TrimPrefix(h, "#")
+	if len(h) != 6 {
+		return 0, 0, 0, ErrInvalidHex
 	}
-	return result
+	b, err := hex.DecodeString(h)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %w", ErrInvalidHex, err)
+	}
+	return b[0], b[1], b[2], nil
 }
 
 func asString(value interface{}) string {
@@ -319,6 +559,25 @@ func asInt(value interface{}) int {
 	return 0
 }
 
+func asFloat(value interface{}) float64 {
+	switch typed := value.(type) {
+	case float64:
+		return typed
+	case float32:
+		return float64(typed)
+	case int:
+		return float64(typed)
+	case int64:
+		return float64(typed)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err == nil {
+			return parsed
+		}
+	}
+	return 0
+}
+
 func asBool(value interface{}) bool {
 	switch typed := value.(type) {
 	case bool:
@@ -0,0 +1,182 @@
+package wiz
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// pilotModes groups setPilot keys that select mutually exclusive light modes.
+var pilotModes = [][]string{{"r", "g", "b"}, {"temp"}, {"sceneId", "speed"}}
+
+// lane queues the next send for one device and method while a drain runs.
+type lane struct {
+	pending *batch
+}
+
+// batch holds merged, not yet sent params and the callers waiting on them.
+// Its context is cancelled once every waiter has given up, so an abandoned
+// batch is not sent and a send in progress stops retrying.
+type batch struct {
+	params  map[string]interface{}
+	waiters []chan error
+	live    int
+	stops   []func() bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// SendCoalesced sends an acknowledged command through a per-device queue that
+// keeps only the latest pending params and sends at most once per
+// CoalesceInterval. Calls superseded while waiting return the result of the
+// send that absorbed them.
+func (c *Client) SendCoalesced(ctx context.Context, ip, port, method string, params map[string]interface{}) error {
+	key := net.JoinHostPort(ip, port) + " " + method
+	done := make(chan error, 1)
+
+	c.mu.Lock()
+	if c.lanes == nil {
+		c.lanes = map[string]*lane{}
+	}
+	current, running := c.lanes[key]
+	if !running {
+		current = &lane{}
+		c.lanes[key] = current
+	}
+	// Params left by callers that all gave up are dropped with their batch.
+	next := current.pending
+	if next == nil || next.ctx.Err() != nil {
+		next = &batch{}
+		next.ctx, next.cancel = context.WithCancel(context.Background())
+		current.pending = next
+	}
+	next.params = mergeParams(next.params, params)
+	next.waiters = append(next.waiters, done)
+	next.live++
+	next.stops = append(next.stops, context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if next.live--; next.live == 0 {
+			next.cancel()
+		}
+	}))
+	c.mu.Unlock()
+
+	if !running {
+		go c.drain(key, current, ip, port, method)
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain sends merged params for a lane until nothing is pending, pacing sends
+// by CoalesceInterval so new updates can accumulate in between.
+func (c *Client) drain(key string, current *lane, ip, port, method string) {
+	var last time.Time
+	for {
+		if !last.IsZero() {
+			c.pace(current, c.opts.CoalesceInterval-time.Since(last))
+		}
+
+		c.mu.Lock()
+		next := current.pending
+		current.pending = nil
+		if next == nil {
+			delete(c.lanes, key)
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+
+		err := next.ctx.Err()
+		if err == nil {
+			last = time.Now()
+			err = c.SendCommandAckContext(next.ctx, ip, port, method, next.params)
+		}
+		next.finish(err)
+	}
+}
+
+// pace waits out the gap before a lane's next send, dropping the pending
+// batch early if all of its callers give up meanwhile.
+func (c *Client) pace(current *lane, wait time.Duration) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		c.mu.Lock()
+		next := current.pending
+		c.mu.Unlock()
+		var abandoned <-chan struct{}
+		if next != nil {
+			abandoned = next.ctx.Done()
+		}
+
+		select {
+		case <-timer.C:
+			return
+		case <-abandoned:
+			c.mu.Lock()
+			if current.pending == next {
+				current.pending = nil
+			}
+			c.mu.Unlock()
+			next.finish(next.ctx.Err())
+		}
+	}
+}
+
+// finish releases a batch's context and reports err to its waiters.
+func (b *batch) finish(err error) {
+	for _, stop := range b.stops {
+		stop()
+	}
+	b.cancel()
+	for _, waiter := range b.waiters {
+		waiter <- err
+	}
+}
+
+// mergeParams overlays next onto pending, dropping pending keys of any light
+// mode that next switches away from.
+func mergeParams(pending, next map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(pending)+len(next))
+	for key, value := range pending {
+		merged[key] = value
+	}
+	for index, mode := range pilotModes {
+		if !hasAnyKey(next, mode) {
+			continue
+		}
+		for otherIndex, other := range pilotModes {
+			if otherIndex == index {
+				continue
+			}
+			for _, key := range other {
+				delete(merged, key)
+			}
+		}
+	}
+	for key, value := range next {
+		merged[key] = value
+	}
+	return merged
+}
+
+func hasAnyKey(params map[string]interface{}, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := params[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SendCoalesced sends a rate-limited, merged command using the default client.
+func SendCoalesced(ctx context.Context, ip, port, method string, params map[string]interface{}) error {
+	return defaultClient.SendCoalesced(ctx, ip, port, method, params)
+}
@@ -0,0 +1,54 @@
+package wiz
+
+import (
+	"fmt"
+	"math"
+)
+
+// Tunable-white range accepted by setPilot temp.
+const (
+	MinKelvin = 2200
+	MaxKelvin = 6500
+)
+
+// ClampKelvin limits a color temperature to the range WiZ bulbs accept.
+func ClampKelvin(kelvin int) int {
+	if kelvin < MinKelvin {
+		return MinKelvin
+	}
+	if kelvin > MaxKelvin {
+		return MaxKelvin
+	}
+	return kelvin
+}
+
+// KelvinToRGB approximates the display color of a white temperature for UI swatches.
+func KelvinToRGB(kelvin int) (uint8, uint8, uint8) {
+	temp := float64(kelvin) / 100
+
+	var r, g, b float64
+	if temp <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(temp) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(temp-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(temp-60, -0.0755148492)
+	}
+
+	switch {
+	case temp >= 66:
+		b = 255
+	case temp <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(temp-10) - 305.0447927307
+	}
+
+	return uint8(clampColor(int(r))), uint8(clampColor(int(g))), uint8(clampColor(int(b)))
+}
+
+// KelvinToHex returns the KelvinToRGB approximation as a #RRGGBB string.
+func KelvinToHex(kelvin int) string {
+	r, g, b := KelvinToRGB(kelvin)
+	return fmt.Sprintf("#%02X%02X%02X", r, g, b)
+}
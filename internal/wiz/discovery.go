@@ -0,0 +1,179 @@
+package wiz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiscoverDevices scans local network broadcast targets and returns detected bulbs.
+func (c *Client) DiscoverDevices() ([]Device, error) {
+	return c.DiscoverDevicesContext(context.Background())
+}
+
+// DiscoverDevicesContext is DiscoverDevices that returns ctx.Err() as soon as ctx is done.
+func (c *Client) DiscoverDevicesContext(ctx context.Context) ([]Device, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	listenAddr := &net.UDPAddr{IP: net.IPv4zero, Port: 0}
+	conn, err := net.ListenUDP("udp4", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery socket: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read loop below as soon as the caller gives up.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	_ = conn.SetWriteBuffer(8 * 1024)
+	_ = conn.SetReadBuffer(16 * 1024)
+
+	discovery := discoveryPayload{Method: "getSystemConfig", Params: map[string]string{}}
+	jsonData, err := json.Marshal(discovery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal discovery payload: %w", err)
+	}
+
+	var targets []*net.UDPAddr
+	if len(c.opts.DiscoveryTargets) > 0 {
+		for _, target := range c.opts.DiscoveryTargets {
+			addr, err := net.ResolveUDPAddr("udp4", target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid discovery target %q: %w", target, err)
+			}
+			targets = append(targets, addr)
+		}
+	} else {
+		targets = discoveryTargets(38899)
+	}
+	for i := 0; i < c.opts.Attempts; i++ {
+		for _, target := range targets {
+			_ = conn.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout))
+			_, _ = conn.WriteToUDP(jsonData, target)
+		}
+		if err := sleepContext(ctx, 150*time.Millisecond); err != nil {
+			return nil, err
+		}
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(c.opts.DiscoveryWindow))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	devicesByKey := make(map[string]Device)
+	buffer := make([]byte, 2048)
+	for {
+		n, addr, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("error reading discovery response: %w", err)
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(buffer[:n], &response); err != nil {
+			continue
+		}
+
+		if result, ok := response["result"].(map[string]interface{}); ok {
+			mac := asString(result["mac"])
+			name := asString(result["moduleName"])
+			model := asString(result["moduleName"])
+			firmware := asString(result["fwVersion"])
+
+			if name == "" {
+				name = asString(result["deviceName"])
+			}
+			if name == "" {
+				name = makeFallbackName(mac, addr.IP.String())
+			}
+
+			caps := CapabilitiesFromModule(model)
+			caps.applyKelvinRange(result)
+
+			device := Device{IP: addr.IP.String(), Port: strconv.Itoa(addr.Port), Mac: mac, Name: name, Model: model, Firmware: firmware, Capabilities: caps}
+			key := strings.ToLower(strings.TrimSpace(mac))
+			if key == "" {
+				key = "ip:" + net.JoinHostPort(device.IP, device.Port)
+			}
+			devicesByKey[key] = device
+		}
+	}
+
+	devices := make([]Device, 0, len(devicesByKey))
+	for _, device := range devicesByKey {
+		devices = append(devices, device)
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		if devices[i].Name == devices[j].Name {
+			return devices[i].IP < devices[j].IP
+		}
+		return devices[i].Name < devices[j].Name
+	})
+
+	return devices, nil
+}
+
+func discoveryTargets(port int) []*net.UDPAddr {
+	targets := map[string]*net.UDPAddr{
+		net.JoinHostPort("255.255.255.255", fmt.Sprintf("%d", port)): &net.UDPAddr{IP: net.IPv4bcast.To4(), Port: port},
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return []*net.UDPAddr{{IP: net.IPv4bcast, Port: port}}
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP == nil || ipNet.Mask == nil {
+				continue
+			}
+
+			ipv4 := ipNet.IP.To4()
+			if ipv4 == nil || len(ipNet.Mask) < 4 {
+				continue
+			}
+
+			broadcast := make(net.IP, len(ipv4))
+			for idx := 0; idx < 4; idx++ {
+				broadcast[idx] = ipv4[idx] | ^ipNet.Mask[idx]
+			}
+
+			target := &net.UDPAddr{IP: broadcast, Port: port}
+			targets[target.String()] = target
+		}
+	}
+
+	result := make([]*net.UDPAddr, 0, len(targets))
+	for _, target := range targets {
+		result = append(result, target)
+	}
+	return result
+}
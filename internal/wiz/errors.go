@@ -0,0 +1,57 @@
+package wiz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors returned by the wiz package; match them with errors.Is.
+var (
+	// ErrTimeout means the device never replied within the retry budget.
+	ErrTimeout = errors.New("timed out waiting for response")
+	// ErrUnreachable means the request could not be addressed or written to the network.
+	ErrUnreachable = errors.New("host unreachable")
+	// ErrNoResult means the device replied without a result object.
+	ErrNoResult = errors.New("missing result")
+	// ErrMalformedResponse means the reply was not valid JSON.
+	ErrMalformedResponse = errors.New("malformed response")
+	// ErrNotAcknowledged means the device replied but did not report success.
+	ErrNotAcknowledged = errors.New("command not acknowledged")
+	// ErrClosed means the client was closed before the request was sent.
+	ErrClosed = errors.New("wiz client is closed")
+	// ErrInvalidHex means a color string is not a six-digit hex value.
+	ErrInvalidHex = errors.New("invalid hex")
+)
+
+// DeviceError reports an error object returned by a WiZ device instead of a result.
+type DeviceError struct {
+	Method  string
+	Code    int
+	Message string
+}
+
+// Error formats the device rejection with its protocol code.
+func (e *DeviceError) Error() string {
+	message := strings.TrimSpace(e.Message)
+	if message == "" {
+		message = "unknown error"
+	}
+	return fmt.Sprintf("%s rejected by device: %s (code %d)", e.Method, message, e.Code)
+}
+
+// parseDeviceError extracts a DeviceError from a response error object, if present.
+func parseDeviceError(method string, response map[string]interface{}) *DeviceError {
+	raw, ok := response["error"]
+	if !ok || raw == nil {
+		return nil
+	}
+	deviceErr := &DeviceError{Method: method}
+	if fields, ok := raw.(map[string]interface{}); ok {
+		deviceErr.Code = asInt(fields["code"])
+		deviceErr.Message = asString(fields["message"])
+	} else {
+		deviceErr.Message = asString(raw)
+	}
+	return deviceErr
+}
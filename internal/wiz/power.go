@@ -0,0 +1,34 @@
+package wiz
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetPower reads live power draw in watts from a metering smart plug.
+func (c *Client) GetPower(ip, port string) (float64, error) {
+	return c.GetPowerContext(context.Background(), ip, port)
+}
+
+// GetPowerContext is GetPower that stops waiting when ctx is done.
+func (c *Client) GetPowerContext(ctx context.Context, ip, port string) (float64, error) {
+	result, err := c.call(ctx, ip, port, "getPower", map[string]interface{}{})
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := result["power"]; !ok {
+		return 0, fmt.Errorf("getPower response: %w", ErrNoResult)
+	}
+	// Plugs report milliwatts.
+	return asFloat(result["power"]) / 1000, nil
+}
+
+// GetPower reads live power draw in watts using the default client.
+func GetPower(ip, port string) (float64, error) {
+	return defaultClient.GetPower(ip, port)
+}
+
+// GetPowerContext is GetPower that stops waiting when ctx is done.
+func GetPowerContext(ctx context.Context, ip, port string) (float64, error) {
+	return defaultClient.GetPowerContext(ctx, ip, port)
+}
@@ -0,0 +1,237 @@
+package wiz
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultPushPort is the UDP port devices send syncPilot notifications to.
+const DefaultPushPort = 38900
+
+// registrationInterval is how often registrations are renewed; devices stop
+// pushing to hosts that have not re-registered for about a minute.
+const registrationInterval = 20 * time.Second
+
+// pushBuffer is how many pushes may queue before new ones are dropped.
+const pushBuffer = 64
+
+// Push is a state change a device reported on its own, including changes
+// made from the phone app or a wall switch. IP and Port are the address it
+// was sent from, which is the address the device answers requests on.
+type Push struct {
+	Mac   string     `json:"mac"`
+	IP    string     `json:"ip"`
+	Port  string     `json:"port"`
+	State PilotState `json:"state"`
+}
+
+// PushListener receives syncPilot notifications and keeps the devices
+// registered with it sending them. Registrations are sent from the listening
+// socket, so devices answer and push to the same port.
+type PushListener struct {
+	conn     *net.UDPConn
+	phoneMac string
+	pushes   chan Push
+	done     chan struct{}
+	stop     sync.Once
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	targets map[netip.AddrPort]bool
+}
+
+// ListenPush binds addr, normally ":38900", and renews registrations until
+// the listener is closed.
+func ListenPush(addr string) (*PushListener, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push address %q: %w", addr, err)
+	}
+	conn, err := net.ListenUDP("udp4", udpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for pushes on %s: %w", addr, err)
+	}
+	_ = conn.SetReadBuffer(16 * 1024)
+
+	// Devices only need a stable identifier for the host; use a random
+	// locally administered MAC rather than reading the interfaces.
+	mac := make([]byte, 6)
+	_, _ = rand.Read(mac)
+	mac[0] = mac[0]&0xfc | 0x02
+
+	l := &PushListener{
+		conn:     conn,
+		phoneMac: hex.EncodeToString(mac),
+		pushes:   make(chan Push, pushBuffer),
+		done:     make(chan struct{}),
+		targets:  map[netip.AddrPort]bool{},
+	}
+	l.wg.Add(2)
+	go l.readLoop()
+	go l.renewLoop()
+	return l, nil
+}
+
+// Addr returns the address the listener is bound to.
+func (l *PushListener) Addr() string {
+	return l.conn.LocalAddr().String()
+}
+
+// Pushes delivers notifications until the listener is closed. Pushes that
+// arrive while the channel is full are dropped.
+func (l *PushListener) Pushes() <-chan Push {
+	return l.pushes
+}
+
+// Register asks a device to push its state changes to this listener and
+// keeps renewing the registration. Registering a device twice is harmless.
+func (l *PushListener) Register(ip, port string) error {
+	addr, err := resolveAddr(ip, port)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.targets[addr] = true
+	l.mu.Unlock()
+	return l.register(addr)
+}
+
+// Unregister stops renewing a device's registration and asks it to stop
+// pushing to this listener.
+func (l *PushListener) Unregister(ip, port string) error {
+	addr, err := resolveAddr(ip, port)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	delete(l.targets, addr)
+	l.mu.Unlock()
+	return l.send(addr, false)
+}
+
+// Close stops listening and closes the Pushes channel.
+func (l *PushListener) Close() error {
+	var err error
+	l.stop.Do(func() {
+		close(l.done)
+		err = l.conn.Close()
+	})
+	l.wg.Wait()
+	return err
+}
+
+// register sends one registration datagram naming the local address the
+// device reaches this host on.
+func (l *PushListener) register(addr netip.AddrPort) error {
+	return l.send(addr, true)
+}
+
+// send writes a registration datagram that starts or stops pushes.
+func (l *PushListener) send(addr netip.AddrPort, register bool) error {
+	phoneIP, err := l.localIP(addr)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload{Method: "registration", Params: map[string]interface{}{
+		"phoneIp":  phoneIP,
+		"phoneMac": l.phoneMac,
+		"register": register,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal registration payload: %w", err)
+	}
+	_ = l.conn.SetWriteDeadline(time.Now().Add(DefaultOptions().WriteTimeout))
+	if _, err := l.conn.WriteToUDPAddrPort(data, addr); err != nil {
+		return fmt.Errorf("failed to register with %s: %w: %w", addr, ErrUnreachable, err)
+	}
+	return nil
+}
+
+// localIP returns the listener's address when it is bound to one, or else
+// the local address the route to the device leaves from.
+func (l *PushListener) localIP(addr netip.AddrPort) (string, error) {
+	if bound := l.conn.LocalAddr().(*net.UDPAddr).IP; !bound.IsUnspecified() {
+		return bound.String(), nil
+	}
+	// Connecting a UDP socket only picks a route; nothing is sent.
+	probe, err := net.DialUDP("udp4", nil, net.UDPAddrFromAddrPort(addr))
+	if err != nil {
+		return "", fmt.Errorf("no route to %s: %w: %w", addr, ErrUnreachable, err)
+	}
+	defer probe.Close()
+	return probe.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
+
+// renewLoop re-sends every registration until the listener is closed.
+func (l *PushListener) renewLoop() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(registrationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+		l.mu.Lock()
+		targets := make([]netip.AddrPort, 0, len(l.targets))
+		for addr := range l.targets {
+			targets = append(targets, addr)
+		}
+		l.mu.Unlock()
+		for _, addr := range targets {
+			_ = l.register(addr)
+		}
+	}
+}
+
+// readLoop decodes syncPilot notifications until the socket closes.
+// Registration replies are ignored; a firstBeat from a registered device
+// means it rebooted and forgot its registration, so it is registered again.
+func (l *PushListener) readLoop() {
+	defer l.wg.Done()
+	defer close(l.pushes)
+	buffer := make([]byte, 4096)
+	for {
+		n, addr, err := l.conn.ReadFromUDPAddrPort(buffer)
+		if err != nil {
+			return
+		}
+		addr = normalizeAddrPort(addr)
+
+		var message struct {
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if json.Unmarshal(buffer[:n], &message) != nil || message.Params == nil {
+			continue
+		}
+		switch message.Method {
+		case "syncPilot":
+			push := Push{
+				Mac:   asString(message.Params["mac"]),
+				IP:    addr.Addr().String(),
+				Port:  strconv.Itoa(int(addr.Port())),
+				State: parsePilotState(message.Params),
+			}
+			select {
+			case l.pushes <- push:
+			default:
+			}
+		case "firstBeat":
+			l.mu.Lock()
+			registered := l.targets[addr]
+			l.mu.Unlock()
+			if registered {
+				_ = l.register(addr)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package wiz
+
+import (
+	"context"
+	"time"
+)
+
+// SunriseStage is one keyframe of a wake-up ramp. At is its position in the
+// ramp from 0 to 1.
+type SunriseStage struct {
+	At    float64
+	State PilotState
+}
+
+// sunriseColor rises from deep red through orange on the RGB channels, then
+// hands over to tunable white for the bright cool finish.
+var sunriseColor = []SunriseStage{
+	{At: 0, State: PilotState{Power: true, Brightness: minDimming, ColorHex: "#8B0000"}},
+	{At: 0.3, State: PilotState{Power: true, Brightness: 30, ColorHex: "#FF3000"}},
+	{At: 0.55, State: PilotState{Power: true, Brightness: 55, ColorHex: "#FF8C00"}},
+	{At: 0.75, State: PilotState{Power: true, Brightness: 75, Temp: 2700}},
+	{At: 1, State: PilotState{Power: true, Brightness: 100, Temp: 6500}},
+}
+
+// SunriseStages returns wake-up keyframes suited to a device. Bulbs without
+// RGB ramp from their warmest to coolest white, dimmable bulbs ramp brightness
+// only, and devices that cannot dim get no ramp.
+func SunriseStages(caps Capabilities) []SunriseStage {
+	switch {
+	case caps.Color:
+		return append([]SunriseStage(nil), sunriseColor...)
+	case caps.ColorTemp:
+		return []SunriseStage{
+			{At: 0, State: PilotState{Power: true, Brightness: minDimming, Temp: caps.ClampKelvin(MinKelvin)}},
+			{At: 1, State: PilotState{Power: true, Brightness: 100, Temp: caps.ClampKelvin(6500)}},
+		}
+	case caps.Dimming:
+		return []SunriseStage{
+			{At: 0, State: PilotState{Power: true, Brightness: minDimming}},
+			{At: 1, State: PilotState{Power: true, Brightness: 100}},
+		}
+	default:
+		return nil
+	}
+}
+
+// Sunrise plays a wake-up ramp on one device over duration, switching it on
+// at minimum brightness and ending at full cool white. Capabilities are
+// detected first so each device gets the richest ramp it supports; a device
+// that cannot dim is simply switched on.
+func (c *Client) Sunrise(ctx context.Context, ip, port string, duration time.Duration) error {
+	caps, err := c.DetectCapabilitiesContext(ctx, ip, port)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	stages := SunriseStages(caps)
+	if len(stages) == 0 {
+		return c.SendCommandAckContext(ctx, ip, port, "setState", map[string]interface{}{"state": true})
+	}
+
+	first := Transition{To: stages[0].State}
+	if err := c.SendCommandAckContext(ctx, ip, port, "setPilot", first.Frame(1)); err != nil {
+		return err
+	}
+	for i := 1; i < len(stages); i++ {
+		segment := Transition{
+			From:     stages[i-1].State,
+			To:       stages[i].State,
+			Duration: time.Duration(float64(duration) * (stages[i].At - stages[i-1].At)),
+		}
+		if err := c.Fade(ctx, ip, port, segment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BroadcastSunrise runs Sunrise on every target concurrently.
+func (c *Client) BroadcastSunrise(ctx context.Context, targets []Target, duration time.Duration) []Result {
+	return fanOut(targets, func(_ int, target Target) error {
+		return c.Sunrise(ctx, target.IP, target.Port, duration)
+	})
+}
+
+// Sunrise plays a wake-up ramp on one device using the default client.
+func Sunrise(ctx context.Context, ip, port string, duration time.Duration) error {
+	return defaultClient.Sunrise(ctx, ip, port, duration)
+}
+
+// BroadcastSunrise plays a wake-up ramp on every target using the default client.
+func BroadcastSunrise(ctx context.Context, targets []Target, duration time.Duration) []Result {
+	return defaultClient.BroadcastSunrise(ctx, targets, duration)
+}
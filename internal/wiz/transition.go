@@ -0,0 +1,179 @@
+package wiz
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// DefaultFrameInterval is the gap between intermediate transition frames,
+// slow enough that bulbs keep up and fast enough to look smooth.
+const DefaultFrameInterval = 250 * time.Millisecond
+
+// minDimming is the lowest brightness setPilot accepts.
+const minDimming = 10
+
+// Transition interpolates brightness, RGB color, and white temperature from
+// one pilot state to another over Duration.
+//
+// A zero To.Brightness keeps the starting level, and an empty To.ColorHex with
+// a zero To.Temp keeps the starting color. When To.Power is false the light
+// dims to the minimum and is then switched off with its starting level
+// restored, so the next plain power-on does not come back at the minimum.
+type Transition struct {
+	From     PilotState
+	To       PilotState
+	Duration time.Duration
+	// FrameInterval bounds how often intermediate frames are sent; zero uses DefaultFrameInterval.
+	FrameInterval time.Duration
+}
+
+// Frame returns the setPilot params for progress between 0 and 1.
+func (t Transition) Frame(progress float64) map[string]interface{} {
+	if progress < 0 {
+		progress = 0
+	}
+	if progress > 1 {
+		progress = 1
+	}
+
+	from, to := t.From, t.To
+	fromLevel := from.Brightness
+	if !from.Power || fromLevel < minDimming {
+		fromLevel = minDimming
+	}
+	toLevel := to.Brightness
+	switch {
+	case !to.Power:
+		toLevel = minDimming
+	case toLevel == 0:
+		// Keep the level the device remembers, even when fading in from off.
+		toLevel = 100
+		if from.Brightness >= minDimming {
+			toLevel = from.Brightness
+		}
+	}
+	params := map[string]interface{}{"dimming": clampDimming(lerp(fromLevel, toLevel, progress))}
+
+	switch {
+	case to.ColorHex != "":
+		tr, tg, tb, err := HexToRGB(to.ColorHex)
+		if err != nil {
+			break
+		}
+		fr, fg, fb := startRGB(from, tr, tg, tb)
+		params["r"] = lerp(int(fr), int(tr), progress)
+		params["g"] = lerp(int(fg), int(tg), progress)
+		params["b"] = lerp(int(fb), int(tb), progress)
+	case to.Temp > 0:
+		// Crossfade from a color through RGB and land on the exact white temperature.
+		if from.ColorHex != "" && progress < 1 {
+			tr, tg, tb := KelvinToRGB(to.Temp)
+			fr, fg, fb := startRGB(from, tr, tg, tb)
+			params["r"] = lerp(int(fr), int(tr), progress)
+			params["g"] = lerp(int(fg), int(tg), progress)
+			params["b"] = lerp(int(fb), int(tb), progress)
+			break
+		}
+		fromTemp := from.Temp
+		if fromTemp <= 0 {
+			fromTemp = to.Temp
+		}
+		params["temp"] = ClampKelvin(lerp(fromTemp, to.Temp, progress))
+	}
+	return params
+}
+
+// Fade plays a transition on one device. Intermediate frames are sent without
+// waiting for replies so a dropped frame never stalls the fade; the final
+// state is acknowledged. Cancelling ctx stops the fade where it is.
+func (c *Client) Fade(ctx context.Context, ip, port string, t Transition) error {
+	interval := t.FrameInterval
+	if interval <= 0 {
+		interval = DefaultFrameInterval
+	}
+
+	if t.Duration > 0 {
+		start := time.Now()
+		for {
+			progress := float64(time.Since(start)) / float64(t.Duration)
+			if progress >= 1 {
+				break
+			}
+			if err := c.SendCommandContext(ctx, ip, port, "setPilot", t.Frame(progress)); err != nil {
+				return err
+			}
+			if err := sleepContext(ctx, interval); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !t.To.Power {
+		if t.From.Power && t.From.Brightness >= minDimming {
+			params := map[string]interface{}{"state": false, "dimming": clampDimming(t.From.Brightness)}
+			return c.SendCommandAckContext(ctx, ip, port, "setPilot", params)
+		}
+		return c.SendCommandAckContext(ctx, ip, port, "setState", map[string]interface{}{"state": false})
+	}
+	return c.SendCommandAckContext(ctx, ip, port, "setPilot", t.Frame(1))
+}
+
+// FadeTo reads the device's current state and fades from it to the target over duration.
+func (c *Client) FadeTo(ctx context.Context, ip, port string, to PilotState, duration time.Duration) error {
+	from, err := c.GetPilotStateContext(ctx, ip, port)
+	if err != nil {
+		return err
+	}
+	return c.Fade(ctx, ip, port, Transition{From: from, To: to, Duration: duration})
+}
+
+// BroadcastFade runs FadeTo on every target concurrently.
+func (c *Client) BroadcastFade(ctx context.Context, targets []Target, to PilotState, duration time.Duration) []Result {
+	return fanOut(targets, func(_ int, target Target) error {
+		return c.FadeTo(ctx, target.IP, target.Port, to, duration)
+	})
+}
+
+// startRGB returns the color a transition starts from, falling back to the
+// target color when the starting state has none.
+func startRGB(from PilotState, r, g, b uint8) (uint8, uint8, uint8) {
+	if from.ColorHex != "" {
+		if fr, fg, fb, err := HexToRGB(from.ColorHex); err == nil {
+			return fr, fg, fb
+		}
+	}
+	if from.Temp > 0 {
+		return KelvinToRGB(from.Temp)
+	}
+	return r, g, b
+}
+
+func lerp(from, to int, progress float64) int {
+	return from + int(math.Round(float64(to-from)*progress))
+}
+
+func clampDimming(value int) int {
+	if value < minDimming {
+		return minDimming
+	}
+	if value > 100 {
+		return 100
+	}
+	return value
+}
+
+// Fade plays a transition on one device using the default client.
+func Fade(ctx context.Context, ip, port string, t Transition) error {
+	return defaultClient.Fade(ctx, ip, port, t)
+}
+
+// FadeTo fades a device from its current state using the default client.
+func FadeTo(ctx context.Context, ip, port string, to PilotState, duration time.Duration) error {
+	return defaultClient.FadeTo(ctx, ip, port, to, duration)
+}
+
+// BroadcastFade fades every target concurrently using the default client.
+func BroadcastFade(ctx context.Context, targets []Target, to PilotState, duration time.Duration) []Result {
+	return defaultClient.BroadcastFade(ctx, targets, to, duration)
+}
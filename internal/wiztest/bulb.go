@@ -0,0 +1,490 @@
+// Package wiztest runs simulated WiZ devices on loopback for tests and demos.
+package wiztest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wiz-tui/internal/wiz"
+)
+
+// Config describes a simulated device and its starting state.
+type Config struct {
+	ModuleName string
+	Mac        string
+	FwVersion  string
+
+	Power      bool
+	Brightness int
+	ColorHex   string
+	Temp       int
+	SceneID    int
+	Speed      int
+
+	// Metered makes a plug answer getPower; unmetered plugs reject it with
+	// method-not-found like real ones.
+	Metered bool
+	// PowerMilliwatts is reported by getPower on metered plugs.
+	PowerMilliwatts int
+
+	// LossRate is the fraction of requests silently dropped, from 0 to 1.
+	LossRate float64
+	// Latency delays every reply.
+	Latency time.Duration
+}
+
+// Bulb is a running simulated device bound to a loopback UDP port.
+type Bulb struct {
+	conn *net.UDPConn
+	caps wiz.Capabilities
+	done chan struct{}
+
+	mu       sync.Mutex
+	cfg      Config
+	failures map[string]*wiz.DeviceError
+	requests map[string]int
+	// subscriber receives syncPilot pushes after a registration. Real
+	// devices push to the registered phoneIp on port 38900; the simulation
+	// pushes to the address the registration came from.
+	subscriber *net.UDPAddr
+}
+
+// Start binds a simulated device to a free loopback port and begins answering requests.
+func Start(cfg Config) (*Bulb, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start simulated bulb: %w", err)
+	}
+
+	if cfg.ModuleName == "" {
+		cfg.ModuleName = "ESP01_SHRGB1C_31"
+	}
+	if cfg.FwVersion == "" {
+		cfg.FwVersion = "1.25.0"
+	}
+	if cfg.Mac == "" {
+		cfg.Mac = fmt.Sprintf("a8bb50%06x", conn.LocalAddr().(*net.UDPAddr).Port)
+	}
+	if cfg.Brightness == 0 {
+		cfg.Brightness = 100
+	}
+	if cfg.ColorHex == "" && cfg.Temp == 0 && cfg.SceneID == 0 {
+		cfg.Temp = 4200
+	}
+	if cfg.Speed == 0 {
+		cfg.Speed = wiz.DefaultSceneSpeed
+	}
+
+	caps := wiz.CapabilitiesFromModule(cfg.ModuleName)
+	caps.PowerMetering = caps.Kind == wiz.KindPlug && cfg.Metered
+	bulb := &Bulb{
+		conn:     conn,
+		caps:     caps,
+		done:     make(chan struct{}),
+		cfg:      cfg,
+		failures: map[string]*wiz.DeviceError{},
+		requests: map[string]int{},
+	}
+	go bulb.serve()
+	return bulb, nil
+}
+
+// StartFleet starts one simulated device per config, closing all of them if any fails.
+func StartFleet(configs ...Config) ([]*Bulb, error) {
+	bulbs := make([]*Bulb, 0, len(configs))
+	for _, cfg := range configs {
+		bulb, err := Start(cfg)
+		if err != nil {
+			for _, started := range bulbs {
+				_ = started.Close()
+			}
+			return nil, err
+		}
+		bulbs = append(bulbs, bulb)
+	}
+	return bulbs, nil
+}
+
+// DiscoveryTargets returns host:port addresses for wiz.Options.DiscoveryTargets.
+func DiscoveryTargets(bulbs []*Bulb) []string {
+	targets := make([]string, 0, len(bulbs))
+	for _, bulb := range bulbs {
+		targets = append(targets, bulb.Addr())
+	}
+	return targets
+}
+
+// IP returns the loopback address the device listens on.
+func (b *Bulb) IP() string {
+	return b.conn.LocalAddr().(*net.UDPAddr).IP.String()
+}
+
+// Port returns the UDP port the device listens on.
+func (b *Bulb) Port() string {
+	return strconv.Itoa(b.conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+// Addr returns the device host:port.
+func (b *Bulb) Addr() string {
+	return net.JoinHostPort(b.IP(), b.Port())
+}
+
+// Mac returns the device MAC address.
+func (b *Bulb) Mac() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cfg.Mac
+}
+
+// Close stops the device.
+func (b *Bulb) Close() error {
+	err := b.conn.Close()
+	<-b.done
+	return err
+}
+
+// State returns a snapshot of the device's current pilot state.
+func (b *Bulb) State() wiz.PilotState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return wiz.PilotState{
+		Power:      b.cfg.Power,
+		Brightness: b.cfg.Brightness,
+		ColorHex:   b.cfg.ColorHex,
+		Temp:       b.cfg.Temp,
+		SceneID:    b.cfg.SceneID,
+		Speed:      b.cfg.Speed,
+	}
+}
+
+// Requests returns how many requests for method the device has received, including dropped ones.
+func (b *Bulb) Requests(method string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.requests[method]
+}
+
+// SetLossRate changes the fraction of requests silently dropped.
+func (b *Bulb) SetLossRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cfg.LossRate = rate
+}
+
+// SetLatency changes the delay applied before each reply.
+func (b *Bulb) SetLatency(latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cfg.Latency = latency
+}
+
+// SetPowerMilliwatts changes the wattage reported by getPower.
+func (b *Bulb) SetPowerMilliwatts(milliwatts int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cfg.PowerMilliwatts = milliwatts
+}
+
+// FailMethod makes the device answer method with an error object until cleared with code 0.
+func (b *Bulb) FailMethod(method string, code int, message string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if code == 0 {
+		delete(b.failures, method)
+		return
+	}
+	b.failures[method] = &wiz.DeviceError{Method: method, Code: code, Message: message}
+}
+
+// Registered reports whether a host has registered for syncPilot pushes.
+func (b *Bulb) Registered() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.subscriber != nil
+}
+
+// Apply changes the device state as the phone app or a wall switch would,
+// using setPilot params, and pushes the new state to a registered host.
+func (b *Bulb) Apply(params map[string]interface{}) error {
+	b.mu.Lock()
+	err := b.applyPilot(params)
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	b.push()
+	return nil
+}
+
+// push sends a syncPilot notification with the current state to the registered host.
+func (b *Bulb) push() {
+	b.mu.Lock()
+	subscriber := b.subscriber
+	params := b.pilotResult()
+	b.mu.Unlock()
+	if subscriber == nil {
+		return
+	}
+	params["src"] = "udp"
+	data, err := json.Marshal(map[string]interface{}{"method": "syncPilot", "env": "pro", "params": params})
+	if err != nil {
+		return
+	}
+	_, _ = b.conn.WriteToUDP(data, subscriber)
+}
+
+// serve answers requests until the socket is closed.
+func (b *Bulb) serve() {
+	defer close(b.done)
+	buffer := make([]byte, 4096)
+	for {
+		n, addr, err := b.conn.ReadFromUDP(buffer)
+		if err != nil {
+			return
+		}
+
+		var request struct {
+			ID     interface{}            `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := json.Unmarshal(buffer[:n], &request); err != nil {
+			continue
+		}
+
+		response, latency, drop := b.handle(request.Method, request.Params)
+		if drop {
+			continue
+		}
+		_, failed := response["error"]
+		if request.Method == "registration" && !failed {
+			b.register(addr, request.Params)
+		}
+		changed := !failed && (request.Method == "setState" || request.Method == "setPilot")
+		response["method"] = request.Method
+		if request.ID != nil {
+			response["id"] = request.ID
+		}
+		data, err := json.Marshal(response)
+		if err != nil {
+			continue
+		}
+
+		if latency > 0 {
+			go func(addr *net.UDPAddr) {
+				time.Sleep(latency)
+				_, _ = b.conn.WriteToUDP(data, addr)
+				if changed {
+					b.push()
+				}
+			}(addr)
+			continue
+		}
+		_, _ = b.conn.WriteToUDP(data, addr)
+		if changed {
+			b.push()
+		}
+	}
+}
+
+// register records or clears the host that receives syncPilot pushes.
+func (b *Bulb) register(addr *net.UDPAddr, params map[string]interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if register, _ := params["register"].(bool); register {
+		b.subscriber = addr
+	} else {
+		b.subscriber = nil
+	}
+}
+
+// handle applies one request to the simulated state and builds its response.
+func (b *Bulb) handle(method string, params map[string]interface{}) (map[string]interface{}, time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.requests[method]++
+	if b.cfg.LossRate > 0 && rand.Float64() < b.cfg.LossRate {
+		return nil, 0, true
+	}
+	latency := b.cfg.Latency
+
+	if failure, ok := b.failures[method]; ok {
+		return errorResponse(failure.Code, failure.Message), latency, false
+	}
+
+	switch method {
+	case "getPilot":
+		return map[string]interface{}{"result": b.pilotResult()}, latency, false
+	case "setState":
+		state, ok := params["state"].(bool)
+		if !ok {
+			return errorResponse(-32602, "Invalid params"), latency, false
+		}
+		b.cfg.Power = state
+		return successResponse(), latency, false
+	case "setPilot":
+		if err := b.applyPilot(params); err != nil {
+			return errorResponse(err.Code, err.Message), latency, false
+		}
+		return successResponse(), latency, false
+	case "registration":
+		if _, ok := params["phoneIp"].(string); !ok {
+			return errorResponse(-32602, "Invalid params"), latency, false
+		}
+		return map[string]interface{}{"result": map[string]interface{}{"mac": b.cfg.Mac, "success": true}}, latency, false
+	case "getSystemConfig":
+		result := map[string]interface{}{
+			"mac":        b.cfg.Mac,
+			"homeId":     0,
+			"moduleName": b.cfg.ModuleName,
+			"fwVersion":  b.cfg.FwVersion,
+		}
+		return map[string]interface{}{"result": result}, latency, false
+	case "getModelConfig":
+		if !b.caps.ColorTemp {
+			return errorResponse(-32601, "Method not found"), latency, false
+		}
+		result := map[string]interface{}{
+			"cctRange": []int{b.caps.MinKelvin, b.caps.MinKelvin, b.caps.MaxKelvin, b.caps.MaxKelvin},
+		}
+		return map[string]interface{}{"result": result}, latency, false
+	case "getPower":
+		if !b.caps.PowerMetering {
+			return errorResponse(-32601, "Method not found"), latency, false
+		}
+		return map[string]interface{}{"result": map[string]interface{}{"power": b.cfg.PowerMilliwatts}}, latency, false
+	default:
+		return errorResponse(-32601, "Method not found"), latency, false
+	}
+}
+
+// pilotResult renders the getPilot result for the current mode.
+func (b *Bulb) pilotResult() map[string]interface{} {
+	result := map[string]interface{}{
+		"mac":   b.cfg.Mac,
+		"rssi":  -55,
+		"state": b.cfg.Power,
+	}
+	if b.caps.Kind == wiz.KindPlug {
+		return result
+	}
+	result["dimming"] = b.cfg.Brightness
+	result["sceneId"] = b.cfg.SceneID
+	switch {
+	case b.cfg.SceneID > 0:
+		result["speed"] = b.cfg.Speed
+	case b.cfg.ColorHex != "":
+		r, g, bl, _ := wiz.HexToRGB(b.cfg.ColorHex)
+		result["r"], result["g"], result["b"] = r, g, bl
+	case b.cfg.Temp > 0:
+		result["temp"] = b.cfg.Temp
+	}
+	return result
+}
+
+// applyPilot validates setPilot params against the device capabilities and updates state.
+func (b *Bulb) applyPilot(params map[string]interface{}) *wiz.DeviceError {
+	invalid := &wiz.DeviceError{Method: "setPilot", Code: -32602, Message: "Invalid params"}
+	next := b.cfg
+
+	state, hasState := params["state"]
+	if hasState {
+		if _, isBool := state.(bool); !isBool {
+			return invalid
+		}
+	}
+
+	_, hasR := params["r"]
+	_, hasG := params["g"]
+	_, hasB := params["b"]
+	_, hasTemp := params["temp"]
+	_, hasScene := params["sceneId"]
+	_, hasDimming := params["dimming"]
+
+	if b.caps.Kind == wiz.KindPlug && (hasR || hasG || hasB || hasTemp || hasScene || hasDimming) {
+		return invalid
+	}
+
+	if hasDimming {
+		dimming := number(params["dimming"])
+		if dimming < 0 || dimming > 100 || !b.caps.Dimming {
+			return invalid
+		}
+		next.Brightness = dimming
+		next.Power = true
+	}
+	if hasR || hasG || hasB {
+		r, g, bl := number(params["r"]), number(params["g"]), number(params["b"])
+		if !b.caps.Color || !inByteRange(r) || !inByteRange(g) || !inByteRange(bl) {
+			return invalid
+		}
+		next.ColorHex = fmt.Sprintf("#%02X%02X%02X", r, g, bl)
+		next.Temp = 0
+		next.SceneID = 0
+		next.Power = true
+	}
+	if hasTemp {
+		temp := number(params["temp"])
+		if !b.caps.ColorTemp || temp < b.caps.MinKelvin || temp > b.caps.MaxKelvin {
+			return invalid
+		}
+		next.Temp = temp
+		next.ColorHex = ""
+		next.SceneID = 0
+		next.Power = true
+	}
+	if hasScene {
+		sceneID := number(params["sceneId"])
+		if !b.caps.SupportsScene(sceneID) {
+			return invalid
+		}
+		next.SceneID = sceneID
+		next.Power = true
+		if _, ok := params["speed"]; ok {
+			speed := number(params["speed"])
+			if speed < wiz.MinSceneSpeed || speed > wiz.MaxSceneSpeed {
+				return invalid
+			}
+			next.Speed = speed
+		}
+	}
+	// An explicit state wins, so a light can be switched off with a stored level.
+	if hasState {
+		next.Power = state.(bool)
+	}
+
+	b.cfg = next
+	return nil
+}
+
+func successResponse() map[string]interface{} {
+	return map[string]interface{}{"result": map[string]interface{}{"success": true}}
+}
+
+func errorResponse(code int, message string) map[string]interface{} {
+	return map[string]interface{}{"error": map[string]interface{}{"code": code, "message": message}}
+}
+
+func number(value interface{}) int {
+	switch typed := value.(type) {
+	case float64:
+		return int(typed)
+	case string:
+		parsed, err := strconv.Atoi(strings.TrimSpace(typed))
+		if err == nil {
+			return parsed
+		}
+	}
+	return -1
+}
+
+func inByteRange(value int) bool {
+	return value >= 0 && value <= 255
+}
@@ -0,0 +1,11 @@
+package wiztest
+
+// DemoFleet describes the simulated devices used by `lumina --demo`:
+// an RGB bulb, a tunable-white bulb, and a metering smart plug.
+func DemoFleet() []Config {
+	return []Config{
+		{ModuleName: "ESP01_SHRGB1C_31", Mac: "a8bb50000001", Power: true, Brightness: 80, ColorHex: "#CBA6F7"},
+		{ModuleName: "ESP01_SHTW1C_31", Mac: "a8bb50000002", Power: true, Brightness: 60, Temp: 2700},
+		{ModuleName: "ESP10_SOCKET_06", Mac: "a8bb50000003", Power: true, Metered: true, PowerMilliwatts: 7400},
+	}
+}
@@ -0,0 +1,185 @@
+package actions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/actions"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+func TestParseCompactForms(t *testing.T) {
+	cases := map[string]actions.Action{
+		"off":                          {Kind: actions.Off},
+		"sunrise":                      {Kind: actions.Sunrise},
+		"on,brightness=60":             {Kind: actions.On, Brightness: 60},
+		"color=#ff8800,brightness=40":  {Kind: actions.Color, ColorHex: "#FF8800", Brightness: 40},
+		"white=2700":                   {Kind: actions.White, Temp: 2700},
+		"scene=Ocean,speed=120":        {Kind: actions.Scene, Scene: "Ocean", Speed: 120},
+		"brightness=30":                {Kind: actions.Brightness, Brightness: 30},
+		"preset=Reading":               {Kind: actions.Preset, Preset: "Reading"},
+		`{"kind":"white","temp":3000}`: {Kind: actions.White, Temp: 3000},
+	}
+	for spec, want := range cases {
+		got, err := actions.Parse(spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", spec, err)
+		}
+		if got != want {
+			t.Fatalf("Parse(%q) = %+v, want %+v", spec, got, want)
+		}
+		again, err := actions.Parse(got.String())
+		if err != nil || again != got {
+			t.Fatalf("expected %q to round-trip through String, got %+v (%v)", spec, again, err)
+		}
+	}
+}
+
+func TestParseRejectsInvalidActions(t *testing.T) {
+	for _, spec := range []string{"", "dance", "color=#GGGGGG", "white=9000", "scene=Nowhere", "brightness=5", "preset="} {
+		if _, err := actions.Parse(spec); !errors.Is(err, actions.ErrInvalid) {
+			t.Fatalf("expected ErrInvalid for %q, got %v", spec, err)
+		}
+	}
+}
+
+func TestResolvePreset(t *testing.T) {
+	presets := []config.Preset{{Name: "Reading", Power: true, Brightness: 80, Temp: 4000}}
+
+	resolved, err := actions.Action{Kind: actions.Preset, Preset: "reading"}.Resolve(presets)
+	if err != nil {
+		t.Fatalf("expected preset to resolve, got %v", err)
+	}
+	if resolved != (actions.Action{Kind: actions.White, Temp: 4000, Brightness: 80}) {
+		t.Fatalf("unexpected resolved action: %+v", resolved)
+	}
+
+	if _, err := (actions.Action{Kind: actions.Preset, Preset: "Party"}).Resolve(presets); !errors.Is(err, actions.ErrInvalid) {
+		t.Fatalf("expected unknown preset to fail, got %v", err)
+	}
+}
+
+func TestRequestParams(t *testing.T) {
+	method, params, err := actions.Action{Kind: actions.Color, ColorHex: "#FF8800", Brightness: 40}.Request()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != "setPilot" || params["r"] != uint8(255) || params["g"] != uint8(136) || params["b"] != uint8(0) || params["dimming"] != 40 {
+		t.Fatalf("unexpected request: %s %v", method, params)
+	}
+
+	method, params, err = actions.Action{Kind: actions.Off}.Request()
+	if err != nil || method != "setState" || params["state"] != false {
+		t.Fatalf("unexpected off request: %s %v (%v)", method, params, err)
+	}
+}
+
+func TestRunAppliesActionToGroup(t *testing.T) {
+	bulbs, err := wiztest.StartFleet(wiztest.Config{}, wiztest.Config{})
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	})
+
+	cfg := config.Config{Port: bulbs[0].Port(), Groups: []config.Group{{Name: "Bedroom"}}}
+	for index, bulb := range bulbs {
+		cfg.SavedDevices = append(cfg.SavedDevices, config.SavedDevice{Name: string(rune('A' + index)), IP: bulb.IP(), Port: bulb.Port(), Mac: bulb.Mac()})
+		cfg.Groups[0].Members = append(cfg.Groups[0].Members, bulb.Mac())
+	}
+
+	targets, err := actions.Targets(cfg, "Bedroom", "", "")
+	if err != nil {
+		t.Fatalf("expected group targets, got %v", err)
+	}
+	if len(targets) != len(bulbs) {
+		t.Fatalf("expected %d targets, got %d", len(bulbs), len(targets))
+	}
+
+	presets := []config.Preset{{Name: "Sunset", Power: true, Brightness: 50, ColorHex: "#FF8800"}}
+	results, err := actions.Run(context.Background(), targets, actions.Action{Kind: actions.Preset, Preset: "Sunset"}, presets, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := wiz.JoinResults(results); err != nil {
+		t.Fatalf("expected every member to succeed, got %v", err)
+	}
+	for index, bulb := range bulbs {
+		state := bulb.State()
+		if !state.Power || state.ColorHex != "#FF8800" || state.Brightness != 50 {
+			t.Fatalf("bulb %d has unexpected state %+v", index, state)
+		}
+	}
+
+	if _, err := actions.Targets(cfg, "Kitchen", "", ""); err == nil {
+		t.Fatal("expected unknown group to fail")
+	}
+}
+
+func TestResolveByMACAcceptsFormattedMACs(t *testing.T) {
+	saved := []config.SavedDevice{
+		{Name: "Desk", IP: "192.168.1.10", Port: "38899", Mac: "A8:BB:50:00:00:01"},
+		{Name: "Hall", IP: "192.168.1.11", Mac: "a8bb50000002"},
+	}
+	discovered := []wiz.Device{{IP: "192.168.1.42", Port: "38899", Mac: "a8bb50000001"}}
+
+	resolved := actions.ResolveByMAC(saved, discovered)
+	if resolved[0].IP != "192.168.1.42" {
+		t.Fatalf("expected the colon MAC to follow the bulb to its new IP, got %+v", resolved[0])
+	}
+	if resolved[1].IP != "192.168.1.11" || saved[0].IP != "192.168.1.10" {
+		t.Fatalf("expected undiscovered devices and the input left untouched, got %+v %+v", resolved, saved)
+	}
+}
+
+func TestLeadSkipsActionsThatCannotFade(t *testing.T) {
+	presets := []config.Preset{{Name: "Party", Power: true, Scene: "Party"}, {Name: "Reading", Power: true, Brightness: 80}}
+	fade := 5 * time.Minute
+	cases := []struct {
+		action actions.Action
+		want   time.Duration
+	}{
+		{actions.Action{Kind: actions.Off}, fade},
+		{actions.Action{Kind: actions.Sunrise}, fade},
+		{actions.Action{Kind: actions.Preset, Preset: "Reading"}, fade},
+		{actions.Action{Kind: actions.Scene, Scene: "Ocean"}, 0},
+		{actions.Action{Kind: actions.Preset, Preset: "Party"}, 0},
+	}
+	for _, tc := range cases {
+		if got := actions.Lead(tc.action, presets, fade); got != tc.want {
+			t.Fatalf("Lead(%s) = %s, want %s", tc.action, got, tc.want)
+		}
+	}
+}
+
+func TestRunSendsSceneAtOnceDespiteFade(t *testing.T) {
+	bulb, err := wiztest.Start(wiztest.Config{Power: true, Brightness: 50})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	action := actions.Action{Kind: actions.Scene, Scene: "Ocean"}
+	fade := actions.Lead(action, nil, time.Minute)
+	started := time.Now()
+	results, err := actions.Run(context.Background(), []wiz.Target{{IP: bulb.IP(), Port: bulb.Port()}}, action, nil, fade)
+	if err == nil {
+		err = wiz.JoinResults(results)
+	}
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("expected scene to be sent at once, took %s", elapsed)
+	}
+	if scene, _ := wiz.SceneByName("Ocean"); bulb.State().SceneID != scene.ID {
+		t.Fatalf("expected Ocean scene applied, got %+v", bulb.State())
+	}
+}
@@ -0,0 +1,150 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/api"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+// streamEvents connects to /events and delivers each decoded event.
+func streamEvents(t *testing.T, url string) <-chan api.Event {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	events := make(chan api.Event, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var event api.Event
+			if json.Unmarshal([]byte(data), &event) == nil {
+				events <- event
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan api.Event, match func(api.Event) bool) api.Event {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("event stream closed")
+			}
+			if match(event) {
+				return event
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
+
+func TestEventsStreamStateChanges(t *testing.T) {
+	server, bulbs := startAPI(t)
+	events := streamEvents(t, server.URL+"/events?mac="+bulbs[0].Mac())
+
+	first := nextEvent(t, events, func(api.Event) bool { return true })
+	if first.Mac != bulbs[0].Mac() || !first.Reachable || first.State == nil || first.State.Brightness != 80 {
+		t.Fatalf("expected the desk lamp's initial state, got %+v", first)
+	}
+
+	request(t, http.MethodPost, server.URL+"/devices/"+bulbs[0].Mac()+"/pilot", `{"dimming": 25}`, nil)
+	changed := nextEvent(t, events, func(event api.Event) bool { return event.State != nil && event.State.Brightness == 25 })
+	if changed.Mac != bulbs[0].Mac() || changed.Target.Name != "Desk lamp" {
+		t.Fatalf("unexpected change event %+v", changed)
+	}
+
+	_ = bulbs[0].Close()
+	gone := nextEvent(t, events, func(event api.Event) bool { return !event.Reachable })
+	if gone.State != nil || gone.Error == "" {
+		t.Fatalf("expected an unreachable event without state, got %+v", gone)
+	}
+}
+
+func TestEventsFilterByGroup(t *testing.T) {
+	server, bulbs := startAPI(t)
+
+	if status := request(t, http.MethodGet, server.URL+"/events?group=Attic", "", nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown group, got %d", status)
+	}
+
+	events := streamEvents(t, server.URL+"/events?group=desk")
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		event := nextEvent(t, events, func(api.Event) bool { return true })
+		seen[event.Mac] = true
+	}
+	if !seen[bulbs[0].Mac()] || !seen[bulbs[1].Mac()] || seen[bulbs[2].Mac()] {
+		t.Fatalf("expected only the desk group's devices, got %v", seen)
+	}
+}
+
+func TestEventsReportDevicesSavedAtTheSameAddress(t *testing.T) {
+	bulbs, err := wiztest.StartFleet(wiztest.DemoFleet()...)
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	defer func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	}()
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout:      50 * time.Millisecond,
+		Attempts:         2,
+		Backoff:          func(int) time.Duration { return 0 },
+		DiscoveryTargets: wiztest.DiscoveryTargets(bulbs),
+		DiscoveryWindow:  200 * time.Millisecond,
+	})
+	defer client.Close()
+
+	// The same lamp saved twice, e.g. before and after a module swap, polls
+	// as two identical targets.
+	cfg := config.Config{SavedDevices: []config.SavedDevice{
+		{Name: "Desk lamp", IP: bulbs[0].IP(), Port: bulbs[0].Port(), Mac: bulbs[0].Mac()},
+		{Name: "Desk lamp", IP: bulbs[0].IP(), Port: bulbs[0].Port(), Mac: "a8bb500000aa"},
+	}}
+	server := httptest.NewServer((&api.Server{
+		Config:       func() (config.Config, error) { return cfg, nil },
+		Client:       client,
+		PollInterval: 20 * time.Millisecond,
+	}).Handler())
+	// Registered before the stream so the stream closes first.
+	t.Cleanup(server.Close)
+
+	events := streamEvents(t, server.URL+"/events")
+	seen := map[string]api.Event{}
+	for len(seen) < 2 {
+		event := nextEvent(t, events, func(api.Event) bool { return true })
+		seen[event.Mac] = event
+	}
+	for mac, event := range seen {
+		if !event.Reachable || event.State == nil || event.State.Brightness != 80 {
+			t.Fatalf("expected %s to carry the lamp's real state, got %+v", mac, event)
+		}
+	}
+}
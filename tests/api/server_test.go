@@ -0,0 +1,212 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/api"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+// startAPI serves the demo fleet. The first two bulbs are saved in a
+// "Desk" group; the desk lamp's saved port is stale so requests only reach it
+// if the server resolves it by MAC.
+func startAPI(t *testing.T) (*httptest.Server, []*wiztest.Bulb) {
+	t.Helper()
+	bulbs, err := wiztest.StartFleet(wiztest.DemoFleet()...)
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	})
+
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout:      50 * time.Millisecond,
+		Attempts:         2,
+		Backoff:          func(int) time.Duration { return 0 },
+		DiscoveryTargets: wiztest.DiscoveryTargets(bulbs),
+		DiscoveryWindow:  200 * time.Millisecond,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	cfg := config.Config{
+		SavedDevices: []config.SavedDevice{
+			{Name: "Desk lamp", IP: "127.0.0.1", Port: "1", Mac: bulbs[0].Mac()},
+			{Name: "Shelf", IP: bulbs[1].IP(), Port: bulbs[1].Port(), Mac: bulbs[1].Mac()},
+		},
+		Groups: []config.Group{{Name: "Desk", Members: []string{bulbs[0].Mac(), bulbs[1].Mac()}}},
+	}
+	server := &api.Server{
+		Config:       func() (config.Config, error) { return cfg, nil },
+		Client:       client,
+		PollInterval: 20 * time.Millisecond,
+	}
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+	return httpServer, bulbs
+}
+
+func request(t *testing.T, method, url, body string, into interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if into != nil {
+		if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+			t.Fatalf("failed to decode %s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestListDevicesMergesSavedAndDiscovered(t *testing.T) {
+	server, bulbs := startAPI(t)
+
+	var devices []api.Device
+	if status := request(t, http.MethodGet, server.URL+"/devices", "", &devices); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if len(devices) != 3 {
+		t.Fatalf("expected two saved devices and one unsaved plug, got %+v", devices)
+	}
+	desk := devices[0]
+	if desk.Name != "Desk lamp" || !desk.Saved || !desk.Online || desk.Port != bulbs[0].Port() {
+		t.Fatalf("expected the desk lamp at its discovered address, got %+v", desk)
+	}
+	if len(desk.Groups) != 1 || desk.Groups[0] != "Desk" || desk.Capabilities.Kind != wiz.KindRGB {
+		t.Fatalf("expected group and capabilities on the desk lamp, got %+v", desk)
+	}
+	if plug := devices[2]; plug.Saved || !plug.Online || plug.Mac != bulbs[2].Mac() {
+		t.Fatalf("expected the unsaved plug last, got %+v", plug)
+	}
+}
+
+func TestDevicePilotAndStateByMAC(t *testing.T) {
+	server, bulbs := startAPI(t)
+	base := server.URL + "/devices/" + bulbs[0].Mac()
+
+	var outcome wiz.Outcome
+	status := request(t, http.MethodPost, base+"/pilot", `{"r": 255, "g": 136, "b": 0, "dimming": 40}`, &outcome)
+	if status != http.StatusOK || !outcome.OK || outcome.Target.Name != "Desk lamp" {
+		t.Fatalf("expected pilot to succeed, got %d %+v", status, outcome)
+	}
+	if state := bulbs[0].State(); state.Brightness != 40 || state.ColorHex != "#FF8800" {
+		t.Fatalf("expected bulb to be orange at 40%%, got %+v", state)
+	}
+
+	outcome = wiz.Outcome{}
+	if status := request(t, http.MethodGet, base+"/state", "", &outcome); status != http.StatusOK || outcome.State == nil {
+		t.Fatalf("expected state, got %d %+v", status, outcome)
+	}
+	if outcome.State.Brightness != 40 || outcome.State.ColorHex != "#FF8800" {
+		t.Fatalf("unexpected state %+v", outcome.State)
+	}
+
+	// Dotted MACs are accepted as on the CLI.
+	mac := bulbs[0].Mac()
+	dotted := server.URL + "/devices/" + mac[0:4] + "." + mac[4:8] + "." + mac[8:12] + "/state"
+	if status := request(t, http.MethodGet, dotted, "", nil); status != http.StatusOK {
+		t.Fatalf("expected a dotted MAC to resolve, got %d", status)
+	}
+}
+
+func TestDeviceErrors(t *testing.T) {
+	server, _ := startAPI(t)
+
+	var body map[string]string
+	if status := request(t, http.MethodGet, server.URL+"/devices/a8bb500000ff/state", "", &body); status != http.StatusNotFound || body["error"] == "" {
+		t.Fatalf("expected 404 for an unknown MAC, got %d %v", status, body)
+	}
+	if status := request(t, http.MethodGet, server.URL+"/devices/kitchen/state", "", nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed MAC, got %d", status)
+	}
+	if status := request(t, http.MethodPost, server.URL+"/devices/a8bb50000001/pilot", `{}`, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty params, got %d", status)
+	}
+	if status := request(t, http.MethodPost, server.URL+"/groups/Attic/pilot", `{"state": false}`, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown group, got %d", status)
+	}
+}
+
+func TestGroupPilotReportsEachMember(t *testing.T) {
+	server, bulbs := startAPI(t)
+	bulbs[1].FailMethod("setPilot", -32602, "Invalid params")
+
+	var outcomes []wiz.Outcome
+	status := request(t, http.MethodPost, server.URL+"/groups/desk/pilot", `{"state": false}`, &outcomes)
+	if status != http.StatusMultiStatus || len(outcomes) != 2 {
+		t.Fatalf("expected 207 with two outcomes, got %d %+v", status, outcomes)
+	}
+	if !outcomes[0].OK || outcomes[1].OK || outcomes[1].Error == "" {
+		t.Fatalf("expected only the shelf to fail, got %+v", outcomes)
+	}
+	if bulbs[0].State().Power {
+		t.Fatal("expected the desk lamp to be switched off")
+	}
+}
+
+func TestSavedColonMACFollowsMovedBulb(t *testing.T) {
+	bulbs, err := wiztest.StartFleet(wiztest.DemoFleet()...)
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	defer func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	}()
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout:      50 * time.Millisecond,
+		Attempts:         2,
+		Backoff:          func(int) time.Duration { return 0 },
+		DiscoveryTargets: wiztest.DiscoveryTargets(bulbs),
+		DiscoveryWindow:  200 * time.Millisecond,
+	})
+	defer client.Close()
+
+	// The desk lamp was saved by hand with an uppercase, colon-separated MAC
+	// and has since moved away from its saved address.
+	mac := bulbs[0].Mac()
+	colons := strings.ToUpper(strings.Join([]string{mac[0:2], mac[2:4], mac[4:6], mac[6:8], mac[8:10], mac[10:12]}, ":"))
+	cfg := config.Config{
+		SavedDevices: []config.SavedDevice{{Name: "Desk lamp", IP: "127.0.0.1", Port: "1", Mac: colons}},
+		Groups:       []config.Group{{Name: "Desk", Members: []string{colons}}},
+	}
+	server := httptest.NewServer((&api.Server{
+		Config: func() (config.Config, error) { return cfg, nil },
+		Client: client,
+	}).Handler())
+	defer server.Close()
+
+	var outcome wiz.Outcome
+	if status := request(t, http.MethodGet, server.URL+"/devices/"+mac+"/state", "", &outcome); status != http.StatusOK || !outcome.OK {
+		t.Fatalf("expected the moved lamp to answer at its discovered address, got %d %+v", status, outcome)
+	}
+	if outcome.Target.Port != bulbs[0].Port() {
+		t.Fatalf("expected the discovered port %s, got %+v", bulbs[0].Port(), outcome.Target)
+	}
+
+	var outcomes []wiz.Outcome
+	status := request(t, http.MethodPost, server.URL+"/groups/desk/pilot", `{"state": false}`, &outcomes)
+	if status != http.StatusOK || len(outcomes) != 1 || !outcomes[0].OK {
+		t.Fatalf("expected the group to reach its colon-MAC member, got %d %+v", status, outcomes)
+	}
+	if bulbs[0].State().Power {
+		t.Fatal("expected the desk lamp to be switched off")
+	}
+}
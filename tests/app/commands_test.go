@@ -0,0 +1,230 @@
+package app_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/app"
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/timers"
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+// startFleet runs two simulated bulbs saved as "Desk" and "Hall", with an
+// isolated config and a fast default client that discovers only them.
+func startFleet(t *testing.T) []*wiztest.Bulb {
+	t.Helper()
+	bulbs, err := wiztest.StartFleet(
+		wiztest.Config{Mac: "a8bb50000011", Power: false, Brightness: 50},
+		wiztest.Config{Mac: "a8bb50000012", Power: false, Brightness: 50},
+	)
+	if err != nil {
+		t.Fatalf("failed to start simulated bulbs: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	})
+
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout:      50 * time.Millisecond,
+		Attempts:         2,
+		Backoff:          func(int) time.Duration { return 0 },
+		DiscoveryTargets: wiztest.DiscoveryTargets(bulbs),
+		DiscoveryWindow:  200 * time.Millisecond,
+	})
+	previous := wiz.SetDefault(client)
+	t.Cleanup(func() {
+		wiz.SetDefault(previous)
+		_ = client.Close()
+	})
+
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	cfg := config.Config{
+		IP:   bulbs[0].IP(),
+		Port: bulbs[0].Port(),
+		SavedDevices: []config.SavedDevice{
+			{Name: "Desk", IP: bulbs[0].IP(), Port: bulbs[0].Port(), Mac: bulbs[0].Mac()},
+			{Name: "Hall", IP: bulbs[1].IP(), Port: bulbs[1].Port(), Mac: bulbs[1].Mac()},
+		},
+		Groups: []config.Group{{Name: "Office", Members: []string{bulbs[0].Mac(), bulbs[1].Mac()}}},
+	}
+	if err := config.Save(cfg); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	return bulbs
+}
+
+// run invokes a subcommand and returns its exit code and output.
+func run(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code, ok := app.Command(args, &stdout, &stderr)
+	if !ok {
+		t.Fatalf("expected %q to be a subcommand", args[0])
+	}
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommandResolvesNameMACAndAddress(t *testing.T) {
+	bulbs := startFleet(t)
+
+	// The hall bulb is addressed by a colon-separated MAC, the desk by name.
+	mac := bulbs[1].Mac()
+	colons := strings.Join([]string{mac[0:2], mac[2:4], mac[4:6], mac[6:8], mac[8:10], mac[10:12]}, ":")
+	code, _, stderr := run(t, "on", "desk", strings.ToUpper(colons))
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	for index, bulb := range bulbs {
+		if !bulb.State().Power {
+			t.Fatalf("expected bulb %d switched on", index)
+		}
+	}
+
+	code, stdout, stderr := run(t, "off", bulbs[0].Addr())
+	if code != 0 || bulbs[0].State().Power || !strings.HasPrefix(stdout, "Desk: off") {
+		t.Fatalf("expected the address to resolve to the saved desk bulb, got %d %q %q", code, stdout, stderr)
+	}
+}
+
+func TestCommandAcceptsFlagsBetweenDevices(t *testing.T) {
+	bulbs := startFleet(t)
+
+	code, stdout, stderr := run(t, "set", "Desk", "--brightness", "40", "Hall", "--output", "ndjson")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	for index, bulb := range bulbs {
+		if state := bulb.State(); state.Brightness != 40 {
+			t.Fatalf("expected bulb %d at 40%%, got %+v", index, state)
+		}
+	}
+
+	var lines []wiz.Outcome
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var outcome wiz.Outcome
+		if err := json.Unmarshal(scanner.Bytes(), &outcome); err != nil {
+			t.Fatalf("expected one JSON object per line, got %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, outcome)
+	}
+	if len(lines) != 2 || !lines[0].OK || !lines[1].OK || lines[0].Target.Name == lines[1].Target.Name {
+		t.Fatalf("expected one ndjson line per device, got %+v", lines)
+	}
+}
+
+func TestCommandJSONOutputIsOneDocument(t *testing.T) {
+	startFleet(t)
+
+	code, stdout, stderr := run(t, "get", "--group", "Office", "--output", "json")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	var outcomes []wiz.Outcome
+	if err := json.Unmarshal([]byte(stdout), &outcomes); err != nil {
+		t.Fatalf("expected a JSON array, got %q: %v", stdout, err)
+	}
+	if len(outcomes) != 2 || outcomes[0].State == nil || outcomes[0].State.Brightness != 50 {
+		t.Fatalf("expected both states in one document, got %+v", outcomes)
+	}
+}
+
+func TestCommandExitCodes(t *testing.T) {
+	bulbs := startFleet(t)
+
+	cases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"success", []string{"on", "--group", "Office"}, 0},
+		{"help", []string{"on", "-h"}, 0},
+		{"serve help", []string{"serve", "-h"}, 0},
+		{"daemon help", []string{"daemon", "-h"}, 0},
+		{"unknown flag", []string{"on", "--bogus"}, 2},
+		{"bad output format", []string{"on", "--output", "yaml"}, 2},
+		{"conflicting set modes", []string{"set", "--color", "#FF0000", "--temp", "2700"}, 2},
+		{"nothing to set", []string{"set", "Desk"}, 2},
+		{"unknown device", []string{"on", "nowhere"}, 2},
+		{"unknown group", []string{"on", "--group", "Attic"}, 2},
+		{"MAC not on the network", []string{"on", "aa:bb:cc:dd:ee:ff"}, 1},
+	}
+	for _, tc := range cases {
+		if code, _, stderr := run(t, tc.args...); code != tc.want {
+			t.Fatalf("%s: expected exit %d, got %d: %s", tc.name, tc.want, code, stderr)
+		}
+	}
+
+	bulbs[1].FailMethod("setState", -32601, "Method not found")
+	if code, _, _ := run(t, "off", "--group", "Office"); code != 3 {
+		t.Fatalf("expected exit 3 when one device fails, got %d", code)
+	}
+	bulbs[0].FailMethod("setState", -32601, "Method not found")
+	if code, _, _ := run(t, "off", "--group", "Office"); code != 1 {
+		t.Fatalf("expected exit 1 when every device fails, got %d", code)
+	}
+}
+
+func TestMQTTHelpDoesNotPrintPassword(t *testing.T) {
+	t.Setenv("LUMINA_MQTT_PASSWORD", "hunter2")
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"mqtt", "-h"}, 0},
+		{[]string{"mqtt", "--bogus"}, 2},
+	} {
+		code, _, stderr := run(t, tc.args...)
+		if code != tc.want || strings.Contains(stderr, "hunter2") {
+			t.Fatalf("%v: expected exit %d and usage without the password, got %d %q", tc.args, tc.want, code, stderr)
+		}
+	}
+}
+
+func TestTimersCancelAcceptsOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on Unix process semantics")
+	}
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	worker := exec.Command("sleep", "30")
+	if err := worker.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	go func() { _ = worker.Wait() }()
+	if err := timers.Add(timers.Timer{ID: "c0ffee42", PID: worker.Process.Pid, Action: "off", Deadline: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"timers", "cancel"}, 2},
+		{[]string{"timers", "cancel", "c0ff", "--output", "yaml"}, 2},
+		{[]string{"timers", "cancel", "ffff", "--output", "json"}, 1},
+	} {
+		if code, _, stderr := run(t, tc.args...); code != tc.want {
+			t.Fatalf("%v: expected exit %d, got %d: %s", tc.args, tc.want, code, stderr)
+		}
+	}
+
+	code, stdout, stderr := run(t, "timers", "cancel", "c0ff", "--output", "json")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	var cancelled []timers.Timer
+	if err := json.Unmarshal([]byte(stdout), &cancelled); err != nil || len(cancelled) != 1 || cancelled[0].ID != "c0ffee42" {
+		t.Fatalf("expected the cancelled timer as JSON, got %q: %v", stdout, err)
+	}
+}
@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"wiz-tui/internal/config"
+)
+
+func TestGroupMembersResolvesSavedDevicesByMAC(t *testing.T) {
+	cfg := config.Config{
+		Port: "38899",
+		SavedDevices: []config.SavedDevice{
+			{Name: "Desk", IP: "192.168.1.10", Mac: "A8BB50000001"},
+			{Name: "Hall", IP: "192.168.1.11", Port: "38900", Mac: "a8bb50000002"},
+		},
+		Groups: []config.Group{{Name: "Downstairs", Members: []string{"a8:bb:50:00:00:01", "A8BB50000002", "missing"}}},
+	}
+
+	group, ok := cfg.FindGroup("downstairs")
+	if !ok {
+		t.Fatal("expected case-insensitive group lookup")
+	}
+	members := cfg.GroupMembers(group)
+	if len(members) != 2 {
+		t.Fatalf("expected two resolved members, got %+v", members)
+	}
+	if members[0].Name != "Desk" || members[0].Port != "38899" {
+		t.Fatalf("expected Desk to inherit default port, got %+v", members[0])
+	}
+	if members[1].Name != "Hall" || members[1].Port != "38900" {
+		t.Fatalf("expected Hall to keep its own port, got %+v", members[1])
+	}
+}
+
+func TestSaveAndLoadRoundTripsGroups(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	want := config.Config{
+		IP:           "192.168.1.10",
+		Port:         "38899",
+		SavedDevices: []config.SavedDevice{{Name: "Desk", IP: "192.168.1.10", Port: "38899", Mac: "a8bb50000001"}},
+		Groups:       []config.Group{{Name: "Office", Members: []string{"a8bb50000001"}}},
+		ActiveGroup:  "Office",
+	}
+	if err := config.Save(want); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	got, err := config.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !reflect.DeepEqual(got.Groups, want.Groups) || got.ActiveGroup != want.ActiveGroup {
+		t.Fatalf("expected groups to round-trip, got %+v", got)
+	}
+}
+
+func TestNormalizeMACAcceptsCommonSeparators(t *testing.T) {
+	for _, text := range []string{"a8bb50000001", "A8:BB:50:00:00:01", "a8-bb-50-00-00-01", "a8bb.5000.0001", " a8bb50000001 "} {
+		if mac := config.NormalizeMAC(text); mac != "a8bb50000001" {
+			t.Fatalf("NormalizeMAC(%q) = %q, want a8bb50000001", text, mac)
+		}
+	}
+	for _, text := range []string{"", "kitchen", "a8bb5000000", "a8bb5000000g", "192.168.1.10"} {
+		if mac := config.NormalizeMAC(text); mac != "" {
+			t.Fatalf("NormalizeMAC(%q) = %q, want empty", text, mac)
+		}
+	}
+}
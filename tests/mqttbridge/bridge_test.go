@@ -0,0 +1,254 @@
+package mqttbridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/mqttbridge"
+	"wiz-tui/internal/mqtttest"
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+// startBridge bridges the demo fleet to an in-process broker. The desk lamp
+// is saved with a stale port so it is only reachable once resolved by MAC.
+func startBridge(t *testing.T) (*mqtttest.Broker, []*wiztest.Bulb, context.CancelFunc) {
+	t.Helper()
+	broker, err := mqtttest.StartBroker()
+	if err != nil {
+		t.Fatalf("failed to start broker: %v", err)
+	}
+	t.Cleanup(func() { _ = broker.Close() })
+
+	bulbs, err := wiztest.StartFleet(wiztest.DemoFleet()...)
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	})
+
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout:      50 * time.Millisecond,
+		Attempts:         2,
+		Backoff:          func(int) time.Duration { return 0 },
+		DiscoveryTargets: wiztest.DiscoveryTargets(bulbs),
+		DiscoveryWindow:  200 * time.Millisecond,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	cfg := config.Config{SavedDevices: []config.SavedDevice{
+		{Name: "Desk lamp", IP: "127.0.0.1", Port: "1", Mac: bulbs[0].Mac()},
+	}}
+	bridge := &mqttbridge.Bridge{
+		Client:         client,
+		Config:         func() (config.Config, error) { return cfg, nil },
+		Broker:         broker.URL(),
+		PollInterval:   20 * time.Millisecond,
+		RescanInterval: time.Hour,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- bridge.Run(ctx) }()
+	stop := func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("bridge failed: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		if ctx.Err() == nil {
+			stop()
+		}
+	})
+	return broker, bulbs, stop
+}
+
+// waitRetained decodes the retained message on topic into v once match accepts it.
+func waitRetained(t *testing.T, broker *mqtttest.Broker, topic string, v interface{}, match func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if payload, ok := broker.Retained(topic); ok && json.Unmarshal(payload, v) == nil && match() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	payload, _ := broker.Retained(topic)
+	t.Fatalf("timed out waiting for %s, last retained %q", topic, payload)
+}
+
+func TestBridgeAnnouncesLightsToHomeAssistant(t *testing.T) {
+	broker, bulbs, _ := startBridge(t)
+
+	var desk mqttbridge.DiscoveryConfig
+	waitRetained(t, broker, "homeassistant/light/lumina_"+bulbs[0].Mac()+"/config", &desk, func() bool { return desk.UniqueID != "" })
+	if desk.Schema != "json" || desk.Device.Name != "Desk lamp" || desk.CommandTopic != "lumina/"+bulbs[0].Mac()+"/set" {
+		t.Fatalf("unexpected desk lamp config %+v", desk)
+	}
+	if !slices.Equal(desk.SupportedColorModes, []string{"color_temp", "rgb"}) || !desk.Brightness || !slices.Contains(desk.EffectList, "Ocean") {
+		t.Fatalf("expected an RGB light with brightness and scenes, got %+v", desk)
+	}
+
+	var shelf mqttbridge.DiscoveryConfig
+	waitRetained(t, broker, "homeassistant/light/lumina_"+bulbs[1].Mac()+"/config", &shelf, func() bool { return shelf.UniqueID != "" })
+	if !slices.Equal(shelf.SupportedColorModes, []string{"color_temp"}) || slices.Contains(shelf.EffectList, "Ocean") || shelf.MinKelvin == 0 {
+		t.Fatalf("expected a tunable white light without color scenes, got %+v", shelf)
+	}
+
+	var plug mqttbridge.DiscoveryConfig
+	waitRetained(t, broker, "homeassistant/light/lumina_"+bulbs[2].Mac()+"/config", &plug, func() bool { return plug.UniqueID != "" })
+	if !slices.Equal(plug.SupportedColorModes, []string{"onoff"}) || plug.Brightness {
+		t.Fatalf("expected an on/off plug, got %+v", plug)
+	}
+
+	if status, _ := broker.Retained("lumina/status"); string(status) != "online" {
+		t.Fatalf("expected the bridge to be online, got %q", status)
+	}
+}
+
+func TestBridgePublishesRetainedState(t *testing.T) {
+	broker, bulbs, _ := startBridge(t)
+
+	var desk mqttbridge.LightState
+	waitRetained(t, broker, "lumina/"+bulbs[0].Mac()+"/state", &desk, func() bool { return desk.State != "" })
+	if desk.State != "ON" || desk.Brightness != 80 || desk.ColorMode != "rgb" || desk.Color == nil {
+		t.Fatalf("unexpected desk lamp state %+v", desk)
+	}
+
+	var shelf mqttbridge.LightState
+	waitRetained(t, broker, "lumina/"+bulbs[1].Mac()+"/state", &shelf, func() bool { return shelf.State != "" })
+	if shelf.ColorMode != "color_temp" || shelf.ColorTemp != 2700 {
+		t.Fatalf("unexpected shelf state %+v", shelf)
+	}
+	if availability, _ := broker.Retained("lumina/" + bulbs[1].Mac() + "/availability"); string(availability) != "online" {
+		t.Fatalf("expected the shelf to be available, got %q", availability)
+	}
+}
+
+func TestBridgeAppliesCommands(t *testing.T) {
+	broker, bulbs, _ := startBridge(t)
+	topic := "lumina/" + bulbs[0].Mac()
+
+	var state mqttbridge.LightState
+	waitRetained(t, broker, topic+"/state", &state, func() bool { return state.State != "" })
+
+	broker.Publish(topic+"/set", []byte(`{"state": "ON", "color": {"r": 255, "g": 136, "b": 0}, "brightness": 40}`), false)
+	waitRetained(t, broker, topic+"/state", &state, func() bool { return state.Brightness == 40 })
+	if got := bulbs[0].State(); got.ColorHex != "#FF8800" || got.Brightness != 40 {
+		t.Fatalf("expected the bulb to be orange at 40%%, got %+v", got)
+	}
+
+	broker.Publish(topic+"/set", []byte(`{"state": "ON", "effect": "Ocean"}`), false)
+	waitRetained(t, broker, topic+"/state", &state, func() bool { return state.Effect == "Ocean" })
+
+	broker.Publish(topic+"/set", []byte(`{"state": "OFF"}`), false)
+	waitRetained(t, broker, topic+"/state", &state, func() bool { return state.State == "OFF" })
+	if bulbs[0].State().Power {
+		t.Fatal("expected the bulb to be off")
+	}
+}
+
+func TestBridgeGoesOfflineOnShutdown(t *testing.T) {
+	broker, bulbs, stop := startBridge(t)
+
+	var state mqttbridge.LightState
+	waitRetained(t, broker, "lumina/"+bulbs[0].Mac()+"/state", &state, func() bool { return state.State != "" })
+	stop()
+	if status, _ := broker.Retained("lumina/status"); string(status) != "offline" {
+		t.Fatalf("expected the bridge to be offline, got %q", status)
+	}
+}
+
+func TestBridgeProbesUnresponsiveDevicesOncePerRescan(t *testing.T) {
+	broker, err := mqtttest.StartBroker()
+	if err != nil {
+		t.Fatalf("failed to start broker: %v", err)
+	}
+	t.Cleanup(func() { _ = broker.Close() })
+
+	bulbs, err := wiztest.StartFleet(wiztest.DemoFleet()[:2]...)
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	})
+	// The desk lamp is saved but missed discovery, and never answers a probe.
+	bulbs[0].FailMethod("getSystemConfig", -32601, "Method not found")
+
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout:      50 * time.Millisecond,
+		Attempts:         2,
+		Backoff:          func(int) time.Duration { return 0 },
+		DiscoveryTargets: []string{bulbs[1].Addr()},
+		DiscoveryWindow:  200 * time.Millisecond,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	cfg := config.Config{SavedDevices: []config.SavedDevice{
+		{Name: "Desk lamp", IP: bulbs[0].IP(), Port: bulbs[0].Port(), Mac: bulbs[0].Mac()},
+		{Name: "Unplugged", IP: "127.0.0.1", Port: "1", Mac: "a8bb500000ff"},
+	}}
+	bridge := &mqttbridge.Bridge{
+		Client:         client,
+		Config:         func() (config.Config, error) { return cfg, nil },
+		Broker:         broker.URL(),
+		PollInterval:   20 * time.Millisecond,
+		RescanInterval: time.Hour,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- bridge.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var desk mqttbridge.LightState
+	waitRetained(t, broker, "lumina/"+bulbs[0].Mac()+"/state", &desk, func() bool { return desk.State != "" })
+	time.Sleep(200 * time.Millisecond)
+	if probes := bulbs[0].Requests("getSystemConfig"); probes != 1 {
+		t.Fatalf("expected one probe until the next rescan, got %d", probes)
+	}
+}
+
+func TestCommandAction(t *testing.T) {
+	tunable := wiz.CapabilitiesFromModule("ESP01_SHTW1C_31")
+	tests := []struct {
+		name    string
+		command mqttbridge.Command
+		want    string
+		fade    time.Duration
+		wantErr bool
+	}{
+		{name: "off", command: mqttbridge.Command{State: "OFF", Transition: 2}, want: "off", fade: 2 * time.Second},
+		{name: "brightness raised to the minimum", command: mqttbridge.Command{State: "ON", Brightness: 3}, want: "on,brightness=10"},
+		{name: "color temperature clamped", command: mqttbridge.Command{ColorTemp: 9000}, want: "white=" + strconv.Itoa(tunable.MaxKelvin)},
+		{name: "unknown effect", command: mqttbridge.Command{Effect: "Disco"}, wantErr: true},
+		{name: "bad state", command: mqttbridge.Command{State: "DIM"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, fade, err := tt.command.Action(tunable)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", action)
+				}
+				return
+			}
+			if err != nil || action.String() != tt.want || fade != tt.fade {
+				t.Fatalf("expected %q over %s, got %q over %s (%v)", tt.want, tt.fade, action, fade, err)
+			}
+		})
+	}
+}
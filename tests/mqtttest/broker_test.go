@@ -0,0 +1,47 @@
+package mqtttest_test
+
+import (
+	"testing"
+
+	"wiz-tui/internal/mqtttest"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"lumina/+/set", "lumina/a8bb50000001/set", true},
+		{"lumina/+/set", "lumina/a8bb50000001/state", false},
+		{"lumina/#", "lumina/status", true},
+		{"lumina/+", "lumina/a8bb50000001/set", false},
+		{"homeassistant/status", "homeassistant/status", true},
+	}
+	for _, tt := range tests {
+		if got := mqtttest.Match(tt.filter, tt.topic); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.filter, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestBrokerKeepsRetainedMessages(t *testing.T) {
+	broker, err := mqtttest.StartBroker()
+	if err != nil {
+		t.Fatalf("failed to start broker: %v", err)
+	}
+	defer broker.Close()
+
+	broker.Publish("lumina/status", []byte("online"), true)
+	broker.Publish("lumina/a8bb50000001/set", []byte(`{"state": "ON"}`), false)
+	if payload, ok := broker.Retained("lumina/status"); !ok || string(payload) != "online" {
+		t.Fatalf("expected retained status, got %q", payload)
+	}
+	if messages := broker.RetainedMatching("lumina/#"); len(messages) != 1 {
+		t.Fatalf("expected only the retained message, got %+v", messages)
+	}
+
+	broker.Publish("lumina/status", nil, true)
+	if _, ok := broker.Retained("lumina/status"); ok {
+		t.Fatal("expected an empty retained publish to clear the topic")
+	}
+}
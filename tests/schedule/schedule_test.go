@@ -0,0 +1,208 @@
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/schedule"
+)
+
+// 2026-10-16 is a Friday.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestDailySpecSkipsWeekends(t *testing.T) {
+	spec, err := schedule.ParseDaily("07:00", "weekdays")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next := spec.Next(at(16, 6, 30)); !next.Equal(at(16, 7, 0)) {
+		t.Fatalf("expected Friday 07:00, got %v", next)
+	}
+	if next := spec.Next(at(16, 7, 0)); !next.Equal(at(19, 7, 0)) {
+		t.Fatalf("expected Monday 07:00 after Friday's run, got %v", next)
+	}
+}
+
+func TestCronSpec(t *testing.T) {
+	spec, err := schedule.ParseCron("30 22,23 * * sat,sun")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next := spec.Next(at(16, 12, 0)); !next.Equal(at(17, 22, 30)) {
+		t.Fatalf("expected Saturday 22:30, got %v", next)
+	}
+	if next := spec.Next(at(17, 22, 30)); !next.Equal(at(17, 23, 30)) {
+		t.Fatalf("expected Saturday 23:30, got %v", next)
+	}
+
+	stepped, err := schedule.ParseCron("*/20 * * * *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next := stepped.Next(at(16, 9, 41)); !next.Equal(at(16, 10, 0)) {
+		t.Fatalf("expected 10:00, got %v", next)
+	}
+
+	for _, expr := range []string{"", "0 7 * *", "61 * * * *", "0 7 * * funday", "0 9-7 * * *"} {
+		if _, err := schedule.ParseCron(expr); !errors.Is(err, schedule.ErrInvalidSpec) {
+			t.Fatalf("expected ErrInvalidSpec for %q, got %v", expr, err)
+		}
+	}
+}
+
+func TestCompileRejectsBadRules(t *testing.T) {
+	cases := []config.Schedule{
+		{At: "07:00", Action: "on"},
+		{Name: "Morning", Action: "on"},
+		{Name: "Morning", At: "7am", Action: "on"},
+		{Name: "Morning", At: "07:00", Action: "dance"},
+	}
+	for _, rule := range cases {
+		if _, err := schedule.Compile(rule, nil); err == nil {
+			t.Fatalf("expected %+v to be rejected", rule)
+		}
+	}
+
+	rules, errs := schedule.CompileAll([]config.Schedule{
+		{Name: "Evening", At: "22:00", Action: "white=2700,brightness=30"},
+		{Name: "Paused", At: "23:30", Action: "off", Disabled: true},
+		{Name: "Broken", Cron: "* *", Action: "off"},
+	}, nil)
+	if len(rules) != 1 || rules[0].Name != "Evening" || len(errs) != 1 {
+		t.Fatalf("expected one valid rule and one error, got %d rules and %v", len(rules), errs)
+	}
+}
+
+func TestDueCollapsesAndSkipsStaleTriggers(t *testing.T) {
+	rules, errs := schedule.CompileAll([]config.Schedule{
+		{Name: "Evening", At: "22:00", Action: "white=2700"},
+		{Name: "Night", At: "23:30", Action: "off"},
+		{Name: "Quarterly", Cron: "*/15 * * * *", Action: "on"},
+	}, nil)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// Suspended from 21:50 and resumed at 23:40.
+	due, missed := schedule.Due(rules, at(16, 21, 50), at(16, 23, 40), 15*time.Minute)
+	// Ties keep config order.
+	if len(due) != 2 || due[0].Rule.Name != "Night" || !due[0].At.Equal(at(16, 23, 30)) || due[1].Rule.Name != "Quarterly" {
+		t.Fatalf("unexpected due triggers: %+v", due)
+	}
+	if len(missed) != 1 || missed[0].Rule.Name != "Evening" || !missed[0].At.Equal(at(16, 22, 0)) {
+		t.Fatalf("expected the 22:00 rule to be missed, got %+v", missed)
+	}
+}
+
+func TestDaemonFiresTriggersBetweenChecks(t *testing.T) {
+	rules, _ := schedule.CompileAll([]config.Schedule{{Name: "Morning", At: "07:00", Days: "weekdays", Action: "on"}}, nil)
+	var fired []time.Time
+	daemon := &schedule.Daemon{
+		Rules: func() ([]schedule.Rule, error) { return rules, nil },
+		Fire: func(ctx context.Context, trigger schedule.Trigger) error {
+			fired = append(fired, trigger.At)
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	daemon.Check(ctx, at(16, 6, 59))
+	daemon.Check(ctx, at(16, 7, 0).Add(10*time.Second))
+	daemon.Check(ctx, at(16, 7, 0).Add(25*time.Second))
+	if len(fired) != 1 || !fired[0].Equal(at(16, 7, 0)) {
+		t.Fatalf("expected one 07:00 trigger, got %v", fired)
+	}
+
+	// Resume from a weekend suspend well after Monday's trigger.
+	daemon.Check(ctx, at(19, 9, 0))
+	if len(fired) != 1 {
+		t.Fatalf("expected stale trigger to be skipped, got %v", fired)
+	}
+}
+
+func TestDaemonReportsEvents(t *testing.T) {
+	rules, _ := schedule.CompileAll([]config.Schedule{
+		{Name: "Morning", At: "07:00", Action: "on"},
+		{Name: "Broken", At: "07:00", Action: "off"},
+	}, nil)
+	failure := errors.New("bulb unreachable")
+	var events []schedule.Event
+	daemon := &schedule.Daemon{
+		Rules: func() ([]schedule.Rule, error) { return rules, nil },
+		Fire: func(ctx context.Context, trigger schedule.Trigger) error {
+			if trigger.Rule.Name == "Broken" {
+				return failure
+			}
+			return nil
+		},
+		Events: func(event schedule.Event) { events = append(events, event) },
+	}
+
+	ctx := context.Background()
+	daemon.Check(ctx, at(16, 6, 59))
+	daemon.Check(ctx, at(16, 7, 1))
+	if len(events) != 2 || events[0].Status != schedule.Fired || events[1].Status != schedule.Failed || !errors.Is(events[1].Err, failure) {
+		t.Fatalf("expected a fired and a failed event, got %+v", events)
+	}
+
+	daemon.Check(ctx, at(17, 9, 0))
+	if len(events) != 4 || events[2].Status != schedule.Missed || events[3].Status != schedule.Missed {
+		t.Fatalf("expected both rules reported missed after a suspend, got %+v", events[2:])
+	}
+}
+
+func TestDaemonRunsFadesWithoutBlockingLaterRules(t *testing.T) {
+	rules, _ := schedule.CompileAll([]config.Schedule{
+		{Name: "Sunrise", At: "06:30", IP: "192.168.1.20", Action: "sunrise", FadeMs: int(30 * time.Minute / time.Millisecond)},
+		{Name: "Porch", At: "06:45", IP: "192.168.1.21", Action: "off"},
+		{Name: "Lights out", At: "06:50", IP: "192.168.1.20", Action: "off"},
+	}, nil)
+	fired := make(chan string, 3)
+	fadeStopped := make(chan error, 1)
+	daemon := &schedule.Daemon{
+		Rules: func() ([]schedule.Rule, error) { return rules, nil },
+		Fire: func(ctx context.Context, trigger schedule.Trigger) error {
+			fired <- trigger.Rule.Name
+			if trigger.Rule.FadeMs > 0 {
+				<-ctx.Done()
+				fadeStopped <- ctx.Err()
+				return ctx.Err()
+			}
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	daemon.Check(ctx, at(16, 6, 29))
+	daemon.Check(ctx, at(16, 6, 30))
+	if name := <-fired; name != "Sunrise" {
+		t.Fatalf("expected the sunrise to start, got %q", name)
+	}
+
+	daemon.Check(ctx, at(16, 6, 45))
+	select {
+	case name := <-fired:
+		if name != "Porch" {
+			t.Fatalf("expected the porch rule next, got %q", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a later rule to fire while the sunrise is still fading")
+	}
+
+	// A later rule for the same bulb replaces the fade still running on it.
+	daemon.Check(ctx, at(16, 6, 50))
+	select {
+	case err := <-fadeStopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the fade to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the running fade to be cancelled")
+	}
+	daemon.Wait()
+}
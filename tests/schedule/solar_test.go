@@ -0,0 +1,69 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/schedule"
+)
+
+func near(t *testing.T, label string, got, want time.Time) {
+	t.Helper()
+	if diff := got.Sub(want); diff < -2*time.Minute || diff > 2*time.Minute {
+		t.Fatalf("%s: got %v, want about %v", label, got, want)
+	}
+}
+
+func TestSunTimesLondonMidsummer(t *testing.T) {
+	sunrise, sunset, daylight := schedule.SunTimes(time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC), 51.5074, -0.1278)
+	if daylight != schedule.NormalDay {
+		t.Fatalf("expected a normal day, got %v", daylight)
+	}
+	near(t, "sunrise", sunrise, time.Date(2026, time.June, 21, 3, 43, 0, 0, time.UTC))
+	near(t, "sunset", sunset, time.Date(2026, time.June, 21, 20, 21, 0, 0, time.UTC))
+}
+
+func TestSunTimesPolarDayAndNight(t *testing.T) {
+	const latitude, longitude = 69.65, 18.96 // Tromsø
+	if _, _, daylight := schedule.SunTimes(time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC), latitude, longitude); daylight != schedule.PolarNight {
+		t.Fatalf("expected polar night in December, got %v", daylight)
+	}
+	if _, _, daylight := schedule.SunTimes(time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC), latitude, longitude); daylight != schedule.PolarDay {
+		t.Fatalf("expected polar day in June, got %v", daylight)
+	}
+
+	rule, err := schedule.Compile(config.Schedule{Name: "Dusk", At: "sunset", Action: "on"}, &config.Location{Latitude: latitude, Longitude: longitude})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next := rule.Next(time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC))
+	if next.Before(time.Date(2027, time.January, 10, 0, 0, 0, 0, time.UTC)) || next.After(time.Date(2027, time.January, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the first sunset after the polar night in mid January, got %v", next)
+	}
+}
+
+func TestSunsetOffsetRule(t *testing.T) {
+	location := &config.Location{Latitude: 51.5074, Longitude: -0.1278}
+	rule, err := schedule.Compile(config.Schedule{Name: "Porch", At: "sunset-30m", Days: "weekends", Action: "on"}, location)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 2026-06-19 is a Friday, so the next run is Saturday.
+	next := rule.Next(time.Date(2026, time.June, 19, 12, 0, 0, 0, time.UTC))
+	near(t, "porch", next, time.Date(2026, time.June, 20, 19, 51, 0, 0, time.UTC))
+
+	upcoming := schedule.Upcoming([]schedule.Rule{rule}, time.Date(2026, time.June, 19, 12, 0, 0, 0, time.UTC), 3)
+	if len(upcoming) != 3 || upcoming[1].At.Weekday() != time.Sunday || upcoming[2].At.Weekday() != time.Saturday {
+		t.Fatalf("unexpected upcoming triggers: %+v", upcoming)
+	}
+
+	for _, at := range []string{"sunset30m", "sunrise+13h", "sunset-abc"} {
+		if _, err := schedule.Compile(config.Schedule{Name: "Bad", At: at, Action: "on"}, location); err == nil {
+			t.Fatalf("expected %q to be rejected", at)
+		}
+	}
+	if _, err := schedule.Compile(config.Schedule{Name: "Porch", At: "sunset", Action: "on"}, nil); err == nil {
+		t.Fatal("expected sunset rule without a location to be rejected")
+	}
+}
@@ -0,0 +1,172 @@
+package timers_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/config"
+	"wiz-tui/internal/timers"
+)
+
+func isolateRegistry(t *testing.T) {
+	t.Helper()
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+}
+
+func TestRegistryListsLiveTimersByDeadline(t *testing.T) {
+	isolateRegistry(t)
+	now := time.Now()
+	later := timers.Timer{ID: "bbbb0001", PID: os.Getpid(), IP: "192.168.1.10", Port: "38899", Action: "off", Deadline: now.Add(time.Hour)}
+	sooner := timers.Timer{ID: "aaaa0001", PID: os.Getpid(), Name: "Desk", IP: "192.168.1.11", Port: "38899", Action: "off", Deadline: now.Add(time.Minute)}
+	for _, timer := range []timers.Timer{later, sooner} {
+		if err := timers.Add(timer); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	active, err := timers.List()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(active) != 2 || active[0].ID != sooner.ID || active[0].Target() != "Desk" {
+		t.Fatalf("expected both timers ordered by deadline, got %+v", active)
+	}
+	if remaining := active[1].Remaining(now); remaining != time.Hour {
+		t.Fatalf("expected one hour remaining, got %s", remaining)
+	}
+}
+
+func TestRegistryPrunesExitedWorkers(t *testing.T) {
+	isolateRegistry(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on Unix process semantics")
+	}
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("cannot run helper process: %v", err)
+	}
+
+	if err := timers.Add(timers.Timer{ID: "dead0001", PID: exited.Process.Pid, Action: "off", Deadline: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	active, err := timers.List()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(active) != 0 {
+		t.Fatalf("expected exited worker to be pruned, got %+v", active)
+	}
+}
+
+func TestCancelStopsWorkerByIDPrefix(t *testing.T) {
+	isolateRegistry(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on Unix process semantics")
+	}
+	worker := exec.Command("sleep", "30")
+	if err := worker.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	waited := make(chan error, 1)
+	go func() { waited <- worker.Wait() }()
+
+	if err := timers.Add(timers.Timer{ID: "c0ffee42", PID: worker.Process.Pid, Action: "off", Deadline: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	cancelled, err := timers.Cancel("c0ff")
+	if err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	if cancelled.ID != "c0ffee42" {
+		t.Fatalf("expected prefix to resolve the timer, got %+v", cancelled)
+	}
+
+	select {
+	case <-waited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected worker process to be stopped")
+	}
+	if _, err := timers.Find("c0ffee42"); !errors.Is(err, timers.ErrNotFound) {
+		t.Fatalf("expected timer to be removed, got %v", err)
+	}
+}
+
+func TestRegistryIgnoresReusedPID(t *testing.T) {
+	isolateRegistry(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on Unix process semantics")
+	}
+	other := exec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	defer func() {
+		_ = other.Process.Kill()
+		_ = other.Wait()
+	}()
+
+	// The recorded start does not match the live process now holding the PID.
+	if err := timers.Add(timers.Timer{ID: "5ea1ed01", PID: other.Process.Pid, Started: "1", Action: "off", Deadline: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if _, err := timers.Cancel("5ea1ed01"); !errors.Is(err, timers.ErrNotFound) {
+		t.Fatalf("expected reused PID to be treated as an exited worker, got %v", err)
+	}
+	if err := other.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Fatalf("expected unrelated process to survive, got %v", err)
+	}
+}
+
+func TestRegistryKeepsConcurrentAdds(t *testing.T) {
+	isolateRegistry(t)
+	const count = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, count)
+	for index := 0; index < count; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			id := fmt.Sprintf("c0c0%04d", index)
+			errs <- timers.Add(timers.Timer{ID: id, PID: os.Getpid(), Action: "off", Deadline: time.Now().Add(time.Hour)})
+		}(index)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	active, err := timers.List()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(active) != count {
+		t.Fatalf("expected %d timers after concurrent adds, got %d", count, len(active))
+	}
+}
+
+func TestRegistryIgnoresLeftoverLockFile(t *testing.T) {
+	isolateRegistry(t)
+	// A worker killed mid-update leaves its lock file behind; only a live
+	// holder may block updates.
+	if err := os.WriteFile(timers.Path()+".lock", nil, 0644); err != nil {
+		t.Fatalf("failed to create lock file: %v", err)
+	}
+	start := time.Now()
+	if err := timers.Add(timers.Timer{ID: "1eff0001", PID: os.Getpid(), Action: "off", Deadline: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the leftover lock file not to block, took %s", elapsed)
+	}
+}
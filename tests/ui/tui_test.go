@@ -1,12 +1,21 @@
 package ui_test
 
 import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"wiz-tui/internal/config"
+	"wiz-tui/internal/timers"
 	"wiz-tui/internal/ui"
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -40,3 +49,533 @@ func TestUpdateCtrlCQuits(t *testing.T) {
 		t.Fatal("expected quit command for ctrl+c")
 	}
 }
+
+func pressKeys(m tea.Model, keys ...tea.KeyMsg) tea.Model {
+	for _, key := range keys {
+		m, _ = m.Update(key)
+	}
+	return m
+}
+
+// runCmd executes a command and feeds its messages back into the model,
+// skipping spinner ticks so animation does not loop forever.
+func runCmd(m tea.Model, cmd tea.Cmd) tea.Model {
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case nil, spinner.TickMsg:
+		return m
+	case tea.BatchMsg:
+		for _, next := range msg {
+			m = runCmd(m, next)
+		}
+		return m
+	default:
+		m, cmd = m.Update(msg)
+		return runCmd(m, cmd)
+	}
+}
+
+func TestMenuOpensColorTemperatureView(t *testing.T) {
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	m = pressKeys(m, down, down, down, down, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "Tunable white") {
+		t.Fatalf("expected color temperature view, got view: %q", view)
+	}
+}
+
+func TestMenuOpensSceneView(t *testing.T) {
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	m = pressKeys(m, down, down, down, down, down, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "Ocean") {
+		t.Fatalf("expected scene browser listing, got view: %q", view)
+	}
+}
+
+func TestPowerToggleReachesSimulatedBulb(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulb, err := wiztest.Start(wiztest.Config{Power: true})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	var m tea.Model = ui.NewModel(config.Config{IP: bulb.IP(), Port: bulb.Port()}, false)
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runCmd(m, cmd)
+	if bulb.State().Power {
+		t.Fatal("expected simulated bulb to be switched off")
+	}
+	if view := m.View(); !strings.Contains(view, "Power: OFF") {
+		t.Fatalf("expected power off status, got view: %q", view)
+	}
+}
+
+func TestPowerToggleReachesEveryGroupMember(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulbs, err := wiztest.StartFleet(wiztest.Config{Power: true}, wiztest.Config{Power: true})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulbs: %v", err)
+	}
+	defer func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	}()
+
+	cfg := config.Config{
+		IP:   bulbs[0].IP(),
+		Port: bulbs[0].Port(),
+		SavedDevices: []config.SavedDevice{
+			{Name: "Desk", IP: bulbs[0].IP(), Port: bulbs[0].Port(), Mac: bulbs[0].Mac()},
+			{Name: "Hall", IP: bulbs[1].IP(), Port: bulbs[1].Port(), Mac: bulbs[1].Mac()},
+		},
+		Groups:      []config.Group{{Name: "Office", Members: []string{bulbs[0].Mac(), bulbs[1].Mac()}}},
+		ActiveGroup: "Office",
+	}
+	var m tea.Model = ui.NewModel(cfg, false)
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runCmd(m, cmd)
+	for index, bulb := range bulbs {
+		if bulb.State().Power {
+			t.Fatalf("expected group member %d to be switched off", index)
+		}
+	}
+	if view := m.View(); !strings.Contains(view, "2/2 ok") {
+		t.Fatalf("expected group summary in status, got view: %q", view)
+	}
+}
+
+func TestActiveGroupKeepsControlsOfEveryMember(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulbs, err := wiztest.StartFleet(
+		wiztest.Config{ModuleName: "ESP01_SHTW1C_31", Power: true, Brightness: 60, Temp: 2700},
+		wiztest.Config{ModuleName: "ESP01_SHRGB1C_31", Power: true, Brightness: 80},
+	)
+	if err != nil {
+		t.Fatalf("failed to start simulated bulbs: %v", err)
+	}
+	defer func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	}()
+
+	// The selected device is white-only, but the active group also holds a color bulb.
+	cfg := config.Config{
+		IP:   bulbs[0].IP(),
+		Port: bulbs[0].Port(),
+		SavedDevices: []config.SavedDevice{
+			{Name: "Desk", IP: bulbs[0].IP(), Port: bulbs[0].Port(), Mac: bulbs[0].Mac()},
+			{Name: "Lamp", IP: bulbs[1].IP(), Port: bulbs[1].Port(), Mac: bulbs[1].Mac()},
+		},
+		Groups:      []config.Group{{Name: "Living", Members: []string{bulbs[0].Mac(), bulbs[1].Mac()}}},
+		ActiveGroup: "Living",
+	}
+	var m tea.Model = ui.NewModel(cfg, false)
+	m = runCmd(m, m.Init())
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "Ruby") {
+		t.Fatalf("expected the color grid to open for the group, got view: %q", view)
+	}
+}
+
+func TestPowerToggleIsOptimisticAndRollsBackOnFailure(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulb, err := wiztest.Start(wiztest.Config{Power: true})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+	bulb.FailMethod("setState", -32000, "Busy")
+
+	var m tea.Model = ui.NewModel(config.Config{IP: bulb.IP(), Port: bulb.Port()}, false)
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if bulb.Requests("setState") != 0 {
+		t.Fatal("expected Update to return before sending the command")
+	}
+	if view := m.View(); !strings.Contains(view, "Power: OFF") || !strings.Contains(view, "Sending 1 command") {
+		t.Fatalf("expected optimistic power off with pending spinner, got view: %q", view)
+	}
+
+	m = runCmd(m, cmd)
+	view := m.View()
+	if !strings.Contains(view, "failed") || strings.Contains(view, "Sending") {
+		t.Fatalf("expected failure status after rollback, got view: %q", view)
+	}
+	if !strings.Contains(view, "Power    ON") {
+		t.Fatalf("expected power state rolled back to on, got view: %q", view)
+	}
+}
+
+func TestSavedDeviceSelectionDoesNotBlockUpdate(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	cfg := config.Config{
+		IP:           "192.168.1.5",
+		Port:         "38899",
+		SavedDevices: []config.SavedDevice{{Name: "Desk", IP: "192.168.1.9", Port: "38899", Mac: "a8bb50000001"}},
+	}
+	var m tea.Model = ui.NewModel(cfg, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	m = pressKeys(m, down, down, down, down, down, down, down, down, tea.KeyMsg{Type: tea.KeyEnter})
+
+	start := time.Now()
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("expected saved device selection to return immediately, took %s", elapsed)
+	}
+	if cmd == nil {
+		t.Fatal("expected background lookup command")
+	}
+	if view := m.View(); !strings.Contains(view, "Locating Desk") {
+		t.Fatalf("expected locating status, got view: %q", view)
+	}
+}
+
+func TestShiftArrowAdjustsBrightnessByOnePercent(t *testing.T) {
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	m = pressKeys(m, down, down, down, tea.KeyMsg{Type: tea.KeyEnter})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyShiftLeft}, tea.KeyMsg{Type: tea.KeyShiftLeft}, tea.KeyMsg{Type: tea.KeyLeft})
+	if view := m.View(); !strings.Contains(view, "Level  88%") {
+		t.Fatalf("expected brightness 88%% after fine and coarse steps, got view: %q", view)
+	}
+}
+
+func TestTransitionSettingCyclesDurations(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for i := 0; i < 10; i++ {
+		m = pressKeys(m, down)
+	}
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyRight}, tea.KeyMsg{Type: tea.KeyRight}, tea.KeyMsg{Type: tea.KeyEnter})
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load saved config: %v", err)
+	}
+	if cfg.TransitionMs != 1000 {
+		t.Fatalf("expected 1s transition to be persisted, got %dms", cfg.TransitionMs)
+	}
+}
+
+func TestPowerToggleFadesWhenTransitionIsSet(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulb, err := wiztest.Start(wiztest.Config{Power: true, Brightness: 100})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	var m tea.Model = ui.NewModel(config.Config{IP: bulb.IP(), Port: bulb.Port(), TransitionMs: 600}, false)
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runCmd(m, cmd)
+	if bulb.State().Power {
+		t.Fatal("expected simulated bulb to fade off")
+	}
+	if frames := bulb.Requests("setPilot"); frames < 2 {
+		t.Fatalf("expected fade frames before power off, got %d", frames)
+	}
+	if view := m.View(); !strings.Contains(view, "Power: OFF") {
+		t.Fatalf("expected power off status, got view: %q", view)
+	}
+}
+
+func TestSleepTimerAsksForFadeWindow(t *testing.T) {
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	m = pressKeys(m, down, down, down, down, down, down, tea.KeyMsg{Type: tea.KeyEnter})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("30")}, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "Off in 30m") {
+		t.Fatalf("expected fade window prompt, got view: %q", view)
+	}
+}
+
+func TestSleepTimerAcceptsAction(t *testing.T) {
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	m = pressKeys(m, down, down, down, down, down, down, tea.KeyMsg{Type: tea.KeyEnter})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("30 scene=Ocean")}, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "Run scene=Ocean in 30m") {
+		t.Fatalf("expected action in fade prompt, got view: %q", view)
+	}
+
+	m = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	m = pressKeys(m, down, down, down, down, down, down, tea.KeyMsg{Type: tea.KeyEnter})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("30 preset=Missing")}, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "Invalid timer action") {
+		t.Fatalf("expected unknown preset to be rejected, got view: %q", view)
+	}
+}
+
+func TestSavePresetPersistsCurrentState(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Reading")}, tea.KeyMsg{Type: tea.KeyEnter})
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if len(cfg.Presets) != 1 || cfg.Presets[0].Name != "Reading" || !cfg.Presets[0].Power {
+		t.Fatalf("expected saved preset, got %+v", cfg.Presets)
+	}
+	if view := m.View(); !strings.Contains(view, "Saved preset: Reading") {
+		t.Fatalf("expected confirmation status, got view: %q", view)
+	}
+}
+
+func TestScheduleEditorSavesRule(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for i := 0; i < 12; i++ {
+		m = pressKeys(m, down)
+	}
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Morning")}, tea.KeyMsg{Type: tea.KeyTab})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyCtrlU}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("07:00 weekdays")}, tea.KeyMsg{Type: tea.KeyTab})
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyCtrlU}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("white=6500,brightness=100")}, tea.KeyMsg{Type: tea.KeyEnter})
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	want := config.Schedule{Name: "Morning", At: "07:00", Days: "weekdays", Action: "white=6500,brightness=100"}
+	if len(cfg.Schedules) != 1 || cfg.Schedules[0] != want {
+		t.Fatalf("expected saved schedule %+v, got %+v", want, cfg.Schedules)
+	}
+	if view := m.View(); !strings.Contains(view, "07:00 weekdays") {
+		t.Fatalf("expected schedule in list, got view: %q", view)
+	}
+}
+
+func TestWakeAlarmViewAdjustsTimeAndRamp(t *testing.T) {
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for i := 0; i < 13; i++ {
+		m = pressKeys(m, down)
+	}
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "Alarm   07:00") || !strings.Contains(view, "Ramp    30 min") {
+		t.Fatalf("expected default alarm settings, got view: %q", view)
+	}
+
+	m = pressKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")}, tea.KeyMsg{Type: tea.KeyRight})
+	m = pressKeys(m, down, tea.KeyMsg{Type: tea.KeyLeft}, tea.KeyMsg{Type: tea.KeyLeft})
+	view := m.View()
+	if !strings.Contains(view, "Alarm   08:05") || !strings.Contains(view, "Ramp    20 min") {
+		t.Fatalf("expected adjusted alarm settings, got view: %q", view)
+	}
+	if !strings.Contains(view, "07:45") {
+		t.Fatalf("expected ramp start before the alarm, got view: %q", view)
+	}
+}
+
+func TestTimersViewListsRegisteredTimers(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	err := timers.Add(timers.Timer{ID: "feed0001", PID: os.Getpid(), Name: "Bedroom", IP: "192.168.1.20", Port: "38899", Action: "off", Deadline: time.Now().Add(10 * time.Minute)})
+	if err != nil {
+		t.Fatalf("failed to register timer: %v", err)
+	}
+
+	var m tea.Model = ui.NewModel(config.Config{IP: "192.168.1.5", Port: "38899"}, false)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for i := 0; i < 11; i++ {
+		m = pressKeys(m, down)
+	}
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected timers view to load the registry")
+	}
+	m, _ = m.Update(cmd())
+
+	view := m.View()
+	if !strings.Contains(view, "Bedroom") || !strings.Contains(view, "id feed0001") {
+		t.Fatalf("expected registered timer in view, got view: %q", view)
+	}
+}
+
+// liveProgram runs a model's commands concurrently and feeds their messages
+// back into it, like tea.Program without a terminal.
+type liveProgram struct {
+	t     *testing.T
+	model tea.Model
+	msgs  chan tea.Msg
+	done  chan struct{}
+}
+
+func startLiveProgram(t *testing.T, m tea.Model) *liveProgram {
+	t.Helper()
+	p := &liveProgram{t: t, model: m, msgs: make(chan tea.Msg), done: make(chan struct{})}
+	t.Cleanup(func() { close(p.done) })
+	p.run(m.Init())
+	return p
+}
+
+func (p *liveProgram) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		switch msg := cmd().(type) {
+		case nil, spinner.TickMsg:
+		case tea.BatchMsg:
+			for _, next := range msg {
+				p.run(next)
+			}
+		default:
+			select {
+			case p.msgs <- msg:
+			case <-p.done:
+			}
+		}
+	}()
+}
+
+// waitFor processes messages until ready accepts the model.
+func (p *liveProgram) waitFor(what string, ready func(tea.Model) bool) {
+	p.t.Helper()
+	timeout := time.After(3 * time.Second)
+	for !ready(p.model) {
+		select {
+		case msg := <-p.msgs:
+			var cmd tea.Cmd
+			p.model, cmd = p.model.Update(msg)
+			p.run(cmd)
+		case <-timeout:
+			p.t.Fatalf("timed out waiting for %s, got view: %q", what, p.model.View())
+		}
+	}
+}
+
+func TestDashboardFollowsPushedChanges(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulb, err := wiztest.Start(wiztest.Config{Power: true, Brightness: 80})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+	listener, err := wiz.ListenPush("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for pushes: %v", err)
+	}
+	defer listener.Close()
+
+	m := ui.NewModel(config.Config{IP: bulb.IP(), Port: bulb.Port()}, false).WithPushListener(listener)
+	p := startLiveProgram(t, m)
+	p.waitFor("the initial sync and registration", func(m tea.Model) bool {
+		return strings.Contains(m.View(), "State synced") && bulb.Registered()
+	})
+
+	// Change the bulb as the phone app would; the dashboard must follow
+	// without a manual resync.
+	if err := bulb.Apply(map[string]interface{}{"sceneId": 1.0, "dimming": 30.0}); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	p.waitFor("the pushed scene", func(m tea.Model) bool {
+		view := m.View()
+		return strings.Contains(view, "Scene    Ocean") && strings.Contains(view, "Level    30%")
+	})
+	if requests := bulb.Requests("getPilot"); requests != 1 {
+		t.Fatalf("expected only the initial getPilot, got %d", requests)
+	}
+}
+
+// press feeds keys to the model and runs the commands they return.
+func (p *liveProgram) press(keys ...tea.KeyMsg) {
+	for _, key := range keys {
+		var cmd tea.Cmd
+		p.model, cmd = p.model.Update(key)
+		p.run(cmd)
+	}
+}
+
+func TestDashboardMatchesPushesByMAC(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulb, err := wiztest.Start(wiztest.Config{Power: true, Brightness: 80})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+	listener, err := wiz.ListenPush("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for pushes: %v", err)
+	}
+	defer listener.Close()
+
+	cfg := config.Config{
+		IP:           bulb.IP(),
+		Port:         bulb.Port(),
+		SavedDevices: []config.SavedDevice{{Name: "Desk", IP: bulb.IP(), Port: bulb.Port(), Mac: bulb.Mac()}},
+	}
+	p := startLiveProgram(t, ui.NewModel(cfg, false).WithPushListener(listener))
+	p.waitFor("the initial sync", func(m tea.Model) bool { return strings.Contains(m.View(), "State synced") })
+
+	// Pushes arrive from a source port other than the request port; only the
+	// MAC tells whether they belong to the selected device.
+	sender, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to open sender: %v", err)
+	}
+	defer sender.Close()
+	target, _ := net.ResolveUDPAddr("udp4", listener.Addr())
+	send := func(mac string, dimming int) {
+		payload := fmt.Sprintf(`{"method":"syncPilot","params":{"mac":%q,"state":true,"dimming":%d,"temp":2700}}`, mac, dimming)
+		if _, err := sender.WriteToUDP([]byte(payload), target); err != nil {
+			t.Fatalf("failed to send push: %v", err)
+		}
+	}
+
+	send("a8bb500000ff", 20)
+	send(bulb.Mac(), 40)
+	p.waitFor("the push from the selected MAC", func(m tea.Model) bool {
+		return strings.Contains(m.View(), "Level    40%")
+	})
+	if strings.Contains(p.model.View(), "Level    20%") {
+		t.Fatal("expected the push from another MAC to be ignored")
+	}
+}
+
+func TestSelectingAnotherDeviceUnregistersPushes(t *testing.T) {
+	t.Setenv(config.PathEnv, filepath.Join(t.TempDir(), "config.json"))
+	bulbs, err := wiztest.StartFleet(wiztest.Config{Power: true}, wiztest.Config{Power: true})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulbs: %v", err)
+	}
+	defer func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	}()
+	listener, err := wiz.ListenPush("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for pushes: %v", err)
+	}
+	defer listener.Close()
+
+	cfg := config.Config{
+		IP:   bulbs[0].IP(),
+		Port: bulbs[0].Port(),
+		SavedDevices: []config.SavedDevice{
+			{Name: "Desk", IP: bulbs[0].IP(), Port: bulbs[0].Port()},
+			{Name: "Hall", IP: bulbs[1].IP(), Port: bulbs[1].Port()},
+		},
+	}
+	p := startLiveProgram(t, ui.NewModel(cfg, false).WithPushListener(listener))
+	p.waitFor("the first registration", func(tea.Model) bool { return bulbs[0].Registered() })
+
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	p.press(down, down, down, down, down, down, down, down, enter, down, enter)
+	p.waitFor("the registration to move", func(tea.Model) bool {
+		return bulbs[1].Registered() && !bulbs[0].Registered()
+	})
+}
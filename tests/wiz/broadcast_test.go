@@ -0,0 +1,112 @@
+package wiz_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+func TestBroadcastReachesEveryTarget(t *testing.T) {
+	bulbs, err := wiztest.StartFleet(wiztest.Config{}, wiztest.Config{}, wiztest.Config{})
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	})
+
+	targets := make([]wiz.Target, 0, len(bulbs))
+	for _, bulb := range bulbs {
+		targets = append(targets, wiz.Target{IP: bulb.IP(), Port: bulb.Port()})
+	}
+
+	results := fastClient(t).Broadcast(context.Background(), targets, "setState", map[string]interface{}{"state": true})
+	if err := wiz.JoinResults(results); err != nil {
+		t.Fatalf("expected every target to succeed, got %v", err)
+	}
+	for index, bulb := range bulbs {
+		if results[index].Target != targets[index] {
+			t.Fatalf("expected results in target order, got %+v at %d", results[index].Target, index)
+		}
+		if !bulb.State().Power {
+			t.Fatalf("expected bulb %d to be powered on", index)
+		}
+	}
+}
+
+func TestBroadcastReportsPartialFailure(t *testing.T) {
+	bulbs, err := wiztest.StartFleet(wiztest.Config{}, wiztest.Config{})
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, bulb := range bulbs {
+			_ = bulb.Close()
+		}
+	})
+	bulbs[1].FailMethod("setPilot", -32602, "Invalid params")
+
+	targets := []wiz.Target{
+		{Name: "Desk", IP: bulbs[0].IP(), Port: bulbs[0].Port()},
+		{Name: "Hall", IP: bulbs[1].IP(), Port: bulbs[1].Port()},
+	}
+	results := fastClient(t).Broadcast(context.Background(), targets, "setPilot", map[string]interface{}{"dimming": 40})
+
+	err = wiz.JoinResults(results)
+	var broadcastErr *wiz.BroadcastError
+	if !errors.As(err, &broadcastErr) {
+		t.Fatalf("expected BroadcastError, got %v", err)
+	}
+	if !broadcastErr.Partial() || len(broadcastErr.Failed) != 1 || broadcastErr.Failed[0].Target.Name != "Hall" {
+		t.Fatalf("expected only Hall to fail, got %+v", broadcastErr)
+	}
+	var deviceErr *wiz.DeviceError
+	if !errors.As(err, &deviceErr) || deviceErr.Code != -32602 {
+		t.Fatalf("expected wrapped device error, got %v", err)
+	}
+	if bulbs[0].State().Brightness != 40 {
+		t.Fatalf("expected healthy bulb to apply brightness, got %d", bulbs[0].State().Brightness)
+	}
+}
+
+func TestForEachRunsOncePerTargetInOrder(t *testing.T) {
+	targets := []wiz.Target{
+		{Name: "Desk", IP: "192.168.1.10", Port: "38899"},
+		{Name: "Hall", IP: "192.168.1.11", Port: "38899"},
+		{Name: "Porch", IP: "192.168.1.12", Port: "38899"},
+	}
+	failure := errors.New("unreachable")
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	results := wiz.ForEach(targets, func(_ int, target wiz.Target) error {
+		mu.Lock()
+		calls[target.Name]++
+		mu.Unlock()
+		if target.Name == "Hall" {
+			return failure
+		}
+		return nil
+	})
+
+	if len(results) != len(targets) {
+		t.Fatalf("expected %d results, got %d", len(targets), len(results))
+	}
+	for index, result := range results {
+		if result.Target != targets[index] {
+			t.Fatalf("expected results in target order, got %+v at %d", result.Target, index)
+		}
+		if calls[result.Target.Name] != 1 {
+			t.Fatalf("expected one call for %s, got %d", result.Target.Name, calls[result.Target.Name])
+		}
+	}
+	if !errors.Is(results[1].Err, failure) || results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("expected only Hall to fail, got %+v", results)
+	}
+}
@@ -0,0 +1,109 @@
+package wiz_test
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"testing"
+
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+func TestCapabilitiesFromModule(t *testing.T) {
+	cases := []struct {
+		module string
+		kind   wiz.DeviceKind
+		color  bool
+		temp   bool
+		dim    bool
+	}{
+		{"ESP01_SHRGB1C_31", wiz.KindRGB, true, true, true},
+		{"ESP01_SHTW1C_31", wiz.KindTunableWhite, false, true, true},
+		{"ESP01_SHDW1_31", wiz.KindDimmable, false, false, true},
+		{"ESP10_SOCKET_06", wiz.KindPlug, false, false, false},
+		{"", wiz.KindUnknown, true, true, true},
+	}
+	for _, tc := range cases {
+		caps := wiz.CapabilitiesFromModule(tc.module)
+		if caps.Kind != tc.kind || caps.Color != tc.color || caps.ColorTemp != tc.temp || caps.Dimming != tc.dim {
+			t.Errorf("%q: unexpected capabilities %+v", tc.module, caps)
+		}
+	}
+}
+
+func TestCapabilitiesSupportsScene(t *testing.T) {
+	tw := wiz.CapabilitiesFromModule("ESP01_SHTW1C_31")
+	if tw.SupportsScene(4) {
+		t.Fatal("expected tunable white bulb to reject the Party scene")
+	}
+	if !tw.SupportsScene(6) {
+		t.Fatal("expected tunable white bulb to support the Cozy scene")
+	}
+	if wiz.CapabilitiesFromModule("ESP10_SOCKET_06").SupportsScene(6) {
+		t.Fatal("expected plug to support no scenes")
+	}
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, addr, readErr := server.ReadFromUDP(buf)
+			if readErr != nil {
+				return
+			}
+			var request struct {
+				Method string `json:"method"`
+			}
+			_ = json.Unmarshal(buf[:n], &request)
+			switch request.Method {
+			case "getSystemConfig":
+				_, _ = server.WriteToUDP([]byte(`{"method":"getSystemConfig","result":{"moduleName":"ESP01_SHTW1C_31"}}`), addr)
+			case "getModelConfig":
+				_, _ = server.WriteToUDP([]byte(`{"method":"getModelConfig","result":{"cctRange":[2700,2700,5000,5000]}}`), addr)
+			}
+		}
+	}()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	caps, err := fastClient(t).DetectCapabilities("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("DetectCapabilities failed: %v", err)
+	}
+	if caps.Kind != wiz.KindTunableWhite {
+		t.Fatalf("expected tunable white, got %s", caps.Kind)
+	}
+	if caps.MinKelvin != 2700 || caps.MaxKelvin != 5000 {
+		t.Fatalf("expected 2700-5000K range, got %d-%d", caps.MinKelvin, caps.MaxKelvin)
+	}
+	if got := caps.ClampKelvin(6500); got != 5000 {
+		t.Fatalf("expected clamp to device max, got %d", got)
+	}
+}
+
+func TestDetectCapabilitiesProbesPlugMeter(t *testing.T) {
+	if wiz.CapabilitiesFromModule("ESP10_SOCKET_06").PowerMetering {
+		t.Fatal("expected the module name alone not to claim a power meter")
+	}
+	for _, metered := range []bool{true, false} {
+		plug, err := wiztest.Start(wiztest.Config{ModuleName: "ESP10_SOCKET_06", Metered: metered, PowerMilliwatts: 5000})
+		if err != nil {
+			t.Fatalf("failed to start simulated plug: %v", err)
+		}
+		caps, err := fastClient(t).DetectCapabilities(plug.IP(), plug.Port())
+		_ = plug.Close()
+		if err != nil {
+			t.Fatalf("DetectCapabilities failed: %v", err)
+		}
+		if caps.Kind != wiz.KindPlug || caps.PowerMetering != metered {
+			t.Fatalf("metered=%v: unexpected capabilities %+v", metered, caps)
+		}
+	}
+}
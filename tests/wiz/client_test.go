@@ -1,9 +1,14 @@
 package wiz_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -98,3 +103,337 @@ func TestGetPilotStateMissingResult(t *testing.T) {
 		t.Fatalf("expected missing result error, got: %v", err)
 	}
 }
+
+func TestClientReusesSocketAcrossRequests(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	sources := make(chan string, 2)
+	go func() {
+		buf := make([]byte, 4096)
+		for i := 0; i < 2; i++ {
+			_, addr, readErr := server.ReadFromUDP(buf)
+			if readErr != nil {
+				return
+			}
+			sources <- addr.String()
+			_, _ = server.WriteToUDP([]byte(`{"method":"getPilot","result":{"state":true,"dimming":50}}`), addr)
+		}
+	}()
+
+	client := wiz.NewClient(wiz.Options{ReadTimeout: time.Second, Attempts: 1})
+	defer client.Close()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetPilotState("127.0.0.1", port); err != nil {
+			t.Fatalf("GetPilotState call %d failed: %v", i+1, err)
+		}
+	}
+
+	first, second := <-sources, <-sources
+	if first != second {
+		t.Fatalf("expected both requests from one socket, got %s and %s", first, second)
+	}
+}
+
+func TestClientMatchesRepliesByID(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 4096)
+		type request struct {
+			id   int
+			addr *net.UDPAddr
+		}
+		var requests []request
+		for len(requests) < 2 {
+			n, addr, readErr := server.ReadFromUDP(buf)
+			if readErr != nil {
+				return
+			}
+			var decoded struct {
+				ID int `json:"id"`
+			}
+			_ = json.Unmarshal(buf[:n], &decoded)
+			requests = append(requests, request{id: decoded.ID, addr: addr})
+		}
+		// Answer in reverse order so only id matching pairs them correctly.
+		for i := len(requests) - 1; i >= 0; i-- {
+			response := fmt.Sprintf(`{"id":%d,"method":"getPilot","result":{"state":true,"dimming":%d}}`, requests[i].id, requests[i].id)
+			_, _ = server.WriteToUDP([]byte(response), requests[i].addr)
+		}
+	}()
+
+	client := wiz.NewClient(wiz.Options{ReadTimeout: 2 * time.Second, Attempts: 1})
+	defer client.Close()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, err := client.GetPilotState("127.0.0.1", port)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if state.Brightness <= 0 {
+				errs <- fmt.Errorf("unexpected brightness %d", state.Brightness)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestClientDropsRepliesWithStaleID(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 4096)
+		var staleID int
+		for {
+			n, addr, readErr := server.ReadFromUDP(buf)
+			if readErr != nil {
+				return
+			}
+			var decoded struct {
+				ID int `json:"id"`
+			}
+			_ = json.Unmarshal(buf[:n], &decoded)
+			if staleID == 0 {
+				// Leave the first request unanswered until the second is waiting.
+				staleID = decoded.ID
+				continue
+			}
+			stale := fmt.Sprintf(`{"id":%d,"method":"getPilot","result":{"state":true,"dimming":11}}`, staleID)
+			_, _ = server.WriteToUDP([]byte(stale), addr)
+			time.Sleep(50 * time.Millisecond)
+			fresh := fmt.Sprintf(`{"id":%d,"method":"getPilot","result":{"state":true,"dimming":77}}`, decoded.ID)
+			_, _ = server.WriteToUDP([]byte(fresh), addr)
+		}
+	}()
+
+	client := wiz.NewClient(wiz.Options{ReadTimeout: 100 * time.Millisecond, Attempts: 1})
+	defer client.Close()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	if _, err := client.GetPilotState("127.0.0.1", port); !errors.Is(err, wiz.ErrTimeout) {
+		t.Fatalf("expected the first request to time out, got %v", err)
+	}
+	state, err := client.GetPilotState("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("GetPilotState failed: %v", err)
+	}
+	if state.Brightness != 77 {
+		t.Fatalf("expected the reply tagged with the new id, got brightness %d", state.Brightness)
+	}
+}
+
+func TestClientHonoursAttempts(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	received := make(chan struct{}, 10)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, _, readErr := server.ReadFromUDP(buf); readErr != nil {
+				return
+			}
+			received <- struct{}{}
+		}
+	}()
+
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout: 50 * time.Millisecond,
+		Attempts:    2,
+		Backoff:     func(int) time.Duration { return 0 },
+	})
+	defer client.Close()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	if _, err := client.GetPilotState("127.0.0.1", port); err == nil {
+		t.Fatal("expected timeout error from silent device")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := len(received); got != 2 {
+		t.Fatalf("expected 2 attempts, server saw %d", got)
+	}
+}
+
+func TestGetPilotStateContextCancel(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	client := wiz.NewClient(wiz.Options{ReadTimeout: 5 * time.Second})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	_, err = client.GetPilotStateContext(ctx, "127.0.0.1", port)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected prompt return on cancellation, took %s", elapsed)
+	}
+}
+
+func TestDiscoverDevicesContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := wiz.DiscoverDevicesContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected prompt return on cancellation, took %s", elapsed)
+	}
+}
+
+func TestSendCommandAck(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 4096)
+		_, addr, readErr := server.ReadFromUDP(buf)
+		if readErr != nil {
+			return
+		}
+		_, _ = server.WriteToUDP([]byte(`{"method":"setPilot","result":{"success":true}}`), addr)
+	}()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	if err := wiz.SendCommandAck("127.0.0.1", port, "setPilot", map[string]interface{}{"dimming": 40}); err != nil {
+		t.Fatalf("expected acknowledged send, got: %v", err)
+	}
+}
+
+func TestSendCommandAckDeviceError(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 4096)
+		_, addr, readErr := server.ReadFromUDP(buf)
+		if readErr != nil {
+			return
+		}
+		_, _ = server.WriteToUDP([]byte(`{"method":"setPilot","error":{"code":-32602,"message":"Invalid params"}}`), addr)
+	}()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	err = wiz.SendCommandAck("127.0.0.1", port, "setPilot", map[string]interface{}{"dimming": 400})
+	var deviceErr *wiz.DeviceError
+	if !errors.As(err, &deviceErr) {
+		t.Fatalf("expected DeviceError, got: %v", err)
+	}
+	if deviceErr.Code != -32602 || deviceErr.Message != "Invalid params" || deviceErr.Method != "setPilot" {
+		t.Fatalf("unexpected device error fields: %+v", deviceErr)
+	}
+}
+
+func TestSendCommandAckRetriesWhenSilent(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 4096)
+		// Drop the first datagram, acknowledge the retry.
+		if _, _, readErr := server.ReadFromUDP(buf); readErr != nil {
+			return
+		}
+		_, addr, readErr := server.ReadFromUDP(buf)
+		if readErr != nil {
+			return
+		}
+		_, _ = server.WriteToUDP([]byte(`{"method":"setState","result":{"success":true}}`), addr)
+	}()
+
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout: 100 * time.Millisecond,
+		Attempts:    3,
+		Backoff:     func(int) time.Duration { return 0 },
+	})
+	defer client.Close()
+
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	if err := client.SendCommandAck("127.0.0.1", port, "setState", map[string]interface{}{"state": true}); err != nil {
+		t.Fatalf("expected retry to be acknowledged, got: %v", err)
+	}
+}
+
+func TestGetPilotStateTunableWhite(t *testing.T) {
+	port := startReplyServer(t, `{"method":"getPilot","result":{"state":true,"dimming":60,"temp":2700}}`)
+	state, err := wiz.GetPilotState("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("GetPilotState failed: %v", err)
+	}
+	if state.Temp != 2700 {
+		t.Fatalf("expected temp=2700, got %d", state.Temp)
+	}
+	if state.ColorHex != "" {
+		t.Fatalf("expected no RGB color in white mode, got %s", state.ColorHex)
+	}
+}
+
+func TestKelvinToHex(t *testing.T) {
+	warm := wiz.KelvinToHex(2200)
+	cool := wiz.KelvinToHex(6500)
+	if warm == cool {
+		t.Fatalf("expected distinct swatches, got %s for both", warm)
+	}
+	r, _, b, err := wiz.HexToRGB(warm)
+	if err != nil {
+		t.Fatalf("KelvinToHex produced invalid hex %q: %v", warm, err)
+	}
+	if r <= b {
+		t.Fatalf("expected warm white to be red-heavy, got %s", warm)
+	}
+	if wiz.ClampKelvin(1000) != wiz.MinKelvin || wiz.ClampKelvin(9000) != wiz.MaxKelvin {
+		t.Fatal("expected ClampKelvin to bound the supported range")
+	}
+}
@@ -0,0 +1,144 @@
+package wiz_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+func TestSendCoalescedKeepsOnlyLatestPendingState(t *testing.T) {
+	bulb, err := wiztest.Start(wiztest.Config{Latency: 60 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	client := wiz.NewClient(wiz.Options{ReadTimeout: time.Second, Attempts: 1, CoalesceInterval: 50 * time.Millisecond})
+	defer client.Close()
+
+	errs := make(chan error, 20)
+	for level := 11; level <= 30; level++ {
+		go func(level int) {
+			errs <- client.SendCoalesced(context.Background(), bulb.IP(), bulb.Port(), "setPilot", map[string]interface{}{"dimming": level})
+		}(level)
+		time.Sleep(2 * time.Millisecond)
+	}
+	for i := 0; i < 20; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("expected coalesced sends to succeed, got %v", err)
+		}
+	}
+
+	if got := bulb.State().Brightness; got != 30 {
+		t.Fatalf("expected final brightness 30, got %d", got)
+	}
+	if sent := bulb.Requests("setPilot"); sent >= 10 {
+		t.Fatalf("expected coalescing to collapse 20 updates, bulb saw %d", sent)
+	}
+}
+
+func TestSendCoalescedDropsConflictingLightMode(t *testing.T) {
+	bulb, err := wiztest.Start(wiztest.Config{ModuleName: "ESP01_SHRGB1C_31", Latency: 40 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	client := wiz.NewClient(wiz.Options{ReadTimeout: time.Second, Attempts: 1, CoalesceInterval: 50 * time.Millisecond})
+	defer client.Close()
+
+	errs := make(chan error, 3)
+	send := func(params map[string]interface{}) {
+		go func() {
+			errs <- client.SendCoalesced(context.Background(), bulb.IP(), bulb.Port(), "setPilot", params)
+		}()
+		time.Sleep(5 * time.Millisecond)
+	}
+	send(map[string]interface{}{"dimming": 20})
+	send(map[string]interface{}{"r": 255, "g": 0, "b": 0})
+	send(map[string]interface{}{"temp": 3000})
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("expected coalesced sends to succeed, got %v", err)
+		}
+	}
+
+	state := bulb.State()
+	if state.Temp != 3000 || state.ColorHex != "" {
+		t.Fatalf("expected white mode to replace pending color, got %+v", state)
+	}
+}
+
+func TestSendCoalescedStopsWhenCallersGiveUp(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	defer server.Close()
+	received := make(chan struct{}, 10)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, _, readErr := server.ReadFromUDP(buf); readErr != nil {
+				return
+			}
+			received <- struct{}{}
+		}
+	}()
+
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout:      100 * time.Millisecond,
+		Attempts:         5,
+		Backoff:          func(int) time.Duration { return 0 },
+		CoalesceInterval: 50 * time.Millisecond,
+	})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	port := strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+	if err := client.SendCoalesced(ctx, "127.0.0.1", port, "setPilot", map[string]interface{}{"dimming": 20}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the caller's deadline, got %v", err)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if got := len(received); got != 1 {
+		t.Fatalf("expected retries to stop with the caller, device saw %d attempts", got)
+	}
+}
+
+func TestSendCoalescedDropsAbandonedPendingUpdate(t *testing.T) {
+	bulb, err := wiztest.Start(wiztest.Config{})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	client := wiz.NewClient(wiz.Options{ReadTimeout: time.Second, Attempts: 1, CoalesceInterval: 200 * time.Millisecond})
+	defer client.Close()
+
+	if err := client.SendCoalesced(context.Background(), bulb.IP(), bulb.Port(), "setPilot", map[string]interface{}{"dimming": 20}); err != nil {
+		t.Fatalf("expected the first send to succeed, got %v", err)
+	}
+	// The next update waits out the pacing gap; cancelling it meanwhile
+	// must keep it from ever reaching the bulb.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.SendCoalesced(ctx, bulb.IP(), bulb.Port(), "setPilot", map[string]interface{}{"dimming": 80}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the caller's deadline, got %v", err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if got := bulb.State().Brightness; got != 20 {
+		t.Fatalf("expected the abandoned update to be dropped, bulb is at %d%%", got)
+	}
+	if sent := bulb.Requests("setPilot"); sent != 1 {
+		t.Fatalf("expected one setPilot, bulb saw %d", sent)
+	}
+}
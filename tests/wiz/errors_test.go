@@ -0,0 +1,92 @@
+package wiz_test
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/wiz"
+)
+
+func startReplyServer(t *testing.T, reply string) string {
+	t.Helper()
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to start udp server: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			_, addr, readErr := server.ReadFromUDP(buf)
+			if readErr != nil {
+				return
+			}
+			if reply != "" {
+				_, _ = server.WriteToUDP([]byte(reply), addr)
+			}
+		}
+	}()
+
+	return strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func fastClient(t *testing.T) *wiz.Client {
+	t.Helper()
+	client := wiz.NewClient(wiz.Options{
+		ReadTimeout: 50 * time.Millisecond,
+		Attempts:    2,
+		Backoff:     func(int) time.Duration { return 0 },
+	})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestErrorsTimeout(t *testing.T) {
+	port := startReplyServer(t, "")
+	_, err := fastClient(t).GetPilotState("127.0.0.1", port)
+	if !errors.Is(err, wiz.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got: %v", err)
+	}
+}
+
+func TestErrorsNoResult(t *testing.T) {
+	port := startReplyServer(t, `{"ok":true}`)
+	_, err := fastClient(t).GetPilotState("127.0.0.1", port)
+	if !errors.Is(err, wiz.ErrNoResult) {
+		t.Fatalf("expected ErrNoResult, got: %v", err)
+	}
+}
+
+func TestErrorsMalformed(t *testing.T) {
+	port := startReplyServer(t, `not json`)
+	_, err := fastClient(t).GetPilotState("127.0.0.1", port)
+	if !errors.Is(err, wiz.ErrMalformedResponse) {
+		t.Fatalf("expected ErrMalformedResponse, got: %v", err)
+	}
+}
+
+func TestErrorsNotAcknowledged(t *testing.T) {
+	port := startReplyServer(t, `{"result":{"success":false}}`)
+	err := fastClient(t).SendCommandAck("127.0.0.1", port, "setState", map[string]interface{}{"state": true})
+	if !errors.Is(err, wiz.ErrNotAcknowledged) {
+		t.Fatalf("expected ErrNotAcknowledged, got: %v", err)
+	}
+}
+
+func TestErrorsClosed(t *testing.T) {
+	client := wiz.NewClient(wiz.DefaultOptions())
+	_ = client.Close()
+	if err := client.SendCommand("127.0.0.1", "38899", "setState", map[string]interface{}{"state": true}); !errors.Is(err, wiz.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got: %v", err)
+	}
+}
+
+func TestErrorsInvalidHex(t *testing.T) {
+	if _, _, _, err := wiz.HexToRGB("#GGGGGG"); !errors.Is(err, wiz.ErrInvalidHex) {
+		t.Fatalf("expected ErrInvalidHex, got: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package wiz_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/wiz"
+)
+
+func TestDeviceJSONUsesStableFieldNames(t *testing.T) {
+	device := wiz.Device{
+		IP:           "192.168.1.20",
+		Port:         "38899",
+		Mac:          "a8bb50000001",
+		Name:         "Desk",
+		Model:        "ESP01_SHRGB1C_31",
+		Capabilities: wiz.CapabilitiesFromModule("ESP01_SHRGB1C_31"),
+	}
+	data, err := json.Marshal(device)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	for _, key := range []string{"ip", "port", "mac", "name", "model", "capabilities"} {
+		if _, ok := fields[key]; !ok {
+			t.Fatalf("expected key %q in %s", key, data)
+		}
+	}
+	caps := fields["capabilities"].(map[string]interface{})
+	if caps["kind"] != "rgb" || caps["color"] != true {
+		t.Fatalf("unexpected capabilities JSON: %s", data)
+	}
+
+	var decoded wiz.Device
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("round trip failed: %v", err)
+	}
+	if decoded != device {
+		t.Fatalf("expected %+v after round trip, got %+v", device, decoded)
+	}
+}
+
+func TestDeviceKindJSONNames(t *testing.T) {
+	cases := map[wiz.DeviceKind]string{
+		wiz.KindUnknown:      `"unknown"`,
+		wiz.KindRGB:          `"rgb"`,
+		wiz.KindTunableWhite: `"tunable-white"`,
+		wiz.KindDimmable:     `"dimmable"`,
+		wiz.KindPlug:         `"plug"`,
+	}
+	for kind, want := range cases {
+		data, err := json.Marshal(kind)
+		if err != nil || string(data) != want {
+			t.Fatalf("expected %s for %v, got %s (%v)", want, kind, data, err)
+		}
+		var decoded wiz.DeviceKind
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != kind {
+			t.Fatalf("expected %s to decode to %v, got %v (%v)", data, kind, decoded, err)
+		}
+	}
+}
+
+func TestOutcomeJSON(t *testing.T) {
+	target := wiz.Target{Name: "Desk", IP: "192.168.1.20", Port: "38899"}
+	state := wiz.PilotState{Power: true, Brightness: 40, Temp: 2700}
+
+	ok := wiz.Result{Target: target, Latency: 12 * time.Millisecond}.Outcome()
+	ok.State = &state
+	data, _ := json.Marshal(ok)
+	want := `{"target":{"name":"Desk","ip":"192.168.1.20","port":"38899"},"ok":true,"latencyMs":12,"state":{"power":true,"brightness":40,"temp":2700}}`
+	if string(data) != want {
+		t.Fatalf("expected %s, got %s", want, data)
+	}
+
+	failed := wiz.Result{Target: target, Err: errors.New("timed out")}.Outcome()
+	failed.Action = "off"
+	data, _ = json.Marshal(failed)
+	want = `{"target":{"name":"Desk","ip":"192.168.1.20","port":"38899"},"ok":false,"error":"timed out","latencyMs":0,"action":"off"}`
+	if string(data) != want {
+		t.Fatalf("expected %s, got %s", want, data)
+	}
+}
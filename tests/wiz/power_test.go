@@ -0,0 +1,28 @@
+package wiz_test
+
+import (
+	"errors"
+	"testing"
+
+	"wiz-tui/internal/wiz"
+)
+
+func TestGetPower(t *testing.T) {
+	port := startReplyServer(t, `{"method":"getPower","env":"pro","result":{"power":12345}}`)
+	watts, err := fastClient(t).GetPower("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("GetPower failed: %v", err)
+	}
+	if watts < 12.34 || watts > 12.35 {
+		t.Fatalf("expected 12.345W, got %f", watts)
+	}
+}
+
+func TestGetPowerUnsupported(t *testing.T) {
+	port := startReplyServer(t, `{"method":"getPower","error":{"code":-32601,"message":"Method not found"}}`)
+	_, err := fastClient(t).GetPower("127.0.0.1", port)
+	var deviceErr *wiz.DeviceError
+	if !errors.As(err, &deviceErr) || deviceErr.Code != -32601 {
+		t.Fatalf("expected method-not-found DeviceError, got: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package wiz_test
+
+import (
+	"testing"
+	"time"
+
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+// startPushBulb starts a simulated bulb, registers a push listener with it,
+// and waits until the bulb has accepted the registration.
+func startPushBulb(t *testing.T, cfg wiztest.Config) (*wiztest.Bulb, *wiz.PushListener) {
+	t.Helper()
+	bulb, err := wiztest.Start(cfg)
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	t.Cleanup(func() { _ = bulb.Close() })
+	listener, err := wiz.ListenPush("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for pushes: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	if err := listener.Register(bulb.IP(), bulb.Port()); err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !bulb.Registered() {
+		if time.Now().After(deadline) {
+			t.Fatal("bulb never accepted the registration")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return bulb, listener
+}
+
+func nextPush(t *testing.T, listener *wiz.PushListener) wiz.Push {
+	t.Helper()
+	select {
+	case push, ok := <-listener.Pushes():
+		if !ok {
+			t.Fatal("push channel closed")
+		}
+		return push
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a push")
+	}
+	return wiz.Push{}
+}
+
+func TestPushListenerReceivesExternalChanges(t *testing.T) {
+	bulb, listener := startPushBulb(t, wiztest.Config{Power: true, Brightness: 80})
+
+	if err := bulb.Apply(map[string]interface{}{"r": 255.0, "g": 0.0, "b": 51.0, "dimming": 30.0}); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	push := nextPush(t, listener)
+	if push.Mac != bulb.Mac() || push.IP != bulb.IP() || push.Port != bulb.Port() {
+		t.Fatalf("expected a push from %s at %s, got %+v", bulb.Mac(), bulb.Addr(), push)
+	}
+	if !push.State.Power || push.State.Brightness != 30 || push.State.ColorHex != "#FF0033" {
+		t.Fatalf("unexpected pushed state %+v", push.State)
+	}
+}
+
+func TestPushListenerReceivesChangesFromCommands(t *testing.T) {
+	bulb, listener := startPushBulb(t, wiztest.Config{Power: true})
+
+	if err := fastClient(t).SendCommandAck(bulb.IP(), bulb.Port(), "setState", map[string]interface{}{"state": false}); err != nil {
+		t.Fatalf("setState failed: %v", err)
+	}
+	if push := nextPush(t, listener); push.State.Power {
+		t.Fatalf("expected a push reporting power off, got %+v", push)
+	}
+}
+
+func TestPushListenerCloseEndsPushes(t *testing.T) {
+	listener, err := wiz.ListenPush("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for pushes: %v", err)
+	}
+	if err := listener.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, ok := <-listener.Pushes(); ok {
+		t.Fatal("expected the push channel to be closed")
+	}
+}
+
+func TestPushListenerUnregisterStopsPushes(t *testing.T) {
+	bulb, listener := startPushBulb(t, wiztest.Config{Power: true, Brightness: 80})
+
+	if err := listener.Unregister(bulb.IP(), bulb.Port()); err != nil {
+		t.Fatalf("unregister failed: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for bulb.Registered() {
+		if time.Now().After(deadline) {
+			t.Fatal("bulb kept the registration")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,42 @@
+package wiz_test
+
+import (
+	"testing"
+
+	"wiz-tui/internal/wiz"
+)
+
+func TestSceneByName(t *testing.T) {
+	scene, ok := wiz.SceneByName("pastel-colors")
+	if !ok || scene.ID != 8 {
+		t.Fatalf("expected Pastel Colors (8), got %+v ok=%v", scene, ok)
+	}
+	if _, ok := wiz.SceneByName("disco inferno"); ok {
+		t.Fatal("expected unknown scene lookup to fail")
+	}
+}
+
+func TestSceneParams(t *testing.T) {
+	cozy, _ := wiz.SceneByName("Cozy")
+	params := wiz.SceneParams(cozy, 500)
+	if params["sceneId"] != 6 || params["speed"] != wiz.MaxSceneSpeed {
+		t.Fatalf("unexpected dynamic scene params: %v", params)
+	}
+
+	focus, _ := wiz.SceneByName("focus")
+	params = wiz.SceneParams(focus, 50)
+	if _, ok := params["speed"]; ok {
+		t.Fatalf("expected no speed for static scene, got %v", params)
+	}
+}
+
+func TestGetPilotStateScene(t *testing.T) {
+	port := startReplyServer(t, `{"method":"getPilot","result":{"state":true,"sceneId":5,"speed":80,"dimming":70}}`)
+	state, err := wiz.GetPilotState("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("GetPilotState failed: %v", err)
+	}
+	if state.SceneID != 5 || state.Speed != 80 {
+		t.Fatalf("expected scene 5 at speed 80, got %+v", state)
+	}
+}
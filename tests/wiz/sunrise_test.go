@@ -0,0 +1,64 @@
+package wiz_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+func TestSunriseStagesFollowCapabilities(t *testing.T) {
+	color := wiz.SunriseStages(wiz.CapabilitiesFromModule("ESP01_SHRGB1C_31"))
+	if len(color) < 3 || color[0].State.ColorHex == "" || color[len(color)-1].State.Temp != 6500 {
+		t.Fatalf("expected RGB stages ending in cool white, got %+v", color)
+	}
+	for i := 1; i < len(color); i++ {
+		if color[i].At <= color[i-1].At || color[i].State.Brightness < color[i-1].State.Brightness {
+			t.Fatalf("expected stages to rise in time and brightness, got %+v", color)
+		}
+	}
+
+	white := wiz.SunriseStages(wiz.CapabilitiesFromModule("ESP01_SHTW1C_31"))
+	if len(white) != 2 || white[0].State.Temp != 2700 || white[0].State.ColorHex != "" {
+		t.Fatalf("expected a white-only ramp from the warmest supported white, got %+v", white)
+	}
+
+	if plug := wiz.SunriseStages(wiz.CapabilitiesFromModule("ESP10_SOCKET_06")); plug != nil {
+		t.Fatalf("expected no ramp for plugs, got %+v", plug)
+	}
+}
+
+func TestSunriseEndsAtBrightCoolWhite(t *testing.T) {
+	for _, module := range []string{"ESP01_SHRGB1C_31", "ESP01_SHTW1C_31"} {
+		bulb, err := wiztest.Start(wiztest.Config{ModuleName: module})
+		if err != nil {
+			t.Fatalf("failed to start bulb: %v", err)
+		}
+		t.Cleanup(func() { _ = bulb.Close() })
+
+		if err := fastClient(t).Sunrise(context.Background(), bulb.IP(), bulb.Port(), 300*time.Millisecond); err != nil {
+			t.Fatalf("%s: sunrise failed: %v", module, err)
+		}
+		state := bulb.State()
+		if !state.Power || state.Brightness != 100 || state.Temp != 6500 {
+			t.Fatalf("%s: expected bright cool white, got %+v", module, state)
+		}
+	}
+}
+
+func TestSunriseSwitchesOnPlugs(t *testing.T) {
+	plug, err := wiztest.Start(wiztest.Config{ModuleName: "ESP10_SOCKET_06"})
+	if err != nil {
+		t.Fatalf("failed to start plug: %v", err)
+	}
+	t.Cleanup(func() { _ = plug.Close() })
+
+	if err := fastClient(t).Sunrise(context.Background(), plug.IP(), plug.Port(), time.Second); err != nil {
+		t.Fatalf("sunrise failed: %v", err)
+	}
+	if !plug.State().Power || plug.Requests("setPilot") != 0 {
+		t.Fatalf("expected plug to be switched on without pilot frames, got %+v", plug.State())
+	}
+}
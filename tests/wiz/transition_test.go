@@ -0,0 +1,109 @@
+package wiz_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+func TestTransitionFrameInterpolatesBrightnessAndColor(t *testing.T) {
+	transition := wiz.Transition{
+		From: wiz.PilotState{Power: true, Brightness: 100, ColorHex: "#000000"},
+		To:   wiz.PilotState{Power: true, Brightness: 20, ColorHex: "#C86400"},
+	}
+
+	frame := transition.Frame(0.5)
+	if frame["dimming"] != 60 || frame["r"] != 100 || frame["g"] != 50 || frame["b"] != 0 {
+		t.Fatalf("unexpected midpoint frame: %+v", frame)
+	}
+	if final := transition.Frame(1); final["dimming"] != 20 || final["r"] != 200 {
+		t.Fatalf("expected final frame to match target, got %+v", final)
+	}
+}
+
+func TestTransitionFrameCrossfadesColorIntoWhite(t *testing.T) {
+	transition := wiz.Transition{
+		From: wiz.PilotState{Power: true, Brightness: 50, ColorHex: "#FF0000"},
+		To:   wiz.PilotState{Power: true, Temp: 2700},
+	}
+
+	if mid := transition.Frame(0.5); mid["temp"] != nil || mid["r"] == nil {
+		t.Fatalf("expected RGB crossfade toward white, got %+v", mid)
+	}
+	final := transition.Frame(1)
+	if final["temp"] != 2700 || final["r"] != nil || final["dimming"] != 50 {
+		t.Fatalf("expected exact white temperature at the end, got %+v", final)
+	}
+}
+
+func TestFadeToOffDimsThenSwitchesOff(t *testing.T) {
+	bulb, err := wiztest.Start(wiztest.Config{Power: true, Brightness: 80})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	client := fastClient(t)
+	err = client.Fade(context.Background(), bulb.IP(), bulb.Port(), wiz.Transition{
+		From:          bulb.State(),
+		To:            wiz.PilotState{Power: false},
+		Duration:      200 * time.Millisecond,
+		FrameInterval: 40 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("fade failed: %v", err)
+	}
+
+	if state := bulb.State(); state.Power {
+		t.Fatalf("expected bulb switched off, got %+v", state)
+	}
+	if frames := bulb.Requests("setPilot"); frames < 2 {
+		t.Fatalf("expected intermediate frames, got %d", frames)
+	}
+}
+
+func TestFadeToOffRestoresOriginalBrightness(t *testing.T) {
+	bulb, err := wiztest.Start(wiztest.Config{Power: true, Brightness: 80})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	client := fastClient(t)
+	err = client.FadeTo(context.Background(), bulb.IP(), bulb.Port(), wiz.PilotState{Power: false}, 120*time.Millisecond)
+	if err != nil {
+		t.Fatalf("fade failed: %v", err)
+	}
+	if state := bulb.State(); state.Power || state.Brightness != 80 {
+		t.Fatalf("expected bulb off with brightness 80 stored, got %+v", state)
+	}
+
+	if err := client.SendCommandAckContext(context.Background(), bulb.IP(), bulb.Port(), "setState", map[string]interface{}{"state": true}); err != nil {
+		t.Fatalf("power on failed: %v", err)
+	}
+	if state := bulb.State(); !state.Power || state.Brightness != 80 {
+		t.Fatalf("expected light back at 80%%, got %+v", state)
+	}
+}
+
+func TestFadeStopsWhenCancelled(t *testing.T) {
+	bulb, err := wiztest.Start(wiztest.Config{Power: true, Brightness: 100})
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	defer bulb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = fastClient(t).FadeTo(ctx, bulb.IP(), bulb.Port(), wiz.PilotState{Power: true, Brightness: 10}, time.Minute)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+	if bulb.State().Brightness <= 10 {
+		t.Fatalf("expected fade to stop early, got brightness %d", bulb.State().Brightness)
+	}
+}
@@ -0,0 +1,140 @@
+package wiztest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"wiz-tui/internal/wiz"
+	"wiz-tui/internal/wiztest"
+)
+
+func startBulb(t *testing.T, cfg wiztest.Config) *wiztest.Bulb {
+	t.Helper()
+	bulb, err := wiztest.Start(cfg)
+	if err != nil {
+		t.Fatalf("failed to start simulated bulb: %v", err)
+	}
+	t.Cleanup(func() { bulb.Close() })
+	return bulb
+}
+
+func newClient(t *testing.T, opts wiz.Options) *wiz.Client {
+	t.Helper()
+	client := wiz.NewClient(opts)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestBulbKeepsPilotState(t *testing.T) {
+	bulb := startBulb(t, wiztest.Config{ModuleName: "ESP01_SHRGB1C_31"})
+	client := newClient(t, wiz.Options{ReadTimeout: time.Second})
+
+	if err := client.SendCommandAck(bulb.IP(), bulb.Port(), "setPilot", map[string]interface{}{"r": 255, "g": 0, "b": 51, "dimming": 40}); err != nil {
+		t.Fatalf("setPilot failed: %v", err)
+	}
+
+	state, err := client.GetPilotState(bulb.IP(), bulb.Port())
+	if err != nil {
+		t.Fatalf("getPilot failed: %v", err)
+	}
+	if !state.Power || state.Brightness != 40 || state.ColorHex != "#FF0033" {
+		t.Fatalf("unexpected state after setPilot: %+v", state)
+	}
+
+	if err := client.SendCommandAck(bulb.IP(), bulb.Port(), "setState", map[string]interface{}{"state": false}); err != nil {
+		t.Fatalf("setState failed: %v", err)
+	}
+	if bulb.State().Power {
+		t.Fatal("expected bulb to be off after setState false")
+	}
+}
+
+func TestBulbRejectsUnsupportedParams(t *testing.T) {
+	bulb := startBulb(t, wiztest.Config{ModuleName: "ESP01_SHTW1C_31"})
+	client := newClient(t, wiz.Options{ReadTimeout: time.Second})
+
+	err := client.SendCommandAck(bulb.IP(), bulb.Port(), "setPilot", map[string]interface{}{"r": 255, "g": 0, "b": 0})
+	var deviceErr *wiz.DeviceError
+	if !errors.As(err, &deviceErr) || deviceErr.Code != -32602 {
+		t.Fatalf("expected invalid params from tunable white bulb, got: %v", err)
+	}
+}
+
+func TestBulbAnswersDiscovery(t *testing.T) {
+	bulbs, err := wiztest.StartFleet(wiztest.DemoFleet()...)
+	if err != nil {
+		t.Fatalf("failed to start fleet: %v", err)
+	}
+	defer func() {
+		for _, bulb := range bulbs {
+			bulb.Close()
+		}
+	}()
+
+	client := newClient(t, wiz.Options{
+		DiscoveryTargets: wiztest.DiscoveryTargets(bulbs),
+		DiscoveryWindow:  200 * time.Millisecond,
+	})
+	devices, err := client.DiscoverDevices()
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	if len(devices) != len(bulbs) {
+		t.Fatalf("expected %d devices, got %d", len(bulbs), len(devices))
+	}
+
+	kinds := map[wiz.DeviceKind]bool{}
+	for _, device := range devices {
+		kinds[device.Capabilities.Kind] = true
+		if device.Port == "" {
+			t.Fatalf("expected discovered device port, got %+v", device)
+		}
+	}
+	if !kinds[wiz.KindRGB] || !kinds[wiz.KindTunableWhite] || !kinds[wiz.KindPlug] {
+		t.Fatalf("expected RGB, tunable white, and plug devices, got %v", kinds)
+	}
+}
+
+func TestBulbPacketLossIsRetried(t *testing.T) {
+	bulb := startBulb(t, wiztest.Config{LossRate: 1})
+	client := newClient(t, wiz.Options{
+		ReadTimeout: 50 * time.Millisecond,
+		Attempts:    3,
+		Backoff:     func(int) time.Duration { return 0 },
+	})
+
+	if _, err := client.GetPilotState(bulb.IP(), bulb.Port()); !errors.Is(err, wiz.ErrTimeout) {
+		t.Fatalf("expected timeout with total loss, got: %v", err)
+	}
+	if got := bulb.Requests("getPilot"); got != 3 {
+		t.Fatalf("expected 3 attempts to reach the bulb, got %d", got)
+	}
+
+	bulb.SetLossRate(0)
+	if _, err := client.GetPilotState(bulb.IP(), bulb.Port()); err != nil {
+		t.Fatalf("expected success once loss is cleared, got: %v", err)
+	}
+}
+
+func TestBulbInjectedErrorsAndLatency(t *testing.T) {
+	bulb := startBulb(t, wiztest.Config{})
+	client := newClient(t, wiz.Options{ReadTimeout: time.Second, Attempts: 1})
+
+	bulb.FailMethod("setPilot", -32000, "Busy")
+	err := client.SendCommandAck(bulb.IP(), bulb.Port(), "setPilot", map[string]interface{}{"dimming": 50})
+	var deviceErr *wiz.DeviceError
+	if !errors.As(err, &deviceErr) || deviceErr.Message != "Busy" {
+		t.Fatalf("expected injected device error, got: %v", err)
+	}
+	bulb.FailMethod("setPilot", 0, "")
+
+	bulb.SetLatency(150 * time.Millisecond)
+	start := time.Now()
+	if err := client.SendCommandAck(bulb.IP(), bulb.Port(), "setPilot", map[string]interface{}{"dimming": 50}); err != nil {
+		t.Fatalf("expected delayed success, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("expected injected latency, reply took %s", elapsed)
+	}
+}